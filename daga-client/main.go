@@ -0,0 +1,210 @@
+/*daga-client runs a DAGA authentication against a deployed server set and prints the final linkage tag of the client
+
+	daga-client -key client.key -context context.json -server 10.0.0.1:7000
+	daga-client -key client.key -servers servers.txt -server https://daga.example.org
+
+The server is reached over TCP (dagatcp) for a host:port address and over HTTP (dagahttp) for an http(s) URL.
+Without -context, the context is fetched from the HTTP service and used only if its servers are the ones listed in -servers,
+in the formats of daga.ReadPublicKeys, and all of them endorsed it. With -context, -servers optionally checks the servers of the file.
+The password of the key is read from the file given by -passfile, or from the DAGA_PASSWORD environment variable.
+The index of the client is the position of its public key among the clients of the context.
+
+The result is printed on the standard output, as JSON with -json.
+The exit status is 0 when the client is authenticated, 1 when the authentication is rejected by the servers
+and 2 when it cannot be attempted or the servers cannot be reached*/
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"github.com/dedis/student_17_pop_fs/dagahttp"
+	"github.com/dedis/student_17_pop_fs/dagatcp"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//Exit status of the command
+const (
+	exitRejected = 1
+	exitSetup    = 2
+)
+
+//Status of an authentication
+const (
+	statusAccepted = "accepted"
+	statusRejected = "rejected"
+	statusFailed   = "failed"
+)
+
+/*result is the outcome of an authentication
+Stage names the step that failed for a rejected or failed authentication: request, challenge, responses, submission or verification
+An authentication fails when the servers cannot be reached, and is rejected when they refuse the client*/
+type result struct {
+	Status  string
+	Context daga.ContextID
+	Client  int
+	Tag     string `json:",omitempty"`
+	Stage   string `json:",omitempty"`
+	Reason  string `json:",omitempty"`
+}
+
+func main() {
+	keyPath := flag.String("key", "", "encrypted file of the client's private key")
+	passfile := flag.String("passfile", "", "file containing the password of the key (default: $DAGA_PASSWORD)")
+	contextPath := flag.String("context", "", "file of the context (default: the signed context of the HTTP service)")
+	serversPath := flag.String("servers", "", "file of the public keys of the trusted servers, required without -context")
+	server := flag.String("server", "", "server to authenticate with: host:port for TCP or an http(s) URL")
+	timeout := flag.Duration("timeout", time.Minute, "maximum time for each exchange with the servers")
+	asJSON := flag.Bool("json", false, "print the result as JSON")
+	flag.Parse()
+
+	if *keyPath == "" || *server == "" {
+		fmt.Fprintf(os.Stderr, "Usage: daga-client -key file -server address [-context file] [-servers file]\n")
+		flag.PrintDefaults()
+		os.Exit(exitSetup)
+	}
+
+	password, err := daga.ReadPassword(*passfile)
+	if err != nil {
+		fatal(err)
+	}
+	client, err := daga.LoadClient(*keyPath, password)
+	if err != nil {
+		fatal(err)
+	}
+
+	endpoint, context, err := connect(*server, *contextPath, *serversPath, client.Suite(), *timeout)
+	if err != nil {
+		fatal(err)
+	}
+	//The key of the client is found among the members of the context
+	if err = client.FindIndex(context); err != nil {
+		fatal(err)
+	}
+	res := authenticate(&client, context, endpoint)
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(res, "", "\t")
+		fmt.Printf("%s\n", data)
+	} else if res.Status == statusAccepted {
+		fmt.Printf("%s\n", res.Tag)
+	} else if res.Status == statusFailed {
+		fmt.Printf("Failed in %s: %s\n", res.Stage, res.Reason)
+	} else {
+		fmt.Printf("Rejected in %s: %s\n", res.Stage, res.Reason)
+	}
+	switch res.Status {
+	case statusFailed:
+		os.Exit(exitSetup)
+	case statusRejected:
+		os.Exit(exitRejected)
+	}
+}
+
+/*fatal reports an error preventing the authentication and exits*/
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "daga-client: %s\n", err)
+	os.Exit(exitSetup)
+}
+
+/*connect creates the endpoint of the server and loads the context
+An HTTP service provides the signed context when no context file is given, which must be endorsed by the servers of the file serversPath*/
+func connect(server, contextPath, serversPath string, suite abstract.Suite, timeout time.Duration) (daga.ServerEndpoint, *daga.Context, error) {
+	var endpoint daga.ServerEndpoint
+	var service *dagahttp.Client
+	if strings.HasPrefix(server, "http://") || strings.HasPrefix(server, "https://") {
+		service = dagahttp.NewClient(suite, server)
+		service.Timeout = timeout
+		endpoint = service
+	} else {
+		remote := dagatcp.NewRemote(suite, server)
+		remote.Timeout = timeout
+		endpoint = remote
+	}
+
+	var servers []abstract.Point
+	if serversPath != "" {
+		var err error
+		if servers, err = daga.LoadPublicKeys(suite, serversPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if contextPath != "" {
+		context, err := daga.LoadContext(contextPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = daga.ValidateContext(context, servers); err != nil {
+			return nil, nil, err
+		}
+		return endpoint, context, nil
+	}
+	if service == nil {
+		return nil, nil, fmt.Errorf("A context file is required with a TCP server")
+	}
+	if servers == nil {
+		return nil, nil, fmt.Errorf("A file of the trusted servers is required to fetch the signed context")
+	}
+	signed, err := service.FetchSignedContext(servers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Cannot get the signed context: %s", err)
+	}
+	return endpoint, signed.Context(), nil
+}
+
+/*authenticate runs the steps of a session one by one, so that a rejection names the step that failed*/
+func authenticate(client *daga.Client, context *daga.Context, endpoint daga.ServerEndpoint) result {
+	res := result{Client: client.GetIndex()}
+	res.Context, _ = context.ID()
+	reject := func(stage string, err error) result {
+		res.Status, res.Stage, res.Reason = statusRejected, stage, err.Error()
+		if _, ok := err.(*daga.TransportError); ok {
+			res.Status = statusFailed
+		}
+		return res
+	}
+
+	session, err := daga.NewClientSession(client, context)
+	if err != nil {
+		return reject("request", err)
+	}
+	defer session.Abort()
+	t, err := session.Commit()
+	if err != nil {
+		return reject("request", err)
+	}
+	challenge, err := endpoint.RequestChallenge(t)
+	if err != nil {
+		return reject("challenge", err)
+	}
+	msg, err := session.Answer(challenge)
+	if err != nil {
+		return reject("responses", err)
+	}
+	servmsg, err := endpoint.SubmitMessage(msg)
+	if err != nil {
+		return reject("submission", err)
+	}
+	Tf, err := session.Finalize(servmsg)
+	if err != nil {
+		return reject("verification", err)
+	}
+	//The servers output the neutral element for a client they caught misbehaving
+	if Tf.Equal(context.Suite().Point().Null()) {
+		return reject("verification", fmt.Errorf("Client flagged as misbehaving by the servers"))
+	}
+
+	tag, err := Tf.MarshalBinary()
+	if err != nil {
+		return reject("verification", err)
+	}
+	res.Status, res.Tag = statusAccepted, hex.EncodeToString(tag)
+	return res
+}
@@ -0,0 +1,324 @@
+/*daga-context administrates the contexts of a DAGA deployment
+
+	daga-context create -clients clients.txt -servers servers.txt -commits R.txt -o context.json
+	daga-context inspect -context context.json [-members]
+	daga-context validate -context context.json [-signed signed.json] [-servers servers.txt]
+	daga-context add -context context.json -clients new.txt -o context.json
+	daga-context round -context context.json -commits R.txt -o next.json
+	daga-context endorse -context context.json -o signed.json endorsement0.json endorsement1.json ...
+
+The key and commitment files list the points in the order of the indexes, in the formats of daga.ReadPublicKeys such as the public keys exported by daga-keygen.
+A server publishes its commitment R for a new round with daga-server -rotate, and its endorsement of a context with daga-server -endorse.
+The contexts are written with daga.Context.Save and the signed contexts as a JSON NetSignedContext*/
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//commands maps the name of each subcommand to its implementation
+var commands = map[string]func(args []string) error{
+	"create":   create,
+	"inspect":  inspect,
+	"validate": validate,
+	"add":      add,
+	"round":    round,
+	"endorse":  endorse,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintf(os.Stderr, "Usage: daga-context create|inspect|validate|add|round|endorse [flags]\n")
+		os.Exit(2)
+	}
+	if err := commands[os.Args[1]](os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "daga-context %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+/*parse parses the flags of a subcommand and checks that the required ones are set*/
+func parse(flags *flag.FlagSet, args []string, required ...string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	for _, name := range required {
+		if flags.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("Missing -%s", name)
+		}
+	}
+	return nil
+}
+
+/*create builds the first context of the members from the commitments of the servers*/
+func create(args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	suiteName := flags.String("suite", daga.Suite.String(), "suite of the context")
+	clients := flags.String("clients", "", "file of the public keys of the clients")
+	servers := flags.String("servers", "", "file of the public keys of the servers")
+	commits := flags.String("commits", "", "file of the commitments R of the servers to their round secrets")
+	out := flags.String("o", "", "file of the new context")
+	if err := parse(flags, args, "clients", "servers", "commits", "o"); err != nil {
+		return err
+	}
+
+	suite, err := daga.LookupSuite(*suiteName)
+	if err != nil {
+		return err
+	}
+	G, err := daga.LoadMembers(suite, *clients, *servers)
+	if err != nil {
+		return err
+	}
+	R, err := daga.LoadPublicKeys(suite, *commits)
+	if err != nil {
+		return err
+	}
+	context, err := daga.GenerateContext(suite, G, R)
+	if err != nil {
+		return err
+	}
+	return save(context, *out)
+}
+
+/*inspect prints a summary of a context*/
+func inspect(args []string) error {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	path := flags.String("context", "", "file of the context")
+	members := flags.Bool("members", false, "also list the keys of the members and the commitments of the servers")
+	if err := parse(flags, args, "context"); err != nil {
+		return err
+	}
+
+	context, err := daga.LoadContext(*path)
+	if err != nil {
+		return err
+	}
+	id, err := context.ID()
+	if err != nil {
+		return err
+	}
+	checksum, err := context.Checksum()
+	if err != nil {
+		return err
+	}
+	binary, err := context.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	netcontext, err := context.NetEncode()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(netcontext)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ID\t%s\n", id)
+	fmt.Printf("Checksum\t%s\n", checksum)
+	fmt.Printf("Suite\t%s\n", context.Suite())
+	fmt.Printf("Round\t%d\n", context.Round())
+	fmt.Printf("Clients\t%d\n", len(context.G.X))
+	fmt.Printf("Servers\t%d\n", len(context.G.Y))
+	fmt.Printf("Size\t%d bytes in JSON, %d bytes in binary\n", len(data), len(binary))
+	if err = daga.ValidateContext(context, nil); err != nil {
+		fmt.Printf("Valid\tno\n%s\n", err)
+	} else {
+		fmt.Printf("Valid\tyes\n")
+	}
+
+	if *members {
+		for i, X := range context.G.X {
+			fmt.Printf("X[%d]\t%s\n", i, pointString(X))
+		}
+		for j, Y := range context.G.Y {
+			fmt.Printf("Y[%d]\t%s\n", j, pointString(Y))
+		}
+		for j, R := range context.R {
+			fmt.Printf("R[%d]\t%s\n", j, pointString(R))
+		}
+	}
+	return nil
+}
+
+/*validate checks a context, and that the signed context endorses it when one is given
+The endorsements are checked against the servers of the context, or of the file given by -servers which the context must then hold*/
+func validate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	path := flags.String("context", "", "file of the context")
+	signedPath := flags.String("signed", "", "file of the signed context to check")
+	serversPath := flags.String("servers", "", "file of the public keys of the trusted servers (default: the servers of the context)")
+	if err := parse(flags, args, "context"); err != nil {
+		return err
+	}
+
+	context, err := daga.LoadContext(*path)
+	if err != nil {
+		return err
+	}
+	servers := context.G.Y
+	if *serversPath != "" {
+		if servers, err = daga.LoadPublicKeys(context.Suite(), *serversPath); err != nil {
+			return err
+		}
+	}
+	if err = daga.ValidateContext(context, servers); err != nil {
+		return err
+	}
+	if *signedPath != "" {
+		signed, err := loadSigned(*signedPath)
+		if err != nil {
+			return err
+		}
+		if err = signed.Verify(servers); err != nil {
+			return err
+		}
+		id, _ := context.ID()
+		if signedID, _ := signed.Context().ID(); signedID != id {
+			return fmt.Errorf("Signed context %s is not the context %s", signedID, id)
+		}
+	}
+	fmt.Printf("Context valid\n")
+	return nil
+}
+
+/*add appends clients to a context, in the same round*/
+func add(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ExitOnError)
+	path := flags.String("context", "", "file of the context")
+	clients := flags.String("clients", "", "file of the public keys of the new clients")
+	out := flags.String("o", "", "file of the new context")
+	if err := parse(flags, args, "context", "clients", "o"); err != nil {
+		return err
+	}
+
+	context, err := daga.LoadContext(*path)
+	if err != nil {
+		return err
+	}
+	X, err := daga.LoadPublicKeys(context.Suite(), *clients)
+	if err != nil {
+		return err
+	}
+	next, err := context.AddClients(X)
+	if err != nil {
+		return err
+	}
+	return save(next, *out)
+}
+
+/*round builds the context of the next round from the new commitments of the servers*/
+func round(args []string) error {
+	flags := flag.NewFlagSet("round", flag.ExitOnError)
+	path := flags.String("context", "", "file of the context")
+	commits := flags.String("commits", "", "file of the new commitments R of the servers")
+	out := flags.String("o", "", "file of the context of the next round")
+	if err := parse(flags, args, "context", "commits", "o"); err != nil {
+		return err
+	}
+
+	context, err := daga.LoadContext(*path)
+	if err != nil {
+		return err
+	}
+	R, err := daga.LoadPublicKeys(context.Suite(), *commits)
+	if err != nil {
+		return err
+	}
+	next, err := context.NextRound(R)
+	if err != nil {
+		return err
+	}
+	return save(next, *out)
+}
+
+/*endorse gathers the endorsements of the servers, printed by daga-server -endorse, into a signed context*/
+func endorse(args []string) error {
+	flags := flag.NewFlagSet("endorse", flag.ExitOnError)
+	path := flags.String("context", "", "file of the context")
+	out := flags.String("o", "", "file of the signed context")
+	if err := parse(flags, args, "context", "o"); err != nil {
+		return err
+	}
+
+	context, err := daga.LoadContext(*path)
+	if err != nil {
+		return err
+	}
+	signed, err := daga.NewSignedContext(context)
+	if err != nil {
+		return err
+	}
+	for _, file := range flags.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Cannot read the endorsement: %s", err)
+		}
+		var sig daga.NetServerSignature
+		if err = json.Unmarshal(data, &sig); err != nil {
+			return fmt.Errorf("Cannot json unmarshal %s: %s", file, err)
+		}
+		if err = signed.AddSignature(sig.Index, sig.Sig); err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+	}
+	if err = signed.Verify(context.G.Y); err != nil {
+		return err
+	}
+	netsigned, err := signed.NetEncode()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(netsigned, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(*out, data, 0644); err != nil {
+		return fmt.Errorf("Cannot write the signed context: %s", err)
+	}
+	return nil
+}
+
+/*save validates the context before writing it and prints its ID*/
+func save(context *daga.Context, path string) error {
+	if err := daga.ValidateContext(context, nil); err != nil {
+		return err
+	}
+	if err := context.Save(path); err != nil {
+		return err
+	}
+	id, _ := context.ID()
+	fmt.Printf("Context %s written to %s\n", id, path)
+	return nil
+}
+
+/*loadSigned reads a signed context written by endorse*/
+func loadSigned(path string) (*daga.SignedContext, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the signed context: %s", err)
+	}
+	var netsigned daga.NetSignedContext
+	if err = json.Unmarshal(data, &netsigned); err != nil {
+		return nil, fmt.Errorf("Cannot json unmarshal the signed context: %s", err)
+	}
+	return netsigned.NetDecode()
+}
+
+/*pointString returns the hex encoding of the point*/
+func pointString(point abstract.Point) string {
+	data, err := point.MarshalBinary()
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	return hex.EncodeToString(data)
+}
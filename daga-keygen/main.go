@@ -0,0 +1,227 @@
+/*daga-keygen creates the keys of the DAGA clients and servers and exports their public keys
+
+	daga-keygen client -o client.key -pub client.pub
+	daga-keygen server -index 0 -o server.key -pub server.pub -commit server.R
+	daga-keygen export -key client.key -format armor
+	daga-keygen import -o clients.txt alice.pub bob.pub ...
+
+The private keys are written encrypted with the password read from the file given by -passfile, or from the DAGA_PASSWORD environment variable.
+The public keys are exported in hex, base64 or armor (a PEM block), and a server also exports the commitment R to its first round secret.
+import gathers the public keys collected from the members into a single list, in the order of the files, for daga-context create.
+The index of a client is not chosen with its key: daga-client finds it from the public key of the client in the context*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//commands maps the name of each subcommand to its implementation
+var commands = map[string]func(args []string) error{
+	"client": client,
+	"server": server,
+	"export": export,
+	"import": importKeys,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintf(os.Stderr, "Usage: daga-keygen client|server|export|import [flags]\n")
+		os.Exit(2)
+	}
+	if err := commands[os.Args[1]](os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "daga-keygen %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+/*keyFlags are the flags shared by the generation of the client and server keys*/
+type keyFlags struct {
+	suite    *string
+	out      *string
+	pub      *string
+	format   *string
+	passfile *string
+	force    *bool
+}
+
+func newKeyFlags(flags *flag.FlagSet) keyFlags {
+	return keyFlags{
+		suite:    flags.String("suite", daga.Suite.String(), "suite of the key"),
+		out:      flags.String("o", "", "file of the encrypted private key"),
+		pub:      flags.String("pub", "", "file of the public key (default: standard output)"),
+		format:   flags.String("format", daga.KeyFormatHex, "format of the public key: hex, base64 or armor"),
+		passfile: flags.String("passfile", "", "file containing the password of the key (default: $DAGA_PASSWORD)"),
+		force:    flags.Bool("force", false, "overwrite an existing key"),
+	}
+}
+
+/*check verifies the flags and returns the suite and the password of the key*/
+func (kf keyFlags) check() (abstract.Suite, []byte, error) {
+	if *kf.out == "" {
+		return nil, nil, fmt.Errorf("-o is required")
+	}
+	if _, err := os.Stat(*kf.out); err == nil && !*kf.force {
+		return nil, nil, fmt.Errorf("%s exists, use -force to overwrite it", *kf.out)
+	}
+	suite, err := daga.LookupSuite(*kf.suite)
+	if err != nil {
+		return nil, nil, err
+	}
+	password, err := daga.ReadPassword(*kf.passfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return suite, password, nil
+}
+
+/*client generates the key of a client*/
+func client(args []string) error {
+	flags := flag.NewFlagSet("client", flag.ExitOnError)
+	kf := newKeyFlags(flags)
+	flags.Parse(args)
+	suite, password, err := kf.check()
+	if err != nil {
+		return err
+	}
+
+	c, err := daga.CreateClient(suite, 0, nil)
+	if err != nil {
+		return err
+	}
+	if err = c.SaveKey(*kf.out, password); err != nil {
+		return err
+	}
+	return writeKey(suite, c.GetPublicKey(), *kf.format, *kf.pub)
+}
+
+/*server generates the key of a server and its first round secret*/
+func server(args []string) error {
+	flags := flag.NewFlagSet("server", flag.ExitOnError)
+	kf := newKeyFlags(flags)
+	index := flags.Int("index", -1, "index of the server in the context")
+	commit := flags.String("commit", "", "file of the commitment R to the round secret (default: standard output)")
+	flags.Parse(args)
+	if *index < 0 {
+		return fmt.Errorf("-index is required")
+	}
+	suite, password, err := kf.check()
+	if err != nil {
+		return err
+	}
+
+	s, err := daga.CreateServer(suite, *index, nil)
+	if err != nil {
+		return err
+	}
+	R := s.GenerateNewRoundSecret()
+	if err = s.SaveKey(*kf.out, password); err != nil {
+		return err
+	}
+	if err = writeKey(suite, s.GetPublicKey(), *kf.format, *kf.pub); err != nil {
+		return err
+	}
+	return writeKey(suite, R, *kf.format, *commit)
+}
+
+/*export prints the public key of an existing key file*/
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	path := flags.String("key", "", "file of the encrypted private key")
+	isServer := flags.Bool("server", false, "the key is the one of a server")
+	pub := flags.String("pub", "", "file of the public key (default: standard output)")
+	format := flags.String("format", daga.KeyFormatHex, "format of the public key: hex, base64 or armor")
+	passfile := flags.String("passfile", "", "file containing the password of the key (default: $DAGA_PASSWORD)")
+	flags.Parse(args)
+	if *path == "" {
+		return fmt.Errorf("-key is required")
+	}
+	password, err := daga.ReadPassword(*passfile)
+	if err != nil {
+		return err
+	}
+
+	if *isServer {
+		s, err := daga.LoadServer(*path, password)
+		if err != nil {
+			return err
+		}
+		return writeKey(s.Suite(), s.GetPublicKey(), *format, *pub)
+	}
+	c, err := daga.LoadClient(*path, password)
+	if err != nil {
+		return err
+	}
+	return writeKey(c.Suite(), c.GetPublicKey(), *format, *pub)
+}
+
+/*importKeys concatenates the lists of public keys of the files into a single list*/
+func importKeys(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	suiteName := flags.String("suite", daga.Suite.String(), "suite of the keys")
+	out := flags.String("o", "", "file of the list (default: standard output)")
+	format := flags.String("format", daga.KeyFormatHex, "format of the list: hex, base64 or armor")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return fmt.Errorf("No file to import")
+	}
+	suite, err := daga.LookupSuite(*suiteName)
+	if err != nil {
+		return err
+	}
+
+	var keys []abstract.Point
+	//The keys of different files may be repeated
+	origins := make(map[string]string)
+	for _, path := range flags.Args() {
+		loaded, err := daga.LoadPublicKeys(suite, path)
+		if err != nil {
+			return err
+		}
+		for _, key := range loaded {
+			id := key.String()
+			if origin, ok := origins[id]; ok {
+				return fmt.Errorf("Key of %s repeated from %s", path, origin)
+			}
+			origins[id] = path
+		}
+		keys = append(keys, loaded...)
+	}
+	var list bytes.Buffer
+	for i, key := range keys {
+		fmt.Fprintf(&list, "# %d\n", i)
+		encoded, err := daga.EncodePublicKey(suite, key, *format)
+		if err != nil {
+			return err
+		}
+		list.WriteString(encoded)
+	}
+	return writeOutput(list.String(), *out)
+}
+
+/*writeKey exports the key in the format to the file, or to the standard output if path is empty*/
+func writeKey(suite abstract.Suite, key abstract.Point, format, path string) error {
+	encoded, err := daga.EncodePublicKey(suite, key, format)
+	if err != nil {
+		return err
+	}
+	return writeOutput(encoded, path)
+}
+
+/*writeOutput writes the text to the file, or to the standard output if path is empty*/
+func writeOutput(text, path string) error {
+	if path == "" {
+		fmt.Print(text)
+		return nil
+	}
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		return fmt.Errorf("Cannot write %s: %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*config is the JSON configuration of a server
+The paths are relative to the directory of the configuration file
+Peers holds the TCP address of every server of the context, indexed like its G.Y
+Listen defaults to the address of the server in Peers and HTTP, the address of the client-facing API, is optional
+Timeout and RoundTimeout are durations such as "10s", the dagatcp defaults being used when empty*/
+type config struct {
+	Key           string
+	PasswordFile  string
+	Context       string
+	SignedContext string
+	Peers         []string
+	Listen        string
+	HTTP          string
+	Timeout       string
+	RoundTimeout  string
+}
+
+/*setup is the server loaded from a configuration*/
+type setup struct {
+	server       daga.Server
+	keyPath      string
+	password     []byte
+	context      *daga.Context
+	signed       *daga.SignedContext
+	peers        []string
+	listen       string
+	http         string
+	timeout      time.Duration
+	roundTimeout time.Duration
+}
+
+/*loadConfig reads the configuration file, the key and the context of the server and checks that they match*/
+func loadConfig(path string) (*setup, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the configuration: %s", err)
+	}
+	var conf config
+	if err = json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("Cannot json unmarshal the configuration: %s", err)
+	}
+	if conf.Key == "" || conf.Context == "" {
+		return nil, fmt.Errorf("Key and Context are required")
+	}
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	s := &setup{peers: conf.Peers, listen: conf.Listen, http: conf.HTTP}
+	if s.timeout, err = parseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("Invalid Timeout: %s", err)
+	}
+	if s.roundTimeout, err = parseDuration(conf.RoundTimeout); err != nil {
+		return nil, fmt.Errorf("Invalid RoundTimeout: %s", err)
+	}
+
+	if s.password, err = daga.ReadPassword(resolve(conf.PasswordFile)); err != nil {
+		return nil, err
+	}
+	s.keyPath = resolve(conf.Key)
+	if s.server, err = daga.LoadServer(s.keyPath, s.password); err != nil {
+		return nil, err
+	}
+	if s.context, err = daga.LoadContext(resolve(conf.Context)); err != nil {
+		return nil, err
+	}
+	if err = daga.ValidateContext(s.context, nil); err != nil {
+		return nil, err
+	}
+	if err = s.server.CheckContext(s.context); err != nil {
+		return nil, err
+	}
+	if len(s.peers) != len(s.context.G.Y) {
+		return nil, fmt.Errorf("Wrong number of peers: got %d expected %d", len(s.peers), len(s.context.G.Y))
+	}
+	if s.listen == "" {
+		s.listen = s.peers[s.server.GetIndex()]
+	}
+
+	if conf.SignedContext != "" {
+		if s.signed, err = loadSignedContext(resolve(conf.SignedContext), s.context.G.Y); err != nil {
+			return nil, err
+		}
+		id, _ := s.context.ID()
+		if signedID, _ := s.signed.Context().ID(); signedID != id {
+			return nil, fmt.Errorf("Signed context %s is not the context %s", signedID, id)
+		}
+	}
+	return s, nil
+}
+
+/*parseDuration parses a duration of the configuration, an empty one being 0*/
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+/*loadSignedContext reads a NetSignedContext from a JSON file and verifies that it is endorsed by all the given servers*/
+func loadSignedContext(path string, servers []abstract.Point) (*daga.SignedContext, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the signed context: %s", err)
+	}
+	var netsigned daga.NetSignedContext
+	if err = json.Unmarshal(data, &netsigned); err != nil {
+		return nil, fmt.Errorf("Cannot json unmarshal the signed context: %s", err)
+	}
+	signed, err := netsigned.NetDecode()
+	if err != nil {
+		return nil, err
+	}
+	if err = signed.Verify(servers); err != nil {
+		return nil, fmt.Errorf("Invalid signed context: %s", err)
+	}
+	return signed, nil
+}
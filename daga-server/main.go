@@ -0,0 +1,140 @@
+/*daga-server runs a DAGA server of a deployed server set
+
+	daga-server -config server.json
+	daga-server -config server.json -endorse > endorsement.json
+	daga-server -config server.json -rotate >> commits.txt
+
+The configuration file gives the key, the context and the address of all the servers, see config.
+The server takes part in the challenge generations and the authentications led by its peers over TCP,
+and leads them for the clients connecting to its TCP address or to its HTTP API (dagahttp) when one is configured.
+
+With -endorse, the server prints its signature of the context as a NetServerSignature and exits.
+The signatures of all the servers form the SignedContext that the HTTP API serves to the clients.
+With -rotate, the server replaces its round secret by the one of the next round, saves it in its key file and prints the hex encoded commitment R to give to daga-context round.
+The secret of the current round is erased, so the server cannot serve the current context anymore.
+The server stops on SIGINT or SIGTERM*/
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"github.com/dedis/student_17_pop_fs/dagahttp"
+	"github.com/dedis/student_17_pop_fs/dagatcp"
+)
+
+func main() {
+	configPath := flag.String("config", "", "JSON configuration of the server")
+	endorse := flag.Bool("endorse", false, "print the signature of the context by the server and exit")
+	rotate := flag.Bool("rotate", false, "move the server to the next round, print its new commitment and exit")
+	flag.Parse()
+
+	if *configPath == "" {
+		fmt.Fprintf(os.Stderr, "Usage: daga-server -config file [-endorse|-rotate]\n")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	s, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("daga-server: %s", err)
+	}
+	if *endorse {
+		if err = printEndorsement(s); err != nil {
+			log.Fatalf("daga-server: %s", err)
+		}
+		return
+	}
+	if *rotate {
+		if err = rotateRound(s); err != nil {
+			log.Fatalf("daga-server: %s", err)
+		}
+		return
+	}
+	if err = serve(s); err != nil {
+		log.Fatalf("daga-server: %s", err)
+	}
+}
+
+/*printEndorsement writes the signature of the context by the server on the standard output*/
+func printEndorsement(s *setup) error {
+	sig, err := s.server.SignContext(s.context)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(daga.NetServerSignature{Index: s.server.GetIndex(), Sig: sig}, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", data)
+	return nil
+}
+
+/*rotateRound generates the round secret of the next round, saves it and prints its commitment*/
+func rotateRound(s *setup) error {
+	R, err := s.server.RotateKey(s.context, s.keyPath, s.password)
+	if err != nil {
+		return err
+	}
+	data, err := R.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", hex.EncodeToString(data))
+	return nil
+}
+
+/*serve runs the node and the HTTP API until a signal stops the server*/
+func serve(s *setup) error {
+	node, err := dagatcp.NewNode(&s.server, s.context, s.peers)
+	if err != nil {
+		return err
+	}
+	if s.timeout != 0 {
+		node.Timeout = s.timeout
+	}
+	if s.roundTimeout != 0 {
+		node.RoundTimeout = s.roundTimeout
+	}
+	id, _ := s.context.ID()
+	errs := make(chan error, 2)
+
+	go func() {
+		errs <- node.ListenAndServe(s.listen)
+	}()
+	log.Printf("Server %d of context %s listening for its peers on %s", s.server.GetIndex(), id, s.listen)
+
+	var api *http.Server
+	if s.http != "" {
+		service, err := dagahttp.NewService(node, s.signed)
+		if err != nil {
+			node.Close()
+			return err
+		}
+		api = &http.Server{Addr: s.http, Handler: service}
+		go func() {
+			errs <- api.ListenAndServe()
+		}()
+		log.Printf("Serving the clients on http://%s", s.http)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-signals:
+		log.Printf("Stopping on %s", sig)
+	case err = <-errs:
+	}
+	if api != nil {
+		api.Close()
+	}
+	node.Close()
+	return err
+}
@@ -0,0 +1,94 @@
+package daga
+
+import (
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+/*verifyServerProofs checks all the proofs of the servers that processed the message
+The proofs of the servers that behaved normally are checked together by batchVerifyServerProofs, only when the batch fails are they checked one by one to find the culprit
+It returns -1 when all the proofs are valid, otherwise the index of the first invalid one*/
+func verifyServerProofs(context *Context, msg *ServerMessage) int {
+	if context == nil || msg == nil {
+		return 0
+	}
+	var batch []int
+	for i := range msg.proofs {
+		p := msg.proofs[i]
+		if p.r2 == nil {
+			if i >= len(msg.indexes) || !verifyMisbehavingProof(context, msg.indexes[i], &p, msg.request.sArray[0]) {
+				return i
+			}
+		} else {
+			batch = append(batch, i)
+		}
+	}
+	if batchVerifyServerProofs(context, msg, batch) {
+		return -1
+	}
+	for _, i := range batch {
+		if !verifyServerProof(context, i, msg) {
+			return i
+		}
+	}
+	//The batch failed with valid proofs, which only happens with negligible probability
+	return -1
+}
+
+/*batchVerifyServerProofs checks the proofs of the given indexes of the message together
+The challenge of each proof is first checked against its commitments t1, t2, t3. The three relations verified by verifyServerProof for every proof,
+	t1 = Tprevious*r1 - T*r2
+	t2 = g*r1 + R*c
+	t3 = S_{k+1}*r2 + S_{k+2}*c
+are then multiplied by random weights and summed, the sum being the neutral element only if every relation holds (except with negligible probability).
+The whole sum is computed by a single MultiMul instead of six multiplications per proof*/
+func batchVerifyServerProofs(context *Context, msg *ServerMessage, indexes []int) bool {
+	suite := context.Suite()
+	var points []abstract.Point
+	var scalars []abstract.Scalar
+	//The products of the generator are gathered in a single term
+	gExp := suite.Scalar().Zero()
+
+	for _, i := range indexes {
+		if i < 0 || i >= len(msg.proofs) || i >= len(msg.tags) || i >= len(msg.indexes) {
+			return false
+		}
+		p := msg.proofs[i]
+		if p.c == nil || p.t1 == nil || p.t2 == nil || p.t3 == nil || p.r1 == nil || p.r2 == nil {
+			return false
+		}
+		index := msg.indexes[i]
+		if index < 0 || index >= len(context.R) || index+2 >= len(msg.request.sArray) {
+			return false
+		}
+		var Tprevious abstract.Point
+		if i == 0 {
+			Tprevious = msg.request.t0
+		} else {
+			Tprevious = msg.tags[i-1]
+		}
+		if !serverProofChallenge(context, index, Tprevious, msg.tags[i], msg, p.t1, p.t2, p.t3).Equal(p.c) {
+			return false
+		}
+
+		w1 := suite.Scalar().Pick(random.Stream)
+		w2 := suite.Scalar().Pick(random.Stream)
+		w3 := suite.Scalar().Pick(random.Stream)
+		points = append(points, Tprevious, msg.tags[i], p.t1,
+			context.R[index], p.t2,
+			msg.request.sArray[index+1], msg.request.sArray[index+2], p.t3)
+		scalars = append(scalars, suite.Scalar().Mul(w1, p.r1), suite.Scalar().Neg(suite.Scalar().Mul(w1, p.r2)), suite.Scalar().Neg(w1),
+			suite.Scalar().Mul(w2, p.c), suite.Scalar().Neg(w2),
+			suite.Scalar().Mul(w3, p.r2), suite.Scalar().Mul(w3, p.c), suite.Scalar().Neg(w3))
+		gExp.Add(gExp, suite.Scalar().Mul(w2, p.r1))
+	}
+	if len(points) == 0 {
+		return true
+	}
+
+	sum, err := MultiMul(suite, append(points, nil), append(scalars, gExp))
+	if err != nil {
+		return false
+	}
+	return sum.Equal(suite.Point().Null())
+}
@@ -0,0 +1,124 @@
+package daga
+
+import (
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+func processedServerMessage(t testing.TB, c, s int) (*Context, *ServerMessage) {
+	clients, servers, context, _ := generateTestContext(c, s)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	request, err := clients[rand.Intn(len(clients))].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	msg, err := endpoint.SubmitMessage(request)
+	if err != nil {
+		t.Fatalf("Cannot process the request: %s", err)
+	}
+	return context, msg
+}
+
+func TestVerifyServerProofs(t *testing.T) {
+	context, msg := processedServerMessage(t, rand.Intn(5)+1, rand.Intn(5)+2)
+
+	//Normal execution
+	if i := verifyServerProofs(context, msg); i != -1 {
+		t.Errorf("Valid proofs rejected at index %d", i)
+	}
+	all := make([]int, len(msg.proofs))
+	for i := range all {
+		all[i] = i
+	}
+	if !batchVerifyServerProofs(context, msg, all) {
+		t.Error("Valid proofs rejected by the batch")
+	}
+
+	//Wrong response, the challenge still matching the commitments
+	i := rand.Intn(len(msg.proofs))
+	r1 := msg.proofs[i].r1
+	msg.proofs[i].r1 = suite.Scalar().Add(r1, suite.Scalar().One())
+	if batchVerifyServerProofs(context, msg, all) {
+		t.Error("Wrong check: Response in batch")
+	}
+	if culprit := verifyServerProofs(context, msg); culprit != i {
+		t.Errorf("Wrong culprit: %d instead of %d", culprit, i)
+	}
+	msg.proofs[i].r1 = r1
+
+	//Wrong commitment
+	t3 := msg.proofs[i].t3
+	msg.proofs[i].t3 = suite.Point().Add(t3, suite.Point().Base())
+	if culprit := verifyServerProofs(context, msg); culprit != i {
+		t.Errorf("Wrong culprit: %d instead of %d", culprit, i)
+	}
+	msg.proofs[i].t3 = t3
+
+	//Empty inputs
+	if verifyServerProofs(nil, msg) == -1 || verifyServerProofs(context, nil) == -1 {
+		t.Error("Wrong check: Empty inputs")
+	}
+	if batchVerifyServerProofs(context, msg, []int{len(msg.proofs)}) {
+		t.Error("Wrong check: Index out of range")
+	}
+}
+
+/*TestVerifyServerProofs_Misbehaving checks the proofs of a misbehaving client processed from another entry server than server 0
+Each misbehaving proof is checked against the key of the server that made it, not the one of its position in the message*/
+func TestVerifyServerProofs_Misbehaving(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(2, 3)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	T0, S, s, _ := clients[0].CreateRequest(context)
+	tclient, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	challenge, err := endpoint.RequestChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot generate the challenge: %s", err)
+	}
+	c, r, _ := clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	//Wrong commitment for server 0, the proof of the client only covers the last one
+	S[2] = suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	request := clients[0].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+
+	//Server 2 behaves normally, servers 0 and 1 find the client misbehaving
+	msg := servers[2].InitializeServerMessage(request)
+	for _, j := range []int{2, 0, 1} {
+		if err = servers[j].ServerProtocol(context, msg); err != nil {
+			t.Fatalf("Error in Server Protocol for server %d: %s", j, err)
+		}
+	}
+	if msg.proofs[1].r2 != nil || msg.proofs[2].r2 != nil {
+		t.Fatal("Misbehaving client not detected")
+	}
+	if i := verifyServerProofs(context, msg); i != -1 {
+		t.Errorf("Valid proofs rejected at index %d", i)
+	}
+	Tf, err := clients[0].GetFinalLinkageTag(context, msg)
+	if err != nil || !Tf.Equal(suite.Point().Null()) {
+		t.Errorf("Wrong final linkage tag for a misbehaving client: %s", err)
+	}
+
+	//A misbehaving proof checked against another server
+	msg.indexes[1], msg.indexes[2] = msg.indexes[2], msg.indexes[1]
+	if i := verifyServerProofs(context, msg); i != 1 {
+		t.Errorf("Wrong culprit: %d instead of 1", i)
+	}
+}
+
+func benchmarkVerifyServerProofs(b *testing.B, batch bool) {
+	context, msg := processedServerMessage(b, 4, 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if batch {
+			verifyServerProofs(context, msg)
+		} else {
+			for j := range msg.proofs {
+				verifyServerProof(context, j, msg)
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyServerProofs_Separate(b *testing.B) { benchmarkVerifyServerProofs(b, false) }
+func BenchmarkVerifyServerProofs_Batch(b *testing.B)    { benchmarkVerifyServerProofs(b, true) }
@@ -0,0 +1,553 @@
+package daga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//Provides a compact binary encoding of the messages, as an alternative to the JSON compatible Net* structures
+//Every encoding starts with the version of the format and the kind of message
+//Points, scalars, byte strings and arrays are prefixed with their length as an unsigned varint
+
+//binaryVersion is the version of the binary format produced by the encoders
+const binaryVersion byte = 2
+
+//Kinds of binary encoded messages
+const (
+	binaryContext byte = iota + 1
+	binaryCommitment
+	binaryChallengeCheck
+	binaryChallenge
+	binaryClientMessage
+	binaryServerMessage
+)
+
+/*binaryWriter accumulates the encoding of a message
+The first error is kept and stops all the following writes*/
+type binaryWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+/*binaryReader consumes the encoding of a message
+The first error is kept and stops all the following reads*/
+type binaryReader struct {
+	suite abstract.Suite
+	data  []byte
+	err   error
+}
+
+func newBinaryWriter(kind byte) *binaryWriter {
+	w := &binaryWriter{}
+	w.buf.WriteByte(binaryVersion)
+	w.buf.WriteByte(kind)
+	return w
+}
+
+func (w *binaryWriter) bytes() ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return w.buf.Bytes(), nil
+}
+
+func (w *binaryWriter) uvarint(v uint64) {
+	if w.err != nil {
+		return
+	}
+	temp := make([]byte, binary.MaxVarintLen64)
+	w.buf.Write(temp[:binary.PutUvarint(temp, v)])
+}
+
+func (w *binaryWriter) index(i int) {
+	if i < 0 {
+		w.fail(fmt.Errorf("Negative index: %d", i))
+		return
+	}
+	w.uvarint(uint64(i))
+}
+
+func (w *binaryWriter) flag(b bool) {
+	if b {
+		w.uvarint(1)
+	} else {
+		w.uvarint(0)
+	}
+}
+
+func (w *binaryWriter) blob(data []byte) {
+	w.uvarint(uint64(len(data)))
+	if w.err == nil {
+		w.buf.Write(data)
+	}
+}
+
+func (w *binaryWriter) point(p abstract.Point) {
+	if w.err != nil {
+		return
+	}
+	if p == nil {
+		w.fail(fmt.Errorf("Empty point"))
+		return
+	}
+	data, err := p.MarshalBinary()
+	if err != nil {
+		w.fail(fmt.Errorf("Encode error\n%s", err))
+		return
+	}
+	w.blob(data)
+}
+
+func (w *binaryWriter) scalar(s abstract.Scalar) {
+	if w.err != nil {
+		return
+	}
+	if s == nil {
+		w.fail(fmt.Errorf("Empty scalar"))
+		return
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		w.fail(fmt.Errorf("Encode error\n%s", err))
+		return
+	}
+	w.blob(data)
+}
+
+func (w *binaryWriter) points(points []abstract.Point) {
+	w.uvarint(uint64(len(points)))
+	for _, p := range points {
+		w.point(p)
+	}
+}
+
+func (w *binaryWriter) scalars(scalars []abstract.Scalar) {
+	w.uvarint(uint64(len(scalars)))
+	for _, s := range scalars {
+		w.scalar(s)
+	}
+}
+
+func (w *binaryWriter) sigs(sigs []serverSignature) {
+	w.uvarint(uint64(len(sigs)))
+	for _, sig := range sigs {
+		w.index(sig.index)
+		w.blob(sig.sig)
+	}
+}
+
+func (w *binaryWriter) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+/*newBinaryReader checks the header of an encoding and returns a reader for its content*/
+func newBinaryReader(suite abstract.Suite, data []byte, kind byte) (*binaryReader, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("Truncated header")
+	}
+	if data[0] != binaryVersion {
+		return nil, fmt.Errorf("Unsupported version: %d", data[0])
+	}
+	if data[1] != kind {
+		return nil, fmt.Errorf("Wrong kind of message: got %d expected %d", data[1], kind)
+	}
+	return &binaryReader{suite: suite, data: data[2:]}, nil
+}
+
+/*done returns the first error of the reader, or an error if some data was not consumed*/
+func (r *binaryReader) done() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("%d trailing bytes", len(r.data))
+	}
+	return nil
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail(fmt.Errorf("Invalid length"))
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) index() int {
+	v := r.uvarint()
+	if v > uint64(int(^uint(0)>>1)) {
+		r.fail(fmt.Errorf("Index out of range"))
+		return 0
+	}
+	return int(v)
+}
+
+func (r *binaryReader) flag() bool {
+	v := r.uvarint()
+	if v > 1 {
+		r.fail(fmt.Errorf("Invalid flag: %d", v))
+	}
+	return v == 1
+}
+
+//count reads the number of elements of an array
+//Every element takes at least one byte, which bounds the count by the remaining data
+func (r *binaryReader) count() int {
+	v := r.uvarint()
+	if v > uint64(len(r.data)) {
+		r.fail(fmt.Errorf("Invalid count: %d", v))
+		return 0
+	}
+	return int(v)
+}
+
+func (r *binaryReader) blob() []byte {
+	l := r.count()
+	if r.err != nil {
+		return nil
+	}
+	data := make([]byte, l)
+	copy(data, r.data[:l])
+	r.data = r.data[l:]
+	return data
+}
+
+func (r *binaryReader) point() abstract.Point {
+	return r.decodePoint(decodePoint)
+}
+
+func (r *binaryReader) decodePoint(decode func(abstract.Suite, []byte) (abstract.Point, error)) abstract.Point {
+	data := r.blob()
+	if r.err != nil {
+		return nil
+	}
+	p, err := decode(r.suite, data)
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	return p
+}
+
+func (r *binaryReader) scalar() abstract.Scalar {
+	data := r.blob()
+	if r.err != nil {
+		return nil
+	}
+	s := r.suite.Scalar().Zero()
+	if err := s.UnmarshalBinary(data); err != nil {
+		r.fail(fmt.Errorf("Decode error\n%s", err))
+		return nil
+	}
+	return s
+}
+
+func (r *binaryReader) points() []abstract.Point {
+	n := r.count()
+	var points []abstract.Point
+	for i := 0; i < n && r.err == nil; i++ {
+		points = append(points, r.point())
+	}
+	return points
+}
+
+//tags reads linkage tags, which may be the neutral element
+func (r *binaryReader) tags() []abstract.Point {
+	n := r.count()
+	var tags []abstract.Point
+	for i := 0; i < n && r.err == nil; i++ {
+		tags = append(tags, r.decodePoint(decodeTag))
+	}
+	return tags
+}
+
+func (r *binaryReader) scalars() []abstract.Scalar {
+	n := r.count()
+	var scalars []abstract.Scalar
+	for i := 0; i < n && r.err == nil; i++ {
+		scalars = append(scalars, r.scalar())
+	}
+	return scalars
+}
+
+func (r *binaryReader) sigs() []serverSignature {
+	n := r.count()
+	var sigs []serverSignature
+	for i := 0; i < n && r.err == nil; i++ {
+		index := r.index()
+		sigs = append(sigs, serverSignature{index: index, sig: r.blob()})
+	}
+	return sigs
+}
+
+func (r *binaryReader) digest() (d proofCommitsDigest) {
+	data := r.blob()
+	if r.err != nil {
+		return d
+	}
+	d, err := decodeProofCommitsDigest(data)
+	if err != nil {
+		r.fail(err)
+	}
+	return d
+}
+
+func (r *binaryReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (w *binaryWriter) context(context *Context) {
+	w.blob([]byte(context.Suite().String()))
+	w.uvarint(context.round)
+	w.points(context.G.X)
+	w.points(context.G.Y)
+	w.points(context.R)
+	w.points(context.H)
+}
+
+//context reads a context and switches the reader to its suite
+func (r *binaryReader) context() Context {
+	name := r.blob()
+	if r.err != nil {
+		return Context{}
+	}
+	suite, err := LookupSuite(string(name))
+	if err != nil {
+		r.fail(err)
+		return Context{}
+	}
+	r.suite = suite
+	context := Context{suite: suite, round: r.uvarint()}
+	context.G.X = r.points()
+	context.G.Y = r.points()
+	context.R = r.points()
+	context.H = r.points()
+	return context
+}
+
+func (w *binaryWriter) commitment(com *Commitment) {
+	w.point(com.commit)
+	w.index(com.sig.index)
+	w.blob(com.sig.sig)
+}
+
+func (r *binaryReader) commitment() Commitment {
+	com := Commitment{commit: r.point()}
+	com.sig.index = r.index()
+	com.sig.sig = r.blob()
+	return com
+}
+
+func (w *binaryWriter) clientMessage(msg *ClientMessage) {
+	w.blob(msg.contextID[:])
+	w.context(&msg.context)
+	w.points(msg.sArray)
+	w.point(msg.t0)
+	w.scalar(msg.proof.cs)
+	w.sigs(msg.proof.sigs)
+	w.points(msg.proof.t)
+	w.scalars(msg.proof.c)
+	w.scalars(msg.proof.r)
+	w.flag(msg.proof.nonInteractive)
+}
+
+func (r *binaryReader) clientMessage() ClientMessage {
+	var id ContextID
+	if data := r.blob(); r.err == nil && len(data) != len(id) {
+		r.fail(fmt.Errorf("Wrong context ID length: %d", len(data)))
+	} else {
+		copy(id[:], data)
+	}
+	msg := ClientMessage{contextID: id, context: r.context()}
+	msg.sArray = r.points()
+	msg.t0 = r.point()
+	msg.proof.cs = r.scalar()
+	msg.proof.sigs = r.sigs()
+	msg.proof.t = r.points()
+	msg.proof.c = r.scalars()
+	msg.proof.r = r.scalars()
+	msg.proof.nonInteractive = r.flag()
+	return msg
+}
+
+/*MarshalBinary encodes the context in the binary format, including the name of its suite*/
+func (context *Context) MarshalBinary() ([]byte, error) {
+	w := newBinaryWriter(binaryContext)
+	w.context(context)
+	return w.bytes()
+}
+
+/*UnmarshalContext decodes a context encoded with Context.MarshalBinary*/
+func UnmarshalContext(data []byte) (*Context, error) {
+	r, err := newBinaryReader(nil, data, binaryContext)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for context\n%s", err)
+	}
+	context := r.context()
+	if err = r.done(); err != nil {
+		return nil, fmt.Errorf("Decode error for context\n%s", err)
+	}
+	return &context, nil
+}
+
+/*MarshalBinary encodes the commitment in the binary format*/
+func (com *Commitment) MarshalBinary() ([]byte, error) {
+	w := newBinaryWriter(binaryCommitment)
+	w.commitment(com)
+	return w.bytes()
+}
+
+/*UnmarshalCommitment decodes a commitment encoded with Commitment.MarshalBinary*/
+func UnmarshalCommitment(suite abstract.Suite, data []byte) (*Commitment, error) {
+	r, err := newBinaryReader(suite, data, binaryCommitment)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for commitment\n%s", err)
+	}
+	com := r.commitment()
+	if err = r.done(); err != nil {
+		return nil, fmt.Errorf("Decode error for commitment\n%s", err)
+	}
+	return &com, nil
+}
+
+/*MarshalBinary encodes the challenge check in the binary format*/
+func (chall *ChallengeCheck) MarshalBinary() ([]byte, error) {
+	w := newBinaryWriter(binaryChallengeCheck)
+	w.scalar(chall.cs)
+	w.blob(chall.digest[:])
+	w.sigs(chall.sigs)
+	w.uvarint(uint64(len(chall.commits)))
+	for i := range chall.commits {
+		w.commitment(&chall.commits[i])
+	}
+	w.scalars(chall.openings)
+	return w.bytes()
+}
+
+/*UnmarshalChallengeCheck decodes a challenge check encoded with ChallengeCheck.MarshalBinary*/
+func UnmarshalChallengeCheck(suite abstract.Suite, data []byte) (*ChallengeCheck, error) {
+	r, err := newBinaryReader(suite, data, binaryChallengeCheck)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for challenge check\n%s", err)
+	}
+	chall := ChallengeCheck{cs: r.scalar(), digest: r.digest(), sigs: r.sigs()}
+	n := r.count()
+	for i := 0; i < n && r.err == nil; i++ {
+		chall.commits = append(chall.commits, r.commitment())
+	}
+	chall.openings = r.scalars()
+	if err = r.done(); err != nil {
+		return nil, fmt.Errorf("Decode error for challenge check\n%s", err)
+	}
+	return &chall, nil
+}
+
+/*MarshalBinary encodes the challenge in the binary format*/
+func (chall *Challenge) MarshalBinary() ([]byte, error) {
+	w := newBinaryWriter(binaryChallenge)
+	w.scalar(chall.cs)
+	w.blob(chall.digest[:])
+	w.sigs(chall.sigs)
+	return w.bytes()
+}
+
+/*UnmarshalChallenge decodes a challenge encoded with Challenge.MarshalBinary*/
+func UnmarshalChallenge(suite abstract.Suite, data []byte) (*Challenge, error) {
+	r, err := newBinaryReader(suite, data, binaryChallenge)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for challenge\n%s", err)
+	}
+	chall := Challenge{cs: r.scalar(), digest: r.digest(), sigs: r.sigs()}
+	if err = r.done(); err != nil {
+		return nil, fmt.Errorf("Decode error for challenge\n%s", err)
+	}
+	return &chall, nil
+}
+
+/*MarshalBinary encodes the client message in the binary format, including its context*/
+func (msg *ClientMessage) MarshalBinary() ([]byte, error) {
+	w := newBinaryWriter(binaryClientMessage)
+	w.clientMessage(msg)
+	return w.bytes()
+}
+
+/*UnmarshalClientMessage decodes a client message encoded with ClientMessage.MarshalBinary
+The suite is the one named in the context of the message*/
+func UnmarshalClientMessage(data []byte) (*ClientMessage, error) {
+	r, err := newBinaryReader(nil, data, binaryClientMessage)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for client message\n%s", err)
+	}
+	msg := r.clientMessage()
+	if err = r.done(); err != nil {
+		return nil, fmt.Errorf("Decode error for client message\n%s", err)
+	}
+	return &msg, nil
+}
+
+/*MarshalBinary encodes the server message in the binary format
+The r2 field of a proof is optional since it is not used by the proofs of a misbehaving client*/
+func (msg *ServerMessage) MarshalBinary() ([]byte, error) {
+	w := newBinaryWriter(binaryServerMessage)
+	w.clientMessage(&msg.request)
+	w.points(msg.tags)
+	w.uvarint(uint64(len(msg.proofs)))
+	for _, p := range msg.proofs {
+		w.point(p.t1)
+		w.point(p.t2)
+		w.point(p.t3)
+		w.scalar(p.c)
+		w.scalar(p.r1)
+		w.flag(p.r2 != nil)
+		if p.r2 != nil {
+			w.scalar(p.r2)
+		}
+	}
+	w.uvarint(uint64(len(msg.indexes)))
+	for _, i := range msg.indexes {
+		w.index(i)
+	}
+	w.sigs(msg.sigs)
+	return w.bytes()
+}
+
+/*UnmarshalServerMessage decodes a server message encoded with ServerMessage.MarshalBinary
+The suite is the one named in the context of the request*/
+func UnmarshalServerMessage(data []byte) (*ServerMessage, error) {
+	r, err := newBinaryReader(nil, data, binaryServerMessage)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for server message\n%s", err)
+	}
+	msg := ServerMessage{request: r.clientMessage()}
+	msg.tags = r.tags()
+	n := r.count()
+	for i := 0; i < n && r.err == nil; i++ {
+		//t1 follows the previous tag, it is the neutral element once a client was found misbehaving
+		p := serverProof{t1: r.decodePoint(decodeTag), t2: r.point(), t3: r.point(), c: r.scalar(), r1: r.scalar()}
+		if r.flag() {
+			p.r2 = r.scalar()
+		}
+		msg.proofs = append(msg.proofs, p)
+	}
+	n = r.count()
+	for i := 0; i < n && r.err == nil; i++ {
+		msg.indexes = append(msg.indexes, r.index())
+	}
+	msg.sigs = r.sigs()
+	if err = r.done(); err != nil {
+		return nil, fmt.Errorf("Decode error for server message\n%s", err)
+	}
+	return &msg, nil
+}
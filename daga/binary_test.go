@@ -0,0 +1,183 @@
+package daga
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+func TestBinary_Context(t *testing.T) {
+	p256, _ := LookupSuite("P256")
+	_, _, context, _ := generateTestContextSuite(p256, rand.Intn(10)+1, rand.Intn(10)+1)
+	context.round = uint64(rand.Intn(1000))
+
+	//Normal execution
+	data, err := context.MarshalBinary()
+	if err != nil || data == nil {
+		t.Fatal("Cannot encode the context")
+	}
+	decoded, err := UnmarshalContext(data)
+	if err != nil || decoded.Suite() != p256 || decoded.Round() != context.Round() {
+		t.Fatalf("Cannot decode the context: %s", err)
+	}
+	again, _ := decoded.MarshalBinary()
+	if !bytes.Equal(data, again) {
+		t.Error("Context does not round-trip")
+	}
+
+	//Wrong version, wrong kind, truncated and trailing data
+	wrong := append([]byte{}, data...)
+	wrong[0] = binaryVersion + 1
+	if _, err = UnmarshalContext(wrong); err == nil {
+		t.Error("Wrong check: Unsupported version")
+	}
+	wrong[0], wrong[1] = binaryVersion, binaryChallenge
+	if _, err = UnmarshalContext(wrong); err == nil {
+		t.Error("Wrong check: Wrong kind")
+	}
+	if _, err = UnmarshalContext(data[:len(data)-1]); err == nil {
+		t.Error("Wrong check: Truncated data")
+	}
+	if _, err = UnmarshalContext(append(data, 0)); err == nil {
+		t.Error("Wrong check: Trailing data")
+	}
+	if _, err = UnmarshalContext(nil); err == nil {
+		t.Error("Wrong check: Empty data")
+	}
+}
+
+func TestBinary_Challenge(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+
+	//Commitment
+	commit, _, _ := servers[0].GenerateCommitment(context)
+	data, err := commit.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Cannot encode the commitment: %s", err)
+	}
+	decodedCommit, err := UnmarshalCommitment(suite, data)
+	if err != nil || !decodedCommit.commit.Equal(commit.commit) || !bytes.Equal(decodedCommit.sig.sig, commit.sig.sig) {
+		t.Errorf("Commitment does not round-trip: %s", err)
+	}
+
+	//ChallengeCheck
+	var commits []Commitment
+	var opens []abstract.Scalar
+	for j := range servers {
+		com, open, _ := servers[j].GenerateCommitment(context)
+		commits = append(commits, *com)
+		opens = append(opens, open)
+	}
+	check, err := InitializeChallenge(context, testProofCommitments(context), commits, opens)
+	if err != nil {
+		t.Fatalf("Cannot initialize the challenge: %s", err)
+	}
+	servers[0].CheckUpdateChallenge(context, check)
+	data, err = check.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Cannot encode the challenge check: %s", err)
+	}
+	decodedCheck, err := UnmarshalChallengeCheck(suite, data)
+	if err != nil {
+		t.Fatalf("Cannot decode the challenge check: %s", err)
+	}
+	if err = VerifyCommitmentSignature(context, decodedCheck.commits); err != nil || !decodedCheck.cs.Equal(check.cs) {
+		t.Error("Challenge check does not round-trip")
+	}
+
+	//Challenge
+	T0, _, s, _ := clients[0].CreateRequest(context)
+	tclient, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	challenge, _ := endpoint.RequestChallenge(*tclient)
+	data, err = challenge.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Cannot encode the challenge: %s", err)
+	}
+	decodedChallenge, err := UnmarshalChallenge(suite, data)
+	if err != nil || !decodedChallenge.cs.Equal(challenge.cs) || len(decodedChallenge.sigs) != len(challenge.sigs) {
+		t.Fatalf("Challenge does not round-trip: %s", err)
+	}
+	if _, _, err = clients[0].GenerateProofResponses(context, s, decodedChallenge, v, w); err != nil {
+		t.Errorf("Decoded challenge not accepted by the client: %s", err)
+	}
+
+	//Wrong kind
+	if _, err = UnmarshalChallengeCheck(suite, data); err == nil {
+		t.Error("Wrong check: Wrong kind")
+	}
+}
+
+func TestBinary_Messages(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	i := rand.Intn(len(clients))
+	session, _ := NewClientSession(&clients[i], context)
+	tclient, _ := session.Commit()
+	challenge, _ := endpoint.RequestChallenge(tclient)
+	request, err := session.Answer(challenge)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+
+	//ClientMessage
+	data, err := request.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Cannot encode the client message: %s", err)
+	}
+	decodedRequest, err := UnmarshalClientMessage(data)
+	if err != nil || !verifyClientProof(*decodedRequest, 1, nil) {
+		t.Fatalf("Client message does not round-trip: %s", err)
+	}
+
+	//ServerMessage
+	msg, err := endpoint.SubmitMessage(decodedRequest)
+	if err != nil {
+		t.Fatalf("Cannot process the request: %s", err)
+	}
+	data, err = msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Cannot encode the server message: %s", err)
+	}
+	decodedMsg, err := UnmarshalServerMessage(data)
+	if err != nil {
+		t.Fatalf("Cannot decode the server message: %s", err)
+	}
+	if _, err = clients[i].GetFinalLinkageTag(context, decodedMsg); err != nil {
+		t.Errorf("Decoded server message not accepted by the client: %s", err)
+	}
+
+	//Proof of a misbehaving client without r2
+	proof, _ := servers[0].generateMisbehavingProof(context, request.sArray[0])
+	msg.proofs[0] = *proof
+	data, err = msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Cannot encode a misbehaving proof: %s", err)
+	}
+	decodedMsg, err = UnmarshalServerMessage(data)
+	if err != nil || decodedMsg.proofs[0].r2 != nil || !verifyMisbehavingProof(context, 0, &decodedMsg.proofs[0], request.sArray[0]) {
+		t.Errorf("Misbehaving proof does not round-trip: %s", err)
+	}
+
+	//Empty fields
+	msg.request.t0 = nil
+	if data, err = msg.MarshalBinary(); err == nil || data != nil {
+		t.Error("Wrong check: Empty T0")
+	}
+}
+
+func TestBinary_Size(t *testing.T) {
+	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	challenge, _ := endpoint.RequestChallenge(testProofCommitments(context))
+
+	data, _ := challenge.MarshalBinary()
+	netchall, _ := challenge.NetEncode()
+	netdata, _ := json.Marshal(netchall)
+	if len(data) >= len(netdata) {
+		t.Errorf("Binary encoding is not smaller: %d bytes instead of %d", len(data), len(netdata))
+	}
+}
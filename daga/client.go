@@ -5,53 +5,97 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"sync"
+	"sync/atomic"
 
 	"gopkg.in/dedis/crypto.v0/abstract"
 	"gopkg.in/dedis/crypto.v0/random"
 )
 
-/*Client is used to store the client's private key and index.
+/*Client is used to store the client's private key, index and suite.
 All the client's methods are attached to it*/
 type Client struct {
+	suite   abstract.Suite
 	private abstract.Scalar
 	index   int
 }
 
 /*ClientMessage stores an authentication request message sent by the client to an arbitrarily chosen server*/
 type ClientMessage struct {
-	context ContextEd25519
-	sArray  []abstract.Point
-	t0      abstract.Point
-	proof   ClientProof
+	context   Context
+	contextID ContextID
+	sArray    []abstract.Point
+	t0        abstract.Point
+	proof     ClientProof
 }
 
-/*ClientProof stores the client's proof of his computations*/
+/*ClientProof stores the client's proof of his computations
+In non-interactive mode, cs is derived from a hash instead of being generated by the servers*/
 type ClientProof struct {
-	cs abstract.Scalar
-	t  []abstract.Point
-	c  []abstract.Scalar
-	r  []abstract.Scalar
+	cs             abstract.Scalar
+	sigs           []serverSignature //Signatures of the servers on cs and t, empty in non-interactive mode
+	t              []abstract.Point
+	c              []abstract.Scalar
+	r              []abstract.Scalar
+	nonInteractive bool
 }
 
-//CreateClient is used to initialize a new client with a given index
+//CreateClient is used to initialize a new client with a given index in the given suite
 //If no private key is given, a random one is chosen
-func CreateClient(i int, s abstract.Scalar) (client Client, err error) {
-	if i < 0 {
+func CreateClient(suite abstract.Suite, i int, s abstract.Scalar) (client Client, err error) {
+	if suite == nil || i < 0 {
 		return Client{}, fmt.Errorf("Invalid parameters")
 	}
 	if s == nil {
 		s = suite.Scalar().Pick(random.Stream)
 	}
-	return Client{index: i, private: s}, nil
+	return Client{suite: suite, index: i, private: s}, nil
 }
 
 //GetPublicKey returns the public key associated with a client
 func (client *Client) GetPublicKey() abstract.Point {
-	return suite.Point().Mul(nil, client.private)
+	return client.suite.Point().Mul(nil, client.private)
+}
+
+//GetIndex returns the index of the client in the context
+func (client *Client) GetIndex() int {
+	return client.index
+}
+
+/*FindIndex sets the index of the client to the position of its public key in the members of the context
+A client key does not depend on the context, so its index is only known once the context is*/
+func (client *Client) FindIndex(context *Context) error {
+	if context == nil {
+		return fmt.Errorf("Empty context")
+	}
+	if err := checkSuite(client.suite, context); err != nil {
+		return err
+	}
+	X := client.GetPublicKey()
+	for i, member := range context.G.X {
+		if member != nil && member.Equal(X) {
+			client.index = i
+			return nil
+		}
+	}
+	return fmt.Errorf("Client not in the context")
+}
+
+//Suite returns the suite in which the client runs the protocol
+func (client *Client) Suite() abstract.Suite {
+	return client.suite
 }
 
 /*CreateRequest generates the elements for the authentication request (T0, S) and the generation of the client's proof(s)*/
-func (client *Client) CreateRequest(context *ContextEd25519) (T0 abstract.Point, S []abstract.Point, s abstract.Scalar, err error) {
+func (client *Client) CreateRequest(context *Context) (T0 abstract.Point, S []abstract.Point, s abstract.Scalar, err error) {
+	suite := client.suite
+	if err = checkSuite(suite, context); err != nil {
+		return nil, nil, nil, err
+	}
+	if err = ValidateContext(context, nil); err != nil {
+		return nil, nil, nil, err
+	}
+
 	//Step 1: generate ephemeral DH keys
 	z := suite.Scalar().Pick(random.Stream)
 	Z := suite.Point().Mul(nil, z)
@@ -76,7 +120,11 @@ func (client *Client) CreateRequest(context *ContextEd25519) (T0 abstract.Point,
 		rand := suite.Cipher(shared[i])
 		exp.Mul(exp, suite.Scalar().Pick(rand))
 	}
-	T0 = suite.Point().Mul(context.H[client.index], exp)
+	H, err := context.ClientGenerator(client.index, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	T0 = suite.Point().Mul(H, exp)
 
 	//Computes the commitments
 	S = make([]abstract.Point, len(context.G.Y)+1)
@@ -101,9 +149,15 @@ func (client *Client) CreateRequest(context *ContextEd25519) (T0 abstract.Point,
 }
 
 //GenerateProofCommitments creates and returns the client's commitments t and the random wieghts w
-func (client *Client) GenerateProofCommitments(context *ContextEd25519, T0 abstract.Point, s abstract.Scalar) (t *[]abstract.Point, v, w *[]abstract.Scalar) {
+//It returns nil when the generators of the clients cannot be computed, which CreateRequest already checked
+func (client *Client) GenerateProofCommitments(context *Context, T0 abstract.Point, s abstract.Scalar) (t *[]abstract.Point, v, w *[]abstract.Scalar) {
+	suite := client.suite
+	H, err := context.generators(nil)
+	if err != nil {
+		return nil, nil, nil
+	}
 	//Generates w randomly except for w[client.index] = 0
-	wtemp := make([]abstract.Scalar, len(context.H))
+	wtemp := make([]abstract.Scalar, len(H))
 	w = &wtemp
 	for i := range *w {
 		(*w)[i] = suite.Scalar().Pick(random.Stream)
@@ -111,53 +165,58 @@ func (client *Client) GenerateProofCommitments(context *ContextEd25519, T0 abstr
 	(*w)[client.index] = suite.Scalar().Zero()
 
 	//Generates random v (2 per client)
-	vtemp := make([]abstract.Scalar, 2*len(context.H))
+	vtemp := make([]abstract.Scalar, 2*len(H))
 	v = &vtemp
 	for i := 0; i < len(*v); i++ {
 		(*v)[i] = suite.Scalar().Pick(random.Stream)
 	}
 
 	//Generates the commitments t (3 per clients)
-	ttemp := make([]abstract.Point, 3*len(context.H))
+	//Sm*w_i + g*v_2i+1 is computed as g*(s*w_i + v_2i+1) since Sm = g*s
+	//w and v are secret, so they are only multiplied by the suite and never by the variable-time BaseTable and MultiMul
+	ttemp := make([]abstract.Point, 3*len(H))
 	t = &ttemp
-	for i := 0; i < len(context.H); i++ {
+	for i := 0; i < len(H); i++ {
 		a := suite.Point().Mul(context.G.X[i], (*w)[i])
 		b := suite.Point().Mul(nil, (*v)[2*i])
 		(*t)[3*i] = suite.Point().Add(a, b)
 
-		Sm := suite.Point().Mul(nil, s)
-		c := suite.Point().Mul(Sm, (*w)[i])
-		d := suite.Point().Mul(nil, (*v)[2*i+1])
-		(*t)[3*i+1] = suite.Point().Add(c, d)
+		exp := suite.Scalar().Mul(s, (*w)[i])
+		exp.Add(exp, (*v)[2*i+1])
+		(*t)[3*i+1] = suite.Point().Mul(nil, exp)
 
 		e := suite.Point().Mul(T0, (*w)[i])
-		f := suite.Point().Mul(context.H[i], (*v)[2*i+1])
-		(*t)[3*i+2] = suite.Point().Add(e, f)
+		(*t)[3*i+2] = suite.Point().Add(e, suite.Point().Mul(H[i], (*v)[2*i+1]))
 	}
 
 	return t, v, w
 }
 
 //GenerateProofResponses creates the responses to the challenge cs sent by the servers
-func (client *Client) GenerateProofResponses(context *ContextEd25519, s abstract.Scalar, challenge *Challenge, v, w *[]abstract.Scalar) (c, r *[]abstract.Scalar, err error) {
+func (client *Client) GenerateProofResponses(context *Context, s abstract.Scalar, challenge *Challenge, v, w *[]abstract.Scalar) (c, r *[]abstract.Scalar, err error) {
 	//Check challenge signatures
-	msg, e := challenge.cs.MarshalBinary()
+	msg, e := challengeMessage(challenge.cs, challenge.digest)
 	if e != nil {
-		return nil, nil, fmt.Errorf("Error in challenge conversion: %s", e)
+		return nil, nil, e
 	}
 	for _, sig := range challenge.sigs {
-		e = ECDSAVerify(context.G.Y[sig.index], msg, sig.sig)
+		if sig.index < 0 || sig.index >= len(context.G.Y) {
+			return nil, nil, fmt.Errorf("Wrong index: %d", sig.index)
+		}
+		e = ECDSAVerify(client.suite, context.G.Y[sig.index], msg, sig.sig)
 		if e != nil {
 			return nil, nil, fmt.Errorf("%s", e)
 		}
 	}
 
 	//Generates the c array
+	//The values are copied so that w can be erased without altering the proof
 	var ctemp []abstract.Scalar
 	for _, temp := range *w {
-		ctemp = append(ctemp, temp)
+		ctemp = append(ctemp, temp.Clone())
 	}
 	c = &ctemp
+	suite := client.suite
 	sum := suite.Scalar().Zero()
 	for _, i := range *w {
 		sum = suite.Scalar().Add(sum, i)
@@ -167,7 +226,7 @@ func (client *Client) GenerateProofResponses(context *ContextEd25519, s abstract
 	//Generates the responses
 	var rtemp []abstract.Scalar
 	for _, temp := range *v {
-		rtemp = append(rtemp, temp)
+		rtemp = append(rtemp, temp.Clone())
 	}
 	r = &rtemp
 	a := suite.Scalar().Mul((*c)[client.index], client.private)
@@ -179,37 +238,19 @@ func (client *Client) GenerateProofResponses(context *ContextEd25519, s abstract
 	return c, r, nil
 }
 
-/*verifyClientProof checks the validity of a client's proof*/
-func verifyClientProof(msg ClientMessage) bool {
+/*verifyClientProof checks the validity of a client's proof, sharing the verification of its commitments between the given number of workers
+The generators missing from the context are taken from the cache, which may be nil*/
+func verifyClientProof(msg ClientMessage, workers int, generators *GeneratorCache) bool {
 	check := ValidateClientMessage(&msg)
 	if !check {
 		return false
 	}
 
-	n := len(msg.context.G.X)
+	suite := msg.context.Suite()
 
 	//Check the commitments
-	for i := 0; i < n; i++ {
-		a := suite.Point().Mul(msg.context.G.X[i], msg.proof.c[i])
-		b := suite.Point().Mul(nil, msg.proof.r[2*i])
-		ti0 := suite.Point().Add(a, b)
-		if !ti0.Equal(msg.proof.t[3*i]) {
-			return false
-		}
-
-		c := suite.Point().Mul(msg.sArray[len(msg.sArray)-1], msg.proof.c[i])
-		d := suite.Point().Mul(nil, msg.proof.r[2*i+1])
-		ti10 := suite.Point().Add(c, d)
-		if !ti10.Equal(msg.proof.t[3*i+1]) {
-			return false
-		}
-
-		e := suite.Point().Mul(msg.t0, msg.proof.c[i])
-		f := suite.Point().Mul(msg.context.H[i], msg.proof.r[2*i+1])
-		ti11 := suite.Point().Add(e, f)
-		if !ti11.Equal(msg.proof.t[3*i+2]) {
-			return false
-		}
+	if !verifyClientCommitments(&msg, workers, generators) {
+		return false
 	}
 
 	//Check the challenge
@@ -224,8 +265,202 @@ func verifyClientProof(msg ClientMessage) bool {
 	return true
 }
 
+//baseTableMembers is the number of members from which the verification of a client's proof precomputes the tables of Sm and T0
+const baseTableMembers = 16
+
+/*proofTables holds the tables of the points multiplied for every member of a client's proof, Sm and T0
+They are only read once built, so the workers of verifyClientCommitments share them*/
+type proofTables struct {
+	sm, t0 *BaseTable
+}
+
+/*newProofTables builds the tables of the message, or returns nil when it has too few members for them to pay off*/
+func newProofTables(msg *ClientMessage) *proofTables {
+	if len(msg.context.G.X) < baseTableMembers {
+		return nil
+	}
+	suite := msg.context.Suite()
+	sm, err := NewBaseTable(suite, msg.sArray[len(msg.sArray)-1])
+	if err != nil {
+		return nil
+	}
+	t0, err := NewBaseTable(suite, msg.t0)
+	if err != nil {
+		return nil
+	}
+	return &proofTables{sm: sm, t0: t0}
+}
+
+/*verifyClientCommitments checks the commitments of every member of the client's proof with the given number of workers
+The members are shared between the workers, which all stop as soon as one of them finds a wrong commitment*/
+func verifyClientCommitments(msg *ClientMessage, workers int, generators *GeneratorCache) bool {
+	n := len(msg.context.G.X)
+	H, err := msg.context.generators(generators)
+	if err != nil {
+		return false
+	}
+	tables := newProofTables(msg)
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			if !verifyClientCommitment(msg, H, tables, i) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var failed int32
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func(first int) {
+			defer wg.Done()
+			for i := first; i < n && atomic.LoadInt32(&failed) == 0; i += workers {
+				if !verifyClientCommitment(msg, H, tables, i) {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}(k)
+	}
+	wg.Wait()
+	return failed == 0
+}
+
+/*verifyClientCommitment checks the three commitments of the member i of the client's proof
+The products of Sm and T0 use the tables when there are some, the products of the generator are left to the suite which has a faster multiplication for it*/
+func verifyClientCommitment(msg *ClientMessage, H []abstract.Point, tables *proofTables, i int) bool {
+	suite := msg.context.Suite()
+	Sm := msg.sArray[len(msg.sArray)-1]
+	ci := msg.proof.c[i]
+
+	a := suite.Point().Mul(msg.context.G.X[i], ci)
+	b := suite.Point().Mul(nil, msg.proof.r[2*i])
+	ti0 := suite.Point().Add(a, b)
+	if !ti0.Equal(msg.proof.t[3*i]) {
+		return false
+	}
+
+	var c, ti11 abstract.Point
+	if tables == nil {
+		c = suite.Point().Mul(Sm, ci)
+		ti11 = mulAdd(suite, msg.t0, ci, H[i], msg.proof.r[2*i+1])
+	} else {
+		var err error
+		if c, err = tables.sm.Mul(ci); err != nil {
+			return false
+		}
+		e, err := tables.t0.Mul(ci)
+		if err != nil {
+			return false
+		}
+		ti11 = suite.Point().Add(e, suite.Point().Mul(H[i], msg.proof.r[2*i+1]))
+	}
+	d := suite.Point().Mul(nil, msg.proof.r[2*i+1])
+	ti10 := suite.Point().Add(c, d)
+	if !ti10.Equal(msg.proof.t[3*i+1]) {
+		return false
+	}
+	return ti11.Equal(msg.proof.t[3*i+2])
+}
+
+/*nonInteractiveChallenge derives the challenge of a non-interactive proof from the context, the request (S, T0) and the commitments t*/
+func nonInteractiveChallenge(context *Context, S []abstract.Point, T0 abstract.Point, t []abstract.Point) (cs abstract.Scalar, err error) {
+	if context == nil || T0 == nil || len(S) == 0 || len(t) == 0 {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	hasher := sha512.New()
+	var writer io.Writer = hasher
+	data, e := context.ToBytes()
+	if e != nil {
+		return nil, fmt.Errorf("Error in context: %s", e)
+	}
+	writer.Write(data)
+	data, e = PointArrayToBytes(&S)
+	if e != nil {
+		return nil, fmt.Errorf("Error in S: %s", e)
+	}
+	writer.Write(data)
+	if _, e = T0.MarshalTo(writer); e != nil {
+		return nil, fmt.Errorf("Error in T0: %s", e)
+	}
+	data, e = PointArrayToBytes(&t)
+	if e != nil {
+		return nil, fmt.Errorf("Error in t: %s", e)
+	}
+	writer.Write(data)
+	hash := hasher.Sum(nil)
+	suite := context.Suite()
+	rand := suite.Cipher(hash)
+	return suite.Scalar().Pick(rand), nil
+}
+
+/*CreateNonInteractiveMessage builds a complete ClientMessage without interacting with the servers
+The challenge of the proof is derived from a hash of the context, S, T0 and t (Fiat-Shamir)*/
+func (client *Client) CreateNonInteractiveMessage(context *Context) (msg *ClientMessage, err error) {
+	if context == nil {
+		return nil, fmt.Errorf("Empty context")
+	}
+	T0, S, s, err := client.CreateRequest(context)
+	if err != nil {
+		return nil, err
+	}
+	t, v, w := client.GenerateProofCommitments(context, T0, s)
+	if t == nil {
+		return nil, fmt.Errorf("Cannot generate the commitments")
+	}
+
+	cs, err := nonInteractiveChallenge(context, S, T0, *t)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestProofCommitments(*t)
+	if err != nil {
+		return nil, err
+	}
+	challenge := &Challenge{cs: cs, digest: digest}
+	c, r, err := client.GenerateProofResponses(context, s, challenge, v, w)
+	if err != nil {
+		return nil, err
+	}
+	msg = client.AssembleMessage(context, &S, T0, challenge, t, c, r)
+	if msg == nil {
+		return nil, fmt.Errorf("Cannot assemble the message")
+	}
+	msg.proof.nonInteractive = true
+
+	//The secrets are not needed anymore
+	eraseScalar(s)
+	for _, scalar := range *v {
+		eraseScalar(scalar)
+	}
+	for _, scalar := range *w {
+		eraseScalar(scalar)
+	}
+	return msg, nil
+}
+
+/*verifyNonInteractiveClientProof checks the validity of a non-interactive client's proof
+On top of the checks of verifyClientProof, the challenge must be the hash of the request*/
+func verifyNonInteractiveClientProof(msg ClientMessage, workers int, generators *GeneratorCache) bool {
+	if !msg.proof.nonInteractive {
+		return false
+	}
+	if !verifyClientProof(msg, workers, generators) {
+		return false
+	}
+	cs, err := nonInteractiveChallenge(&msg.context, msg.sArray, msg.t0, msg.proof.t)
+	if err != nil {
+		return false
+	}
+	return cs.Equal(msg.proof.cs)
+}
+
 //AssembleMessage is used to build a Client Message from its various elemnts
-func (client *Client) AssembleMessage(context *ContextEd25519, S *[]abstract.Point, T0 abstract.Point, challenge *Challenge, t *[]abstract.Point, c, r *[]abstract.Scalar) (msg *ClientMessage) {
+//It returns nil if the challenge was not generated for the commitments t
+func (client *Client) AssembleMessage(context *Context, S *[]abstract.Point, T0 abstract.Point, challenge *Challenge, t *[]abstract.Point, c, r *[]abstract.Scalar) (msg *ClientMessage) {
 	//Input checks
 	if context == nil || S == nil || T0 == nil || challenge == nil || t == nil || c == nil || r == nil {
 		return nil
@@ -234,18 +469,39 @@ func (client *Client) AssembleMessage(context *ContextEd25519, S *[]abstract.Poi
 		return nil
 	}
 
-	proof := ClientProof{cs: challenge.cs, t: *t, c: *c, r: *r}
-	return &ClientMessage{context: *context, t0: T0, sArray: *S, proof: proof}
+	id, err := context.ID()
+	if err != nil {
+		return nil
+	}
+
+	//The challenge must have been generated for the commitments t
+	digest, err := digestProofCommitments(*t)
+	if err != nil || digest != challenge.digest {
+		return nil
+	}
+
+	proof := ClientProof{cs: challenge.cs, sigs: append([]serverSignature(nil), challenge.sigs...), t: *t, c: *c, r: *r}
+	return &ClientMessage{context: *context, contextID: id, t0: T0, sArray: *S, proof: proof}
 }
 
 //GetFinalLinkageTag checks the server's signatures and proofs
 //It outputs the final linkage tag of the client
-func (client *Client) GetFinalLinkageTag(context *ContextEd25519, msg *ServerMessage) (Tf abstract.Point, err error) {
+func (client *Client) GetFinalLinkageTag(context *Context, msg *ServerMessage) (Tf abstract.Point, err error) {
 	//Input checks
 	if context == nil || msg == nil {
 		return nil, fmt.Errorf("Invalid inputs")
 	}
 
+	if len(msg.indexes) != len(msg.proofs) || len(msg.proofs) != len(msg.tags) || len(msg.tags) != len(msg.sigs) || len(msg.tags) == 0 {
+		return nil, fmt.Errorf("Invalid message")
+	}
+	if e := ValidateContext(context, nil); e != nil {
+		return nil, e
+	}
+	if e := checkServerMessagePoints(client.suite, msg); e != nil {
+		return nil, fmt.Errorf("Invalid message: %s", e)
+	}
+
 	data, e := msg.request.ToBytes()
 	if e != nil {
 		return nil, fmt.Errorf("Error in request: %s", e)
@@ -266,21 +522,19 @@ func (client *Client) GetFinalLinkageTag(context *ContextEd25519, msg *ServerMes
 
 		data = append(data, []byte(strconv.Itoa(msg.indexes[i]))...)
 
-		err = ECDSAVerify(context.G.Y[msg.sigs[i].index], data, msg.sigs[i].sig)
+		//Each server signs its own step
+		if msg.indexes[i] < 0 || msg.indexes[i] >= len(context.G.Y) || msg.sigs[i].index != msg.indexes[i] {
+			return nil, fmt.Errorf("Wrong index in step %d", i)
+		}
+		err = ECDSAVerify(client.suite, context.G.Y[msg.sigs[i].index], data, msg.sigs[i].sig)
 		if err != nil {
 			return nil, fmt.Errorf("Error in signature: "+strconv.Itoa(i)+"\n%s", err)
 		}
+	}
 
-		var valid bool
-		p := msg.proofs[i]
-		if p.r2 == nil {
-			valid = verifyMisbehavingProof(context, i, &p, msg.request.sArray[0])
-		} else {
-			valid = verifyServerProof(context, i, msg)
-		}
-		if !valid {
-			return nil, fmt.Errorf("Invalid server proof")
-		}
+	//Check the proofs once all the signatures are verified
+	if i := verifyServerProofs(context, msg); i >= 0 {
+		return nil, fmt.Errorf("Invalid server proof: %d", i)
 	}
 
 	return msg.tags[len(msg.tags)-1], nil
@@ -296,6 +550,7 @@ func ValidateClientMessage(msg *ClientMessage) bool {
 	if len(msg.sArray) != j+2 {
 		return false
 	}
+	suite := msg.context.Suite()
 	if !msg.sArray[1].Equal(suite.Point().Mul(nil, suite.Scalar().One())) {
 		return false
 	}
@@ -307,6 +562,11 @@ func ValidateClientMessage(msg *ClientMessage) bool {
 	if len(msg.proof.c) != i || len(msg.proof.r) != 2*i || len(msg.proof.t) != 3*i || msg.proof.cs == nil {
 		return false
 	}
+	//The ID matches the context carried by the request
+	id, err := msg.context.ID()
+	if err != nil || id != msg.contextID {
+		return false
+	}
 	return true
 }
 
@@ -363,5 +623,10 @@ func (proof *ClientProof) ToBytes() (data []byte, err error) {
 	}
 	data = append(data, temp...)
 
+	//Binds the mode of the proof in the servers' signatures
+	if proof.nonInteractive {
+		data = append(data, 1)
+	}
+
 	return data, nil
 }
@@ -2,6 +2,7 @@ package daga
 
 import (
 	"math/rand"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,32 +14,56 @@ func TestCreateClient(t *testing.T) {
 	//Normal execution
 	i := rand.Int()
 	s := suite.Scalar().Pick(random.Stream)
-	client, err := CreateClient(i, s)
+	client, err := CreateClient(suite, i, s)
 	if err != nil || client.index != i || !client.private.Equal(s) {
 		t.Error("Cannot initialize a new client with a given private key")
 	}
 
-	client, err = CreateClient(i, nil)
+	client, err = CreateClient(suite, i, nil)
 	if err != nil {
 		t.Error("Cannot create a new client without a private key")
 	}
 
 	//Invalid input
-	client, err = CreateClient(-2, s)
+	client, err = CreateClient(suite, -2, s)
 	if err == nil {
 		t.Error("Wrong check: Invalid index")
 	}
+	client, err = CreateClient(nil, i, s)
+	if err == nil {
+		t.Error("Wrong check: Empty suite")
+	}
 
 }
 
 func TestGetPublicKey_Client(t *testing.T) {
-	client, _ := CreateClient(0, suite.Scalar().Pick(random.Stream))
+	client, _ := CreateClient(suite, 0, suite.Scalar().Pick(random.Stream))
 	P := client.GetPublicKey()
 	if P == nil {
 		t.Error("Cannot get public key")
 	}
 }
 
+func TestFindIndex(t *testing.T) {
+	clients, _, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+1)
+	i := rand.Intn(len(clients))
+
+	//Normal execution, the key being created without knowing the index
+	client, _ := CreateClient(suite, 0, clients[i].private)
+	if err := client.FindIndex(context); err != nil || client.GetIndex() != i {
+		t.Errorf("Wrong index: got %d expected %d: %v", client.GetIndex(), i, err)
+	}
+
+	//Client outside of the context
+	outside, _ := CreateClient(suite, 0, nil)
+	if err := outside.FindIndex(context); err == nil {
+		t.Error("Wrong check: Client not in the context")
+	}
+	if err := client.FindIndex(nil); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
+}
+
 func TestCreateRequest(t *testing.T) {
 	//Normal execution
 	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
@@ -103,21 +128,11 @@ func TestGenerateProofCommitments(t *testing.T) {
 func TestGenerateProofResponses(t *testing.T) {
 	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
 	T0, _, s, _ := clients[0].CreateRequest(context)
-	_, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	tproof, v, w := clients[0].GenerateProofCommitments(context, T0, s)
 
 	//Dumb challenge generation
 	cs := suite.Scalar().Pick(random.Stream)
-	msg, _ := cs.MarshalBinary()
-	var sigs []serverSignature
-	//Make each test server sign the challenge
-	for _, server := range servers {
-		sig, e := ECDSASign(server.private, msg)
-		if e != nil {
-			t.Errorf("Cannot sign the challenge for server %d", server.index)
-		}
-		sigs = append(sigs, serverSignature{index: server.index, sig: sig})
-	}
-	challenge := Challenge{cs: cs, sigs: sigs}
+	challenge := signTestChallenge(servers, cs, *tproof)
 
 	//Normal execution
 	c, r, err := clients[0].GenerateProofResponses(context, s, &challenge, v, w)
@@ -151,7 +166,7 @@ func TestGenerateProofResponses(t *testing.T) {
 			break
 		}
 	}
-	wrongChallenge := Challenge{cs: fake, sigs: sigs}
+	wrongChallenge := Challenge{cs: fake, digest: challenge.digest, sigs: challenge.sigs}
 	c, r, err = clients[0].GenerateProofResponses(context, s, &wrongChallenge, v, w)
 	if err == nil {
 		t.Error("Cannot verify the message")
@@ -164,10 +179,11 @@ func TestGenerateProofResponses(t *testing.T) {
 	}
 
 	//Signature modification
+	sigs := challenge.sigs
 	newsig := append([]byte("A"), sigs[0].sig...)
 	newsig = newsig[:len(sigs[0].sig)]
 	sigs[0].sig = newsig
-	SigChallenge := Challenge{cs: cs, sigs: sigs}
+	SigChallenge := Challenge{cs: cs, digest: challenge.digest, sigs: sigs}
 	c, r, err = clients[0].GenerateProofResponses(context, s, &SigChallenge, v, w)
 	if err == nil {
 		t.Error("Cannot verify the message")
@@ -187,28 +203,18 @@ func TestVerifyClientProof(t *testing.T) {
 
 	//Dumb challenge generation
 	cs := suite.Scalar().Pick(random.Stream)
-	msg, _ := cs.MarshalBinary()
-	var sigs []serverSignature
-	//Make each test server sign the challenge
-	for _, server := range servers {
-		sig, e := ECDSASign(server.private, msg)
-		if e != nil {
-			t.Errorf("Cannot sign the challenge for server %d", server.index)
-		}
-		sigs = append(sigs, serverSignature{index: server.index, sig: sig})
-	}
-	challenge := Challenge{cs: cs, sigs: sigs}
+	challenge := signTestChallenge(servers, cs, *tproof)
 
 	//Generate the final proof
 	c, r, _ := clients[0].GenerateProofResponses(context, s, &challenge, v, w)
 
-	ClientMsg := ClientMessage{context: ContextEd25519{G: Members{X: context.G.X, Y: context.G.Y}, R: context.R, H: context.H},
+	ClientMsg := ClientMessage{context: Context{G: Members{X: context.G.X, Y: context.G.Y}, R: context.R, H: context.H}, contextID: contextIDOf(context),
 		t0:     T0,
 		sArray: S,
-		proof:  ClientProof{c: *c, cs: cs, r: *r, t: *tproof}}
+		proof:  ClientProof{c: *c, cs: cs, r: *r, t: *tproof, sigs: challenge.sigs}}
 
 	//Normal execution
-	check := verifyClientProof(ClientMsg)
+	check := verifyClientProof(ClientMsg, 1, nil)
 	if !check {
 		t.Error("Cannot verify client proof")
 	}
@@ -218,7 +224,7 @@ func TestVerifyClientProof(t *testing.T) {
 	i := rand.Intn(len(clients))
 	ttemp := ScratchMsg.proof.t[3*i].Clone()
 	ScratchMsg.proof.t[3*i] = suite.Point().Null()
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect check of t at index %d", 3*i)
 	}
@@ -226,7 +232,7 @@ func TestVerifyClientProof(t *testing.T) {
 
 	ttemp = ScratchMsg.proof.t[3*i+1].Clone()
 	ScratchMsg.proof.t[3*i+1] = suite.Point().Null()
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect check of t at index %d", 3*i+1)
 	}
@@ -234,7 +240,7 @@ func TestVerifyClientProof(t *testing.T) {
 
 	ttemp = ScratchMsg.proof.t[3*i+2].Clone()
 	ScratchMsg.proof.t[3*i+2] = suite.Point().Null()
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect check of t at index %d", 3*i+2)
 	}
@@ -242,12 +248,93 @@ func TestVerifyClientProof(t *testing.T) {
 
 	//Modify the value of the challenge
 	ScratchMsg.proof.cs = suite.Scalar().Zero()
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect check of the challenge")
 	}
 }
 
+func TestCreateNonInteractiveMessage(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	i := rand.Intn(len(clients))
+
+	//Normal execution
+	msg, err := clients[i].CreateNonInteractiveMessage(context)
+	if err != nil || msg == nil {
+		t.Fatal("Cannot create a non-interactive message")
+	}
+	if !msg.proof.nonInteractive {
+		t.Error("Message not marked as non-interactive")
+	}
+	if !verifyNonInteractiveClientProof(*msg, 1, nil) {
+		t.Error("Cannot verify the non-interactive proof")
+	}
+
+	//The servers process the message without generating a challenge
+	servmsg := servers[0].InitializeServerMessage(msg)
+	for _, server := range servers {
+		if err = server.ServerProtocol(context, servmsg); err != nil {
+			t.Fatalf("Server protocol failed at server %d: %s", server.index, err)
+		}
+	}
+	Tf, err := clients[i].GetFinalLinkageTag(context, servmsg)
+	if err != nil || Tf.Equal(suite.Point().Null()) {
+		t.Error("Non-interactive authentication rejected")
+	}
+
+	//The mode survives the network encoding
+	netmsg, _ := msg.NetEncode()
+	decoded, err := netmsg.NetDecode()
+	if err != nil || !decoded.proof.nonInteractive || !verifyNonInteractiveClientProof(*decoded, 1, nil) {
+		t.Error("Non-interactive mode lost in the network encoding")
+	}
+
+	//Empty context
+	msg, err = clients[i].CreateNonInteractiveMessage(nil)
+	if err == nil || msg != nil {
+		t.Error("Wrong check: Empty context")
+	}
+}
+
+func TestVerifyNonInteractiveClientProof(t *testing.T) {
+	clients, _, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	msg, _ := clients[0].CreateNonInteractiveMessage(context)
+
+	//Normal execution
+	if !verifyNonInteractiveClientProof(*msg, 1, nil) {
+		t.Error("Cannot verify the non-interactive proof")
+	}
+
+	//Interactive proof
+	msg.proof.nonInteractive = false
+	if verifyNonInteractiveClientProof(*msg, 1, nil) {
+		t.Error("Wrong check: Interactive proof accepted")
+	}
+	msg.proof.nonInteractive = true
+
+	//Challenge that is not the hash of the request
+	//The proof is rebuilt so that only the derivation of cs is wrong
+	T0, S, s, _ := clients[0].CreateRequest(context)
+	tproof, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	digest, _ := digestProofCommitments(*tproof)
+	challenge := &Challenge{cs: suite.Scalar().Pick(random.Stream), digest: digest}
+	c, r, _ := clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	forged := clients[0].AssembleMessage(context, &S, T0, challenge, tproof, c, r)
+	forged.proof.nonInteractive = true
+	if !verifyClientProof(*forged, 1, nil) {
+		t.Error("Forged proof should be a valid interactive proof")
+	}
+	if verifyNonInteractiveClientProof(*forged, 1, nil) {
+		t.Error("Wrong check: Challenge not derived from the request")
+	}
+
+	//Modified T0
+	msg.t0 = suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	if verifyNonInteractiveClientProof(*msg, 1, nil) {
+		t.Error("Wrong check: Modified T0")
+	}
+}
+
 func TestAssembleMessage(t *testing.T) {
 	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
 	T0, S, s, _ := clients[0].CreateRequest(context)
@@ -255,17 +342,7 @@ func TestAssembleMessage(t *testing.T) {
 
 	//Dumb challenge generation
 	cs := suite.Scalar().Pick(random.Stream)
-	msg, _ := cs.MarshalBinary()
-	var sigs []serverSignature
-	//Make each test server sign the challenge
-	for _, server := range servers {
-		sig, e := ECDSASign(server.private, msg)
-		if e != nil {
-			t.Errorf("Cannot sign the challenge for server %d", server.index)
-		}
-		sigs = append(sigs, serverSignature{index: server.index, sig: sig})
-	}
-	challenge := Challenge{cs: cs, sigs: sigs}
+	challenge := signTestChallenge(servers, cs, *tclient)
 
 	c, r, _ := clients[0].GenerateProofResponses(context, s, &challenge, v, w)
 
@@ -335,23 +412,13 @@ func TestGetFinalLinkageTag(t *testing.T) {
 
 	//Dumb challenge generation
 	cs := suite.Scalar().Pick(random.Stream)
-	msg, _ := cs.MarshalBinary()
-	var sigs []serverSignature
-	//Make each test server sign the challenge
-	for _, server := range servers {
-		sig, e := ECDSASign(server.private, msg)
-		if e != nil {
-			t.Errorf("Cannot sign the challenge for server %d", server.index)
-		}
-		sigs = append(sigs, serverSignature{index: server.index, sig: sig})
-	}
-	challenge := Challenge{cs: cs, sigs: sigs}
+	challenge := signTestChallenge(servers, cs, *tclient)
 
 	c, r, _ := clients[0].GenerateProofResponses(context, s, &challenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	//Create the initial server message
 	servMsg := ServerMessage{request: clientMessage, proofs: nil, tags: nil, sigs: nil, indexes: nil}
@@ -390,9 +457,10 @@ func TestGetFinalLinkageTag(t *testing.T) {
 
 	//Normal execution for a misbehaving client
 	//Assemble the client message
-	S[2] = suite.Point().Null()
-	clientMessage = ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	//The wrong commitment is a valid point, the neutral element being rejected before the protocol runs
+	S[2] = suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	clientMessage = ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	//Create the initial server message
 	servMsg = ServerMessage{request: clientMessage, proofs: nil, tags: nil, sigs: nil, indexes: nil}
@@ -403,7 +471,7 @@ func TestGetFinalLinkageTag(t *testing.T) {
 	}
 	Tf, err = clients[0].GetFinalLinkageTag(context, &servMsg)
 	if err != nil {
-		t.Errorf("Cannot extract final linkage tag for a misbehaving client")
+		t.Fatalf("Cannot extract final linkage tag for a misbehaving client: %s", err)
 	}
 	if !Tf.Equal(suite.Point().Null()) {
 		t.Error("Tf not Null for a misbehaving client")
@@ -417,28 +485,18 @@ func TestValidateClientMessage(t *testing.T) {
 
 	//Dumb challenge generation
 	cs := suite.Scalar().Pick(random.Stream)
-	msg, _ := cs.MarshalBinary()
-	var sigs []serverSignature
-	//Make each test server sign the challenge
-	for _, server := range servers {
-		sig, e := ECDSASign(server.private, msg)
-		if e != nil {
-			t.Errorf("Cannot sign the challenge for server %d", server.index)
-		}
-		sigs = append(sigs, serverSignature{index: server.index, sig: sig})
-	}
-	challenge := Challenge{cs: cs, sigs: sigs}
+	challenge := signTestChallenge(servers, cs, *tproof)
 
 	//Generate the final proof
 	c, r, _ := clients[0].GenerateProofResponses(context, s, &challenge, v, w)
 
-	ClientMsg := ClientMessage{context: ContextEd25519{G: Members{X: context.G.X, Y: context.G.Y}, R: context.R, H: context.H},
+	ClientMsg := ClientMessage{context: Context{G: Members{X: context.G.X, Y: context.G.Y}, R: context.R, H: context.H}, contextID: contextIDOf(context),
 		t0:     T0,
 		sArray: S,
-		proof:  ClientProof{c: *c, cs: cs, r: *r, t: *tproof}}
+		proof:  ClientProof{c: *c, cs: cs, r: *r, t: *tproof, sigs: challenge.sigs}}
 
 	//Normal execution
-	check := verifyClientProof(ClientMsg)
+	check := verifyClientProof(ClientMsg, 1, nil)
 	if !check {
 		t.Error("Cannot verify client proof")
 	}
@@ -446,48 +504,48 @@ func TestValidateClientMessage(t *testing.T) {
 	//Modifying the length of various elements
 	ScratchMsg := ClientMsg
 	ScratchMsg.proof.c = append(ScratchMsg.proof.c, suite.Scalar().Pick(random.Stream))
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for c: %d instead of %d", len(ScratchMsg.proof.c), len(clients))
 	}
 	ScratchMsg.proof.c = ScratchMsg.proof.c[:len(clients)-1]
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for c: %d instead of %d", len(ScratchMsg.proof.c), len(clients))
 	}
 
 	ScratchMsg = ClientMsg
 	ScratchMsg.proof.r = append(ScratchMsg.proof.r, suite.Scalar().Pick(random.Stream))
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for r: %d instead of %d", len(ScratchMsg.proof.c), len(clients))
 	}
 	ScratchMsg.proof.r = ScratchMsg.proof.r[:2*len(clients)-1]
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for r: %d instead of %d", len(ScratchMsg.proof.c), len(clients))
 	}
 
 	ScratchMsg = ClientMsg
 	ScratchMsg.proof.t = append(ScratchMsg.proof.t, suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream)))
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for t: %d instead of %d", len(ScratchMsg.proof.c), len(clients))
 	}
 	ScratchMsg.proof.t = ScratchMsg.proof.t[:3*len(clients)-1]
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for t: %d instead of %d", len(ScratchMsg.proof.c), len(clients))
 	}
 
 	ScratchMsg = ClientMsg
 	ScratchMsg.sArray = append(ScratchMsg.sArray, suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream)))
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for S: %d instead of %d", len(ScratchMsg.sArray), len(servers)+2)
 	}
 	ScratchMsg.sArray = ScratchMsg.sArray[:len(servers)+1]
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect length check for S: %d instead of %d", len(ScratchMsg.sArray), len(servers)+2)
 	}
@@ -495,7 +553,7 @@ func TestValidateClientMessage(t *testing.T) {
 	//Modify the value of the generator in S[1]
 	ScratchMsg = ClientMsg
 	ScratchMsg.sArray[1] = suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Incorrect check for the generator in S[1]")
 	}
@@ -503,7 +561,7 @@ func TestValidateClientMessage(t *testing.T) {
 
 	//Remove T0
 	ScratchMsg.t0 = nil
-	check = verifyClientProof(ScratchMsg)
+	check = verifyClientProof(ScratchMsg, 1, nil)
 	if check {
 		t.Errorf("Accepts a empty T0")
 	}
@@ -516,25 +574,15 @@ func TestToBytes_ClientMessage(t *testing.T) {
 
 	//Dumb challenge generation
 	cs := suite.Scalar().Pick(random.Stream)
-	msg, _ := cs.MarshalBinary()
-	var sigs []serverSignature
-	//Make each test server sign the challenge
-	for _, server := range servers {
-		sig, e := ECDSASign(server.private, msg)
-		if e != nil {
-			t.Errorf("Cannot sign the challenge for server %d", server.index)
-		}
-		sigs = append(sigs, serverSignature{index: server.index, sig: sig})
-	}
-	challenge := Challenge{cs: cs, sigs: sigs}
+	challenge := signTestChallenge(servers, cs, *tproof)
 
 	//Generate the final proof
 	c, r, _ := clients[0].GenerateProofResponses(context, s, &challenge, v, w)
 
-	ClientMsg := ClientMessage{context: ContextEd25519{G: Members{X: context.G.X, Y: context.G.Y}, R: context.R, H: context.H},
+	ClientMsg := ClientMessage{context: Context{G: Members{X: context.G.X, Y: context.G.Y}, R: context.R, H: context.H}, contextID: contextIDOf(context),
 		t0:     T0,
 		sArray: S,
-		proof:  ClientProof{c: *c, cs: cs, r: *r, t: *tproof}}
+		proof:  ClientProof{c: *c, cs: cs, r: *r, t: *tproof, sigs: challenge.sigs}}
 
 	//Normal execution
 	data, err := ClientMsg.ToBytes()
@@ -553,22 +601,12 @@ func TestToBytes_ClientProof(t *testing.T) {
 
 	//Dumb challenge generation
 	cs := suite.Scalar().Pick(random.Stream)
-	msg, _ := cs.MarshalBinary()
-	var sigs []serverSignature
-	//Make each test server sign the challenge
-	for _, server := range servers {
-		sig, e := ECDSASign(server.private, msg)
-		if e != nil {
-			t.Errorf("Cannot sign the challenge for server %d", server.index)
-		}
-		sigs = append(sigs, serverSignature{index: server.index, sig: sig})
-	}
-	challenge := Challenge{cs: cs, sigs: sigs}
+	challenge := signTestChallenge(servers, cs, *tproof)
 
 	//Generate the final proof
 	c, r, _ := clients[0].GenerateProofResponses(context, s, &challenge, v, w)
 
-	proof := ClientProof{c: *c, cs: cs, r: *r, t: *tproof}
+	proof := ClientProof{c: *c, cs: cs, r: *r, t: *tproof, sigs: challenge.sigs}
 
 	//Normal execution
 	data, err := proof.ToBytes()
@@ -580,3 +618,51 @@ func TestToBytes_ClientProof(t *testing.T) {
 	}
 
 }
+
+func TestVerifyClientCommitments(t *testing.T) {
+	clients, _, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+1)
+	msg, err := clients[rand.Intn(len(clients))].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	n := len(clients)
+
+	//Normal execution
+	for _, workers := range []int{0, 1, 2, n, 2 * n} {
+		if !verifyClientCommitments(msg, workers, nil) {
+			t.Errorf("Valid proof rejected with %d workers", workers)
+		}
+	}
+
+	//Wrong commitment of the last member
+	forged := *msg
+	forged.proof.t = append([]abstract.Point{}, msg.proof.t...)
+	forged.proof.t[3*n-1] = suite.Point().Null()
+	for _, workers := range []int{1, 2, n} {
+		if verifyClientCommitments(&forged, workers, nil) {
+			t.Errorf("Wrong check: Forged commitment with %d workers", workers)
+		}
+	}
+}
+
+func benchmarkVerifyClientProof(b *testing.B, workers int) {
+	clients, _, context, _ := generateTestContext(256, 1)
+	msg, err := clients[0].CreateNonInteractiveMessage(context)
+	if err != nil {
+		b.Fatalf("Cannot create the request: %s", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !verifyClientProof(*msg, workers, nil) {
+			b.Fatal("Valid proof rejected")
+		}
+	}
+}
+
+func BenchmarkVerifyClientProof_Sequential(b *testing.B) {
+	benchmarkVerifyClientProof(b, 1)
+}
+
+func BenchmarkVerifyClientProof_Parallel(b *testing.B) {
+	benchmarkVerifyClientProof(b, runtime.NumCPU())
+}
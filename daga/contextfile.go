@@ -0,0 +1,98 @@
+package daga
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+//contextFileVersion is the version of the file format written by Save
+const contextFileVersion = 1
+
+/*contextFile is the content of a file storing a context
+Checksum is computed on the binary encoding of the context and identifies it*/
+type contextFile struct {
+	Version  int
+	Checksum string
+	Context  NetContext
+}
+
+/*Checksum returns the hex encoded SHA-256 of the binary encoding of the context*/
+func (context *Context) Checksum() (string, error) {
+	data, err := context.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("Error in context: %s", err)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+/*checkSizes verifies that there is a commitment per server and either no generator or one per client*/
+func (context *Context) checkSizes() error {
+	if len(context.G.X) == 0 || len(context.G.Y) == 0 {
+		return fmt.Errorf("Empty members")
+	}
+	if len(context.H) != 0 && len(context.H) != len(context.G.X) {
+		return fmt.Errorf("Wrong number of generators: got %d expected %d", len(context.H), len(context.G.X))
+	}
+	if len(context.R) != len(context.G.Y) {
+		return fmt.Errorf("Wrong number of commitments: got %d expected %d", len(context.R), len(context.G.Y))
+	}
+	return nil
+}
+
+/*Save writes the context and its checksum to a file, so that it can be published to the clients and the servers*/
+func (context *Context) Save(path string) error {
+	if err := context.checkSizes(); err != nil {
+		return fmt.Errorf("Invalid context: %s", err)
+	}
+	netcontext, err := context.NetEncode()
+	if err != nil {
+		return fmt.Errorf("Error in context encoding: %s", err)
+	}
+	checksum, err := context.Checksum()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(contextFile{Version: contextFileVersion, Checksum: checksum, Context: *netcontext}, "", "\t")
+	if err != nil {
+		return fmt.Errorf("Cannot json marshal the context: %s", err)
+	}
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("Cannot write the context: %s", err)
+	}
+	return nil
+}
+
+/*LoadContext reads a context written by Save
+It checks the checksum and that the context has a generator per client and a commitment per server*/
+func LoadContext(path string) (*Context, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the context: %s", err)
+	}
+	var file contextFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("Cannot json unmarshal the context: %s", err)
+	}
+	if file.Version != contextFileVersion {
+		return nil, fmt.Errorf("Unsupported context file version: %d", file.Version)
+	}
+	context, err := file.Context.NetDecode()
+	if err != nil {
+		return nil, fmt.Errorf("Error in context decoding: %s", err)
+	}
+	if err = context.checkSizes(); err != nil {
+		return nil, fmt.Errorf("Invalid context: %s", err)
+	}
+	checksum, err := context.Checksum()
+	if err != nil {
+		return nil, err
+	}
+	if checksum != file.Checksum {
+		return nil, fmt.Errorf("Wrong checksum: got %s expected %s", checksum, file.Checksum)
+	}
+	return context, nil
+}
@@ -0,0 +1,115 @@
+package daga
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatalf("Cannot create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "context.json")
+
+	p256, _ := LookupSuite("P256")
+	_, _, context, _ := generateTestContextSuite(p256, rand.Intn(10)+1, rand.Intn(10)+1)
+
+	//Normal execution
+	if err = context.Save(path); err != nil {
+		t.Fatalf("Cannot save the context: %s", err)
+	}
+	loaded, err := LoadContext(path)
+	if err != nil {
+		t.Fatalf("Cannot load the context: %s", err)
+	}
+	if loaded.Suite() != p256 {
+		t.Error("Suite not restored")
+	}
+	expected, _ := context.Checksum()
+	if checksum, _ := loaded.Checksum(); checksum != expected {
+		t.Error("Loaded context differs from the saved one")
+	}
+
+	//Missing file
+	if _, err = LoadContext(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Wrong check: Missing file")
+	}
+
+	//Tampered context
+	data, _ := ioutil.ReadFile(path)
+	var file contextFile
+	json.Unmarshal(data, &file)
+	file.Context.H[0], file.Context.H[len(file.Context.H)-1] = file.Context.H[len(file.Context.H)-1], file.Context.H[0]
+	file.Context.R[0] = file.Context.H[0]
+	data, _ = json.Marshal(file)
+	ioutil.WriteFile(path, data, 0644)
+	if _, err = LoadContext(path); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("Wrong check: Tampered context: %s", err)
+	}
+
+	//Wrong version
+	json.Unmarshal(data, &file)
+	file.Version = contextFileVersion + 1
+	data, _ = json.Marshal(file)
+	ioutil.WriteFile(path, data, 0644)
+	if _, err = LoadContext(path); err == nil {
+		t.Error("Wrong check: Wrong version")
+	}
+}
+
+func TestLoadContext_Sizes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatalf("Cannot create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "context.json")
+
+	_, _, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+2)
+
+	//A context with missing elements cannot be saved
+	H := context.H
+	context.H = H[1:]
+	if err = context.Save(path); err == nil {
+		t.Error("Wrong check: Save with missing generator")
+	}
+	context.H = H
+	R := context.R
+	context.R = R[1:]
+	if err = context.Save(path); err == nil {
+		t.Error("Wrong check: Save with missing commitment")
+	}
+	context.R = R
+
+	//Nor loaded, even with a matching checksum
+	context.Save(path)
+	data, _ := ioutil.ReadFile(path)
+	var file contextFile
+	json.Unmarshal(data, &file)
+	file.Context.H = file.Context.H[1:]
+	short, _ := file.Context.NetDecode()
+	file.Checksum, _ = short.Checksum()
+	data, _ = json.Marshal(file)
+	ioutil.WriteFile(path, data, 0644)
+	if _, err = LoadContext(path); err == nil || !strings.Contains(err.Error(), "generators") {
+		t.Errorf("Wrong check: Load with missing generator: %s", err)
+	}
+
+	netcontext, _ := context.NetEncode()
+	file.Context = *netcontext
+	file.Context.R = file.Context.R[1:]
+	short, _ = file.Context.NetDecode()
+	file.Checksum, _ = short.Checksum()
+	data, _ = json.Marshal(file)
+	ioutil.WriteFile(path, data, 0644)
+	if _, err = LoadContext(path); err == nil || !strings.Contains(err.Error(), "commitments") {
+		t.Errorf("Wrong check: Load with missing commitment: %s", err)
+	}
+}
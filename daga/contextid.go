@@ -0,0 +1,91 @@
+package daga
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//contextIDDomain separates the hash of a context from the other hashes of the protocol
+const contextIDDomain = "DAGA context ID v1"
+
+/*ContextID identifies a context in logs, tag registries and messages
+It is the hash of the canonical encoding of the context, see Context.ID*/
+type ContextID [sha256.Size]byte
+
+/*ID computes the identifier of the context
+The hash covers the suite, the round and the number of clients, servers, commitments and generators,
+every element being length-prefixed so that two different contexts never share an encoding*/
+func (context *Context) ID() (id ContextID, err error) {
+	var buf bytes.Buffer
+	writeBlob := func(data []byte) {
+		binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+	writeBlob([]byte(contextIDDomain))
+	writeBlob([]byte(context.Suite().String()))
+	binary.Write(&buf, binary.BigEndian, context.round)
+	for _, group := range [][]abstract.Point{context.G.X, context.G.Y, context.R, context.H} {
+		binary.Write(&buf, binary.BigEndian, uint32(len(group)))
+	}
+	for _, group := range [][]abstract.Point{context.G.X, context.G.Y, context.R, context.H} {
+		for i, p := range group {
+			if p == nil {
+				return id, fmt.Errorf("Empty point %d", i)
+			}
+			data, err := p.MarshalBinary()
+			if err != nil {
+				return id, fmt.Errorf("Error in point %d: %s", i, err)
+			}
+			writeBlob(data)
+		}
+	}
+	return sha256.Sum256(buf.Bytes()), nil
+}
+
+/*String returns the hex encoding of the ID*/
+func (id ContextID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+/*MarshalText encodes the ID in hex, so that it reads the same in JSON and in logs*/
+func (id ContextID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+/*UnmarshalText decodes an ID encoded by MarshalText*/
+func (id *ContextID) UnmarshalText(text []byte) error {
+	parsed, err := ParseContextID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+/*ParseContextID decodes the hex encoding of an ID*/
+func ParseContextID(s string) (id ContextID, err error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return id, fmt.Errorf("Invalid context ID: %s", err)
+	}
+	if len(data) != len(id) {
+		return id, fmt.Errorf("Invalid context ID: got %d bytes expected %d", len(data), len(id))
+	}
+	copy(id[:], data)
+	return id, nil
+}
+
+/*ContextID returns the ID of the context the request was built for*/
+func (msg *ClientMessage) ContextID() ContextID {
+	return msg.contextID
+}
+
+/*ContextID returns the ID of the context of the request being processed*/
+func (msg *ServerMessage) ContextID() ContextID {
+	return msg.request.contextID
+}
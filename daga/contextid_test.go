@@ -0,0 +1,119 @@
+package daga
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+func TestContextID(t *testing.T) {
+	_, _, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+2)
+
+	//Normal execution
+	id, err := context.ID()
+	if err != nil {
+		t.Fatalf("Cannot compute the ID: %s", err)
+	}
+	if again, _ := context.ID(); again != id {
+		t.Error("ID is not stable")
+	}
+
+	//Every element of the context changes the ID
+	changed := *context
+	changed.round++
+	if other, _ := changed.ID(); other == id {
+		t.Error("Round not covered by the ID")
+	}
+	p256, _ := LookupSuite("P256")
+	changed = *context
+	changed.suite = p256
+	if other, _ := changed.ID(); other == id {
+		t.Error("Suite not covered by the ID")
+	}
+	changed = *context
+	changed.H = append([]abstract.Point{}, context.H...)
+	changed.H[0], changed.H[1] = context.H[1], context.H[0]
+	if other, _ := changed.ID(); other == id {
+		t.Error("Order of H not covered by the ID")
+	}
+
+	//Points moved from one list to the next one do not give the same encoding
+	changed = *context
+	changed.G.X = context.G.X[:len(context.G.X)-1]
+	changed.G.Y = append([]abstract.Point{context.G.X[len(context.G.X)-1]}, context.G.Y...)
+	if other, _ := changed.ID(); other == id {
+		t.Error("Members counts not covered by the ID")
+	}
+
+	//Text encoding
+	data, err := json.Marshal(id)
+	if err != nil {
+		t.Fatalf("Cannot marshal the ID: %s", err)
+	}
+	var decoded ContextID
+	if err = json.Unmarshal(data, &decoded); err != nil || decoded != id {
+		t.Errorf("ID does not round-trip: %s", err)
+	}
+	if _, err = ParseContextID(id.String()[2:]); err == nil {
+		t.Error("Wrong check: Short ID")
+	}
+	if _, err = ParseContextID("not hex"); err == nil {
+		t.Error("Wrong check: Invalid ID")
+	}
+}
+
+func TestContextID_Messages(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	i := rand.Intn(len(clients))
+	request, err := clients[i].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+
+	//Normal execution
+	id, _ := context.ID()
+	if request.ContextID() != id {
+		t.Error("Request does not carry the ID of its context")
+	}
+	msg := servers[0].InitializeServerMessage(request)
+	if msg.ContextID() != id {
+		t.Error("Server message does not carry the ID of its context")
+	}
+
+	//ID not matching the context of the request
+	forged := *request
+	forged.contextID[0] ^= 1
+	if ValidateClientMessage(&forged) {
+		t.Error("Wrong check: Forged ID")
+	}
+
+	//Request for another context
+	_, _, other, _ := generateTestContext(len(clients), len(servers))
+	if err = servers[0].ServerProtocol(other, msg); err == nil {
+		t.Error("Wrong check: Unknown context")
+	}
+	if err = servers[0].ServerProtocol(context, msg); err != nil {
+		t.Fatalf("Cannot process the request: %s", err)
+	}
+
+	//The ID is kept by the encodings
+	netmsg, _ := msg.NetEncode()
+	decoded, err := netmsg.NetDecode()
+	if err != nil || decoded.ContextID() != id {
+		t.Errorf("ID not kept by the JSON encoding: %s", err)
+	}
+	netmsg.ContextID[0] ^= 1
+	if _, err = netmsg.NetDecode(); err == nil {
+		t.Error("Wrong check: Mismatching IDs")
+	}
+	data, _ := msg.MarshalBinary()
+	if decoded, err = UnmarshalServerMessage(data); err != nil || decoded.ContextID() != id {
+		t.Errorf("ID not kept by the binary encoding: %s", err)
+	}
+	pbmsg, _ := msg.ProtoEncode()
+	if decoded, err = ProtoDecodeServerMessage(pbmsg); err != nil || decoded.ContextID() != id {
+		t.Errorf("ID not kept by the Protocol Buffers encoding: %s", err)
+	}
+}
@@ -0,0 +1,123 @@
+package daga
+
+import (
+	"fmt"
+	"sync"
+)
+
+/*ContextStore holds the contexts known to a server, indexed by their ID
+It resolves the messages that reference their context instead of carrying it*/
+type ContextStore struct {
+	mutex    sync.RWMutex
+	contexts map[ContextID]*Context
+}
+
+/*NewContextStore creates a store holding the given contexts*/
+func NewContextStore(contexts ...*Context) (*ContextStore, error) {
+	store := &ContextStore{contexts: make(map[ContextID]*Context)}
+	for _, context := range contexts {
+		if _, err := store.Add(context); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+/*Add makes the context available to the messages referencing it and returns its ID*/
+func (store *ContextStore) Add(context *Context) (ContextID, error) {
+	if context == nil {
+		return ContextID{}, fmt.Errorf("Empty context")
+	}
+	id, err := context.ID()
+	if err != nil {
+		return id, fmt.Errorf("Error in context: %s", err)
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.contexts[id] = context
+	return id, nil
+}
+
+/*Get returns the context with the given ID*/
+func (store *ContextStore) Get(id ContextID) (*Context, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	context, ok := store.contexts[id]
+	if !ok {
+		return nil, fmt.Errorf("Unknown context: %s", id)
+	}
+	return context, nil
+}
+
+/*Remove forgets the context, typically once the servers moved to the next round*/
+func (store *ContextStore) Remove(id ContextID) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.contexts, id)
+}
+
+/*NetClientMessageRef is a NetClientMessage referencing its context by ID instead of carrying it
+It saves the O(clients) points of the context on every hop, the receiver resolving the context from its ContextStore*/
+type NetClientMessageRef struct {
+	ContextID ContextID
+	SArray    []NetPoint
+	T0        NetPoint
+	Proof     NetClientProof
+}
+
+/*NetServerMessageRef is a NetServerMessage whose request references its context by ID*/
+type NetServerMessageRef struct {
+	ContextID ContextID
+	Request   NetClientMessageRef
+	Tags      []NetPoint
+	Proofs    []NetServerProof
+	Indexes   []int
+	Sigs      []NetServerSignature
+}
+
+/*NetEncodeRef encodes the message without its context*/
+func (msg *ClientMessage) NetEncodeRef() (*NetClientMessageRef, error) {
+	netmsg, err := msg.netEncodeRequest()
+	if err != nil {
+		return nil, err
+	}
+	return &NetClientMessageRef{ContextID: netmsg.ContextID, SArray: netmsg.SArray, T0: netmsg.T0, Proof: netmsg.Proof}, nil
+}
+
+/*NetDecode decodes the message with the context of the store it references*/
+func (netmsg *NetClientMessageRef) NetDecode(store *ContextStore) (*ClientMessage, error) {
+	if store == nil {
+		return nil, fmt.Errorf("Empty context store")
+	}
+	context, err := store.Get(netmsg.ContextID)
+	if err != nil {
+		return nil, err
+	}
+	full := NetClientMessage{ContextID: netmsg.ContextID, SArray: netmsg.SArray, T0: netmsg.T0, Proof: netmsg.Proof}
+	return full.netDecodeRequest(context)
+}
+
+/*NetEncodeRef encodes the message with a request referencing its context*/
+func (msg *ServerMessage) NetEncodeRef() (*NetServerMessageRef, error) {
+	request, err := msg.request.NetEncodeRef()
+	if err != nil {
+		return nil, fmt.Errorf("Encode error in request\n%s", err)
+	}
+	netmsg, err := msg.netEncodeProofs()
+	if err != nil {
+		return nil, err
+	}
+	return &NetServerMessageRef{ContextID: netmsg.ContextID, Request: *request, Tags: netmsg.Tags,
+		Proofs: netmsg.Proofs, Indexes: netmsg.Indexes, Sigs: netmsg.Sigs}, nil
+}
+
+/*NetDecode decodes the message with the context of the store referenced by its request*/
+func (netmsg *NetServerMessageRef) NetDecode(store *ContextStore) (*ServerMessage, error) {
+	request, err := netmsg.Request.NetDecode(store)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error in request\n%s", err)
+	}
+	full := NetServerMessage{ContextID: netmsg.ContextID, Tags: netmsg.Tags, Proofs: netmsg.Proofs,
+		Indexes: netmsg.Indexes, Sigs: netmsg.Sigs}
+	return full.netDecodeProofs(request)
+}
@@ -0,0 +1,102 @@
+package daga
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestContextStore(t *testing.T) {
+	_, _, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	_, _, other, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+
+	//Normal execution
+	store, err := NewContextStore(context)
+	if err != nil {
+		t.Fatalf("Cannot create the store: %s", err)
+	}
+	id, _ := context.ID()
+	if found, err := store.Get(id); err != nil || found != context {
+		t.Errorf("Cannot get the context: %s", err)
+	}
+	otherID, err := store.Add(other)
+	if err != nil {
+		t.Fatalf("Cannot add a context: %s", err)
+	}
+	store.Remove(otherID)
+	if _, err = store.Get(otherID); err == nil {
+		t.Error("Wrong check: Removed context")
+	}
+
+	//Empty inputs
+	if _, err = store.Add(nil); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
+	if _, err = NewContextStore(context, nil); err == nil {
+		t.Error("Wrong check: Empty context at creation")
+	}
+}
+
+func TestNetMessageRef(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	store, _ := NewContextStore(context)
+	i := rand.Intn(len(clients))
+	request, err := clients[i].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+
+	//ClientMessage
+	netrequest, err := request.NetEncodeRef()
+	if err != nil {
+		t.Fatalf("Cannot encode the client message: %s", err)
+	}
+	data, _ := json.Marshal(netrequest)
+	full, _ := request.NetEncode()
+	fulldata, _ := json.Marshal(full)
+	if len(data) >= len(fulldata) {
+		t.Errorf("Reference not smaller than the context: %d and %d bytes", len(data), len(fulldata))
+	}
+	var receivedRequest NetClientMessageRef
+	json.Unmarshal(data, &receivedRequest)
+	decodedRequest, err := receivedRequest.NetDecode(store)
+	if err != nil || !verifyNonInteractiveClientProof(*decodedRequest, 1, nil) {
+		t.Fatalf("Client message does not round-trip: %s", err)
+	}
+
+	//ServerMessage, processed by the servers after decoding
+	msg, err := endpoint.SubmitMessage(decodedRequest)
+	if err != nil {
+		t.Fatalf("Cannot process the request: %s", err)
+	}
+	netmsg, err := msg.NetEncodeRef()
+	if err != nil {
+		t.Fatalf("Cannot encode the server message: %s", err)
+	}
+	data, _ = json.Marshal(netmsg)
+	var receivedMsg NetServerMessageRef
+	json.Unmarshal(data, &receivedMsg)
+	decodedMsg, err := receivedMsg.NetDecode(store)
+	if err != nil {
+		t.Fatalf("Cannot decode the server message: %s", err)
+	}
+	if _, err = clients[i].GetFinalLinkageTag(context, decodedMsg); err != nil {
+		t.Errorf("Decoded server message not accepted by the client: %s", err)
+	}
+
+	//Unknown context
+	empty, _ := NewContextStore()
+	if _, err = receivedRequest.NetDecode(empty); err == nil {
+		t.Error("Wrong check: Unknown context")
+	}
+	if _, err = receivedMsg.NetDecode(nil); err == nil {
+		t.Error("Wrong check: Empty store")
+	}
+
+	//Mismatching IDs
+	receivedMsg.ContextID[0] ^= 1
+	if _, err = receivedMsg.NetDecode(store); err == nil {
+		t.Error("Wrong check: Mismatching IDs")
+	}
+}
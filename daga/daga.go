@@ -1,10 +1,13 @@
 package daga
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 
 	"gopkg.in/dedis/crypto.v0/abstract"
 	"gopkg.in/dedis/crypto.v0/ed25519"
+	"gopkg.in/dedis/crypto.v0/nist"
 	"gopkg.in/dedis/crypto.v0/sign"
 )
 
@@ -14,23 +17,79 @@ type Members struct {
 	Y []abstract.Point
 }
 
-/*ContextEd25519 holds all the context elements for DAGA with the ed25519 curve
-group is the curve
+/*Context holds all the context elements for DAGA
+suite is the group in which the protocol runs
+round is the number of rotations of the servers' secrets since the context was created
 R is the server's commitments
 H is the client's per-round generators*/
-type ContextEd25519 struct {
-	G Members
-	R []abstract.Point
-	H []abstract.Point
+type Context struct {
+	suite abstract.Suite
+	round uint64
+	G     Members
+	R     []abstract.Point
+	H     []abstract.Point
 }
 
-//Suite exports the cryptographic interface to external packages
+//Suite is the default cryptographic suite, used by contexts created without an explicit suite
 var Suite = ed25519.NewAES128SHA256Ed25519(false)
-var suite = ed25519.NewAES128SHA256Ed25519(false)
+
+//suites lists the groups DAGA can run on, indexed by their name
+var suites = map[string]abstract.Suite{}
+
+func init() {
+	RegisterSuite(Suite)
+	RegisterSuite(nist.NewAES128SHA256P256())
+}
+
+/*RegisterSuite makes a suite available to decode contexts referencing it by name*/
+func RegisterSuite(suite abstract.Suite) {
+	suites[suite.String()] = suite
+}
+
+/*LookupSuite returns the registered suite with the given name*/
+func LookupSuite(name string) (abstract.Suite, error) {
+	suite, ok := suites[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown suite: %s", name)
+	}
+	return suite, nil
+}
+
+/*NewContext creates a context running in the given suite*/
+func NewContext(suite abstract.Suite, G Members, R, H []abstract.Point) (*Context, error) {
+	if suite == nil {
+		return nil, fmt.Errorf("Empty suite")
+	}
+	return &Context{suite: suite, G: G, R: R, H: H}, nil
+}
+
+/*Suite returns the suite of the context, the default one if none was chosen at creation*/
+func (context *Context) Suite() abstract.Suite {
+	if context == nil || context.suite == nil {
+		return Suite
+	}
+	return context.suite
+}
+
+/*Round returns the round of the context, 0 for a context that was never rotated*/
+func (context *Context) Round() uint64 {
+	return context.round
+}
+
+/*checkSuite verifies that a key holder and a context use the same group*/
+func checkSuite(own abstract.Suite, context *Context) error {
+	if own.String() != context.Suite().String() {
+		return fmt.Errorf("Suite mismatch: %s instead of %s", context.Suite(), own)
+	}
+	return nil
+}
 
 /*ECDSASign gnerates a Schnorr signature*/
-func ECDSASign(priv abstract.Scalar, msg []byte) (s []byte, err error) {
+func ECDSASign(suite abstract.Suite, priv abstract.Scalar, msg []byte) (s []byte, err error) {
 	//Input checks
+	if suite == nil {
+		return nil, fmt.Errorf("Empty suite")
+	}
 	if priv == nil {
 		return nil, fmt.Errorf("Empty private key")
 	}
@@ -46,8 +105,11 @@ func ECDSASign(priv abstract.Scalar, msg []byte) (s []byte, err error) {
 }
 
 /*ECDSAVerify checks if a Schnorr signature is valid*/
-func ECDSAVerify(public abstract.Point, msg, sig []byte) (err error) {
+func ECDSAVerify(suite abstract.Suite, public abstract.Point, msg, sig []byte) (err error) {
 	//Input checks
+	if suite == nil {
+		return fmt.Errorf("Empty suite")
+	}
 	if public == nil {
 		return fmt.Errorf("Empty public key")
 	}
@@ -62,8 +124,8 @@ func ECDSAVerify(public abstract.Point, msg, sig []byte) (err error) {
 	return err
 }
 
-/*ToBytes is a utility functton to convert a ContextEd25519 into []byte, used in signatures*/
-func (context *ContextEd25519) ToBytes() (data []byte, err error) {
+/*ToBytes is a utility functton to convert a Context into []byte, used in signatures*/
+func (context *Context) ToBytes() (data []byte, err error) {
 	temp, e := PointArrayToBytes(&context.G.X)
 	if e != nil {
 		return nil, fmt.Errorf("Error in X: %s", e)
@@ -88,6 +150,10 @@ func (context *ContextEd25519) ToBytes() (data []byte, err error) {
 	}
 	data = append(data, temp...)
 
+	round := make([]byte, 8)
+	binary.BigEndian.PutUint64(round, context.round)
+	data = append(data, round...)
+
 	return data, nil
 }
 
@@ -114,3 +180,59 @@ func ScalarArrayToBytes(array *[]abstract.Scalar) (data []byte, err error) {
 	}
 	return data, nil
 }
+
+/*checkPoint rejects the points that have no place in the protocol: the neutral element unless allowNull is set, and the points outside of the prime-order subgroup.
+The latter are found by multiplying the point by the order q of the subgroup, as (q-1)*P + P, which only gives the neutral element for the points of the subgroup*/
+func checkPoint(suite abstract.Suite, p abstract.Point, allowNull bool) error {
+	if p == nil {
+		return fmt.Errorf("Empty point")
+	}
+	null := suite.Point().Null()
+	if p.Equal(null) {
+		if allowNull {
+			return nil
+		}
+		return fmt.Errorf("Neutral element")
+	}
+	minusOne := suite.Scalar().Neg(suite.Scalar().One())
+	if !suite.Point().Add(suite.Point().Mul(p, minusOne), p).Equal(null) {
+		return fmt.Errorf("Point outside of the prime-order subgroup")
+	}
+	return nil
+}
+
+/*unmarshalPoint unmarshals a point of the suite and checks it with checkPoint
+Only the canonical encoding, the one produced by MarshalBinary, is accepted, so that a point has a single encoding in signatures and hashes*/
+func unmarshalPoint(suite abstract.Suite, data []byte, allowNull bool) (abstract.Point, error) {
+	point := suite.Point().Null()
+	if err := point.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("Decode error\n%s", err)
+	}
+	canonical, err := point.MarshalBinary()
+	if err != nil || !bytes.Equal(canonical, data) {
+		return nil, fmt.Errorf("Decode error\nNon-canonical encoding")
+	}
+	if err = checkPoint(suite, point, allowNull); err != nil {
+		return nil, fmt.Errorf("Decode error\n%s", err)
+	}
+	return point, nil
+}
+
+/*decodePoint unmarshals a point of the suite, rejecting the invalid ones*/
+func decodePoint(suite abstract.Suite, data []byte) (abstract.Point, error) {
+	return unmarshalPoint(suite, data, false)
+}
+
+/*decodeTag unmarshals a linkage tag, which is the neutral element for a misbehaving client*/
+func decodeTag(suite abstract.Suite, data []byte) (abstract.Point, error) {
+	return unmarshalPoint(suite, data, true)
+}
+
+/*decodeScalar unmarshals a scalar of the suite*/
+func decodeScalar(suite abstract.Suite, data []byte) (abstract.Scalar, error) {
+	scalar := suite.Scalar().Zero()
+	if err := scalar.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("Decode error\n%s", err)
+	}
+	return scalar, nil
+}
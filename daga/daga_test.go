@@ -8,23 +8,38 @@ import (
 	"gopkg.in/dedis/crypto.v0/random"
 )
 
+//suite is the suite used by the tests of the package
+var suite = Suite
+
+//contextIDOf returns the ID of the context, for the messages built by hand in the tests
+func contextIDOf(context *Context) ContextID {
+	id, _ := context.ID()
+	return id
+}
+
 func TestECDSASign(t *testing.T) {
 	priv := suite.Scalar().Pick(random.Stream)
 
 	//Normal execution
-	sig, err := ECDSASign(priv, []byte("Test String"))
+	sig, err := ECDSASign(suite, priv, []byte("Test String"))
 	if err != nil || sig == nil {
 		t.Error("Cannot execute signature")
 	}
 
+	//Empty suite
+	sig, err = ECDSASign(nil, priv, []byte("Test String"))
+	if err == nil || sig != nil {
+		t.Error("Empty suite is accepted")
+	}
+
 	//Empty public key
-	sig, err = ECDSASign(nil, []byte("Test String"))
+	sig, err = ECDSASign(suite, nil, []byte("Test String"))
 	if err == nil || sig != nil {
 		t.Error("Empty public key is accepted")
 	}
 
 	//Empty message
-	sig, err = ECDSASign(priv, nil)
+	sig, err = ECDSASign(suite, priv, nil)
 	if err == nil || sig != nil {
 		t.Error("Empty message is accepted")
 	}
@@ -34,10 +49,10 @@ func TestECDSAVerify(t *testing.T) {
 	//Correct signature
 	priv := suite.Scalar().Pick(random.Stream)
 	msg := []byte("Test String")
-	sig, _ := ECDSASign(priv, msg)
+	sig, _ := ECDSASign(suite, priv, msg)
 
 	//Normal signature
-	check := ECDSAVerify(suite.Point().Mul(nil, priv), msg, sig)
+	check := ECDSAVerify(suite, suite.Point().Mul(nil, priv), msg, sig)
 	if check != nil {
 		t.Error("Cannot verify signatures")
 	}
@@ -46,7 +61,7 @@ func TestECDSAVerify(t *testing.T) {
 	var fake []byte
 	copy(fake, msg)
 	fake = append(fake, []byte("A")...)
-	check = ECDSAVerify(suite.Point().Mul(nil, priv), fake, sig)
+	check = ECDSAVerify(suite, suite.Point().Mul(nil, priv), fake, sig)
 	if check == nil {
 		t.Error("Wrong check: Message edited")
 	}
@@ -54,42 +69,105 @@ func TestECDSAVerify(t *testing.T) {
 	//Signature modification
 	newsig := append([]byte("A"), sig...)
 	newsig = newsig[:len(sig)]
-	check = ECDSAVerify(suite.Point().Mul(nil, priv), msg, newsig)
+	check = ECDSAVerify(suite, suite.Point().Mul(nil, priv), msg, newsig)
 	if check == nil {
 		t.Error("Wrong check: signature changed")
 	}
 
+	//Empty suite
+	check = ECDSAVerify(nil, suite.Point().Mul(nil, priv), msg, sig)
+	if check == nil {
+		t.Error("Wrong check: empty suite")
+	}
+
 	//Empty public key
-	check = ECDSAVerify(nil, msg, sig)
+	check = ECDSAVerify(suite, nil, msg, sig)
 	if check == nil {
 		t.Error("Wrong check: empty public key")
 	}
 
 	//Empty message
-	check = ECDSAVerify(suite.Point().Mul(nil, priv), nil, sig)
+	check = ECDSAVerify(suite, suite.Point().Mul(nil, priv), nil, sig)
 	if check == nil {
 		t.Error("Wrong check: empty message")
 	}
 
 	//0 length message
-	check = ECDSAVerify(suite.Point().Mul(nil, priv), []byte{}, sig)
+	check = ECDSAVerify(suite, suite.Point().Mul(nil, priv), []byte{}, sig)
 	if check == nil {
 		t.Error("Wrong check: 0 length message")
 	}
 
 	//Empty signature
-	check = ECDSAVerify(suite.Point().Mul(nil, priv), msg, nil)
+	check = ECDSAVerify(suite, suite.Point().Mul(nil, priv), msg, nil)
 	if check == nil {
 		t.Error("Wrong check: empty signature")
 	}
 
 	//0 length signature
-	check = ECDSAVerify(suite.Point().Mul(nil, priv), msg, []byte{})
+	check = ECDSAVerify(suite, suite.Point().Mul(nil, priv), msg, []byte{})
 	if check == nil {
 		t.Error("Wrong check: 0 length signature")
 	}
 }
 
+func TestLookupSuite(t *testing.T) {
+	//Registered suites
+	for _, name := range []string{"Ed25519", "P256"} {
+		s, err := LookupSuite(name)
+		if err != nil || s == nil || s.String() != name {
+			t.Errorf("Cannot find the suite %s", name)
+		}
+	}
+
+	//Unknown suite
+	s, err := LookupSuite("Unknown")
+	if err == nil || s != nil {
+		t.Error("Wrong check: Unknown suite")
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	_, _, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	p256, _ := LookupSuite("P256")
+
+	//Normal execution
+	c, err := NewContext(p256, context.G, context.R, context.H)
+	if err != nil || c == nil || c.Suite() != p256 {
+		t.Error("Cannot create a context")
+	}
+
+	//Empty suite
+	c, err = NewContext(nil, context.G, context.R, context.H)
+	if err == nil || c != nil {
+		t.Error("Wrong check: Empty suite")
+	}
+
+	//Contexts without a suite use the default one
+	c = &Context{G: context.G, R: context.R, H: context.H}
+	if c.Suite() != Suite {
+		t.Error("Default suite not used")
+	}
+}
+
+func TestSuiteMismatch(t *testing.T) {
+	p256, _ := LookupSuite("P256")
+	clients, servers, _, _ := generateTestContextSuite(p256, 1, 1)
+	_, _, context, _ := generateTestContext(1, 1)
+
+	//A client cannot use a context of another suite
+	_, _, _, err := clients[0].CreateRequest(context)
+	if err == nil {
+		t.Error("Wrong check: Client suite mismatch")
+	}
+
+	//Neither can a server
+	_, _, err = servers[0].GenerateCommitment(context)
+	if err == nil {
+		t.Error("Wrong check: Server suite mismatch")
+	}
+}
+
 func TestToBytes(t *testing.T) {
 	c := rand.Intn(10) + 1
 	s := rand.Intn(10) + 1
@@ -0,0 +1,144 @@
+package daga
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//commitsDigest identifies a round by the commitments R of the servers
+type commitsDigest [sha256.Size]byte
+
+/*GeneratorCache computes the per-round generators of the clients on demand and keeps them, indexed by the commitments R of the round and the index of the client
+It lets servers with many members generate and validate only the generators they need instead of the whole H slice. It is safe for concurrent use*/
+type GeneratorCache struct {
+	suite  abstract.Suite
+	mutex  sync.Mutex
+	rounds map[commitsDigest]map[int]abstract.Point
+}
+
+/*NewGeneratorCache creates an empty cache for the suite*/
+func NewGeneratorCache(suite abstract.Suite) (*GeneratorCache, error) {
+	if suite == nil {
+		return nil, fmt.Errorf("Empty suite")
+	}
+	return &GeneratorCache{suite: suite, rounds: make(map[commitsDigest]map[int]abstract.Point)}, nil
+}
+
+/*digest hashes the commitments of a round*/
+func (cache *GeneratorCache) digest(R []abstract.Point) (d commitsDigest, err error) {
+	if len(R) == 0 {
+		return d, fmt.Errorf("Empty commitments")
+	}
+	hasher := sha256.New()
+	for i, commit := range R {
+		if commit == nil {
+			return d, fmt.Errorf("Empty commitment at index %d", i)
+		}
+		if _, err = commit.MarshalTo(hasher); err != nil {
+			return d, fmt.Errorf("Error in commitment %d: %s", i, err)
+		}
+	}
+	copy(d[:], hasher.Sum(nil))
+	return d, nil
+}
+
+/*Generator returns the generator of the client index for the round of the commitments R, computing it with GenerateClientGenerator on the first request*/
+func (cache *GeneratorCache) Generator(R []abstract.Point, index int) (abstract.Point, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("Wrong index: %d", index)
+	}
+	d, err := cache.digest(R)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mutex.Lock()
+	gen, ok := cache.rounds[d][index]
+	cache.mutex.Unlock()
+	if ok {
+		return gen, nil
+	}
+
+	//The generator is computed outside of the lock, concurrent requests at most compute it twice
+	gen, err = GenerateClientGenerator(cache.suite, index, &R)
+	if err != nil {
+		return nil, err
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	round, ok := cache.rounds[d]
+	if !ok {
+		round = make(map[int]abstract.Point)
+		cache.rounds[d] = round
+	}
+	round[index] = gen
+	return gen, nil
+}
+
+/*Check tells whether H is the generator of the client index for the round of the commitments R*/
+func (cache *GeneratorCache) Check(R []abstract.Point, index int, H abstract.Point) bool {
+	if H == nil {
+		return false
+	}
+	gen, err := cache.Generator(R, index)
+	return err == nil && gen.Equal(H)
+}
+
+/*Forget drops the generators of the round of the commitments R, typically once the servers moved to the next round*/
+func (cache *GeneratorCache) Forget(R []abstract.Point) {
+	d, err := cache.digest(R)
+	if err != nil {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.rounds, d)
+}
+
+/*generators returns the generators of all the clients of the context, through ClientGenerator when the context does not carry H*/
+func (context *Context) generators(cache *GeneratorCache) ([]abstract.Point, error) {
+	if len(context.H) == len(context.G.X) {
+		return context.H, nil
+	}
+	H := make([]abstract.Point, len(context.G.X))
+	for i := range H {
+		var err error
+		if H[i], err = context.ClientGenerator(i, cache); err != nil {
+			return nil, err
+		}
+	}
+	return H, nil
+}
+
+/*checkClientGenerator tells whether H is the generator of the client index for the commitments R of the context, through the cache when there is one for its suite*/
+func (context *Context) checkClientGenerator(index int, H abstract.Point, cache *GeneratorCache) bool {
+	if cache != nil && cache.suite.String() == context.Suite().String() {
+		return cache.Check(context.R, index, H)
+	}
+	if H == nil {
+		return false
+	}
+	gen, err := GenerateClientGenerator(context.Suite(), index, &context.R)
+	return err == nil && gen.Equal(H)
+}
+
+/*ClientGenerator returns the generator of the client index in the context
+It is taken from H when the context carries it, otherwise it is computed from R through the cache, or directly if the cache is nil*/
+func (context *Context) ClientGenerator(index int, cache *GeneratorCache) (abstract.Point, error) {
+	if index < 0 || index >= len(context.G.X) {
+		return nil, fmt.Errorf("Wrong index: %d", index)
+	}
+	if index < len(context.H) {
+		return context.H[index], nil
+	}
+	if cache == nil {
+		return GenerateClientGenerator(context.Suite(), index, &context.R)
+	}
+	if cache.suite.String() != context.Suite().String() {
+		return nil, fmt.Errorf("Cache for another suite: %s", cache.suite.String())
+	}
+	return cache.Generator(context.R, index)
+}
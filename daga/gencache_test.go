@@ -0,0 +1,101 @@
+package daga
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+func TestGeneratorCache(t *testing.T) {
+	_, _, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+1)
+	cache, err := NewGeneratorCache(suite)
+	if err != nil {
+		t.Fatalf("Cannot create the cache: %s", err)
+	}
+
+	//Normal execution, from several goroutines
+	var wg sync.WaitGroup
+	for i := range context.H {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if gen, err := cache.Generator(context.R, i); err != nil || !gen.Equal(context.H[i]) {
+				t.Errorf("Wrong generator %d: %s", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	i := rand.Intn(len(context.H))
+	first, _ := cache.Generator(context.R, i)
+	if again, _ := cache.Generator(context.R, i); again != first {
+		t.Error("Generator not cached")
+	}
+	if !cache.Check(context.R, i, context.H[i]) {
+		t.Error("Valid generator rejected")
+	}
+	if cache.Check(context.R, i, suite.Point().Base()) {
+		t.Error("Wrong check: Generator")
+	}
+
+	//Another round does not share the generators
+	R := append([]abstract.Point{suite.Point().Base()}, context.R[1:]...)
+	if gen, _ := cache.Generator(R, i); gen.Equal(first) {
+		t.Error("Generators shared between rounds")
+	}
+	cache.Forget(context.R)
+	if again, _ := cache.Generator(context.R, i); again == first || !again.Equal(first) {
+		t.Error("Round not forgotten")
+	}
+
+	//Empty inputs
+	if _, err = NewGeneratorCache(nil); err == nil {
+		t.Error("Wrong check: Empty suite")
+	}
+	if _, err = cache.Generator(nil, 0); err == nil {
+		t.Error("Wrong check: Empty commitments")
+	}
+	if _, err = cache.Generator(context.R, -1); err == nil {
+		t.Error("Wrong check: Negative index")
+	}
+}
+
+func TestContext_ClientGenerator(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+2)
+	cache, _ := NewGeneratorCache(suite)
+	i := rand.Intn(len(context.H))
+
+	//Normal execution, with and without H
+	lazy := *context
+	lazy.H = nil
+	for _, c := range []*Context{context, &lazy} {
+		for _, cc := range []*GeneratorCache{cache, nil} {
+			if gen, err := c.ClientGenerator(i, cc); err != nil || !gen.Equal(context.H[i]) {
+				t.Errorf("Wrong generator: %s", err)
+			}
+		}
+	}
+
+	//The servers check the proofs without H
+	request, err := clients[0].CreateNonInteractiveMessage(&lazy)
+	if err != nil {
+		t.Fatalf("Cannot create a message without H: %s", err)
+	}
+	msg := servers[0].InitializeServerMessage(request)
+	for j := range servers {
+		if err = servers[j].ServerProtocol(&lazy, msg); err != nil {
+			t.Fatalf("Cannot verify a message without H: %s", err)
+		}
+	}
+
+	//Wrong inputs
+	if _, err := lazy.ClientGenerator(len(context.G.X), cache); err == nil {
+		t.Error("Wrong check: Index out of range")
+	}
+	p256, _ := LookupSuite("P256")
+	other, _ := NewGeneratorCache(p256)
+	if _, err := lazy.ClientGenerator(i, other); err == nil {
+		t.Error("Wrong check: Cache for another suite")
+	}
+}
@@ -5,13 +5,17 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"runtime"
 
 	"gopkg.in/dedis/crypto.v0/abstract"
 	"gopkg.in/dedis/crypto.v0/random"
 )
 
-/*GenerateClientGenerator generates a per-round generator for a given client*/
-func GenerateClientGenerator(index int, commits *[]abstract.Point) (gen abstract.Point, err error) {
+/*GenerateClientGenerator generates a per-round generator for a given client in the given suite*/
+func GenerateClientGenerator(suite abstract.Suite, index int, commits *[]abstract.Point) (gen abstract.Point, err error) {
+	if suite == nil {
+		return nil, fmt.Errorf("Empty suite")
+	}
 	if index < 0 {
 		return nil, fmt.Errorf("Wrond index: %d", index)
 	}
@@ -33,8 +37,125 @@ func GenerateClientGenerator(index int, commits *[]abstract.Point) (gen abstract
 	return
 }
 
-func generateTestContext(c, s int) (clients []Client, servers []Server, context *ContextEd25519, err error) {
-	context = &ContextEd25519{}
+/*NextRound creates the context of the round following this one from the commitments R of the servers to their new secrets.
+The members are kept and the per-round generators of the clients are recomputed from R, unless the context leaves H empty*/
+func (context *Context) NextRound(R []abstract.Point) (*Context, error) {
+	if len(R) != len(context.G.Y) {
+		return nil, fmt.Errorf("Wrong number of commitments: got %d expected %d", len(R), len(context.G.Y))
+	}
+	for i, commit := range R {
+		if commit == nil {
+			return nil, fmt.Errorf("Empty commitment at index %d", i)
+		}
+		if i < len(context.R) && commit.Equal(context.R[i]) {
+			return nil, fmt.Errorf("Commitment %d was not renewed", i)
+		}
+	}
+
+	suite := context.Suite()
+	next := Context{suite: suite, round: context.round + 1, R: R}
+	next.G.X = append([]abstract.Point{}, context.G.X...)
+	next.G.Y = append([]abstract.Point{}, context.G.Y...)
+	//A context without H stays without
+	if len(context.H) != 0 {
+		H, err := clientGenerators(suite, 0, len(next.G.X), next.R)
+		if err != nil {
+			return nil, err
+		}
+		next.H = H
+	}
+	return &next, nil
+}
+
+/*GenerateContext creates the first context of the members G from the commitments R of the servers to their round secrets.
+The per-round generators of the clients are computed from R*/
+func GenerateContext(suite abstract.Suite, G Members, R []abstract.Point) (*Context, error) {
+	if suite == nil {
+		return nil, fmt.Errorf("Empty suite")
+	}
+	if len(G.X) == 0 || len(G.Y) == 0 {
+		return nil, fmt.Errorf("Empty members")
+	}
+	if len(R) != len(G.Y) {
+		return nil, fmt.Errorf("Wrong number of commitments: got %d expected %d", len(R), len(G.Y))
+	}
+	H, err := clientGenerators(suite, 0, len(G.X), R)
+	if err != nil {
+		return nil, err
+	}
+	context := Context{suite: suite, R: append([]abstract.Point{}, R...), H: H}
+	context.G.X = append([]abstract.Point{}, G.X...)
+	context.G.Y = append([]abstract.Point{}, G.Y...)
+	return &context, nil
+}
+
+/*AddClients creates a context of the same round with the public keys X appended to the clients.
+The servers and their commitments are kept, so the new clients only need their own generators, unless the context leaves H empty*/
+func (context *Context) AddClients(X []abstract.Point) (*Context, error) {
+	if len(X) == 0 {
+		return nil, fmt.Errorf("No client to add")
+	}
+	suite := context.Suite()
+	next := Context{suite: suite, round: context.round, R: append([]abstract.Point{}, context.R...)}
+	next.G.X = append(append([]abstract.Point{}, context.G.X...), X...)
+	next.G.Y = append([]abstract.Point{}, context.G.Y...)
+	if len(context.H) != 0 {
+		H, err := clientGenerators(suite, len(context.G.X), len(context.G.X)+len(X), context.R)
+		if err != nil {
+			return nil, err
+		}
+		next.H = append(append([]abstract.Point{}, context.H...), H...)
+	}
+	return &next, nil
+}
+
+/*clientGenerators computes the per-round generators of the clients from index from to index to (excluded)*/
+func clientGenerators(suite abstract.Suite, from, to int, R []abstract.Point) ([]abstract.Point, error) {
+	var H []abstract.Point
+	for i := from; i < to; i++ {
+		gen, err := GenerateClientGenerator(suite, i, &R)
+		if err != nil {
+			return nil, fmt.Errorf("Error in client's generators:\n%s", err)
+		}
+		H = append(H, gen)
+	}
+	return H, nil
+}
+
+/*RotateRound moves all the servers of the context to the next round and returns the new context.
+servers must hold every server of the context, they are all checked before any secret is erased*/
+func RotateRound(context *Context, servers []Server) (*Context, error) {
+	if context == nil || len(servers) != len(context.G.Y) {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	seen := make([]bool, len(servers))
+	for _, server := range servers {
+		if err := server.CheckContext(context); err != nil {
+			return nil, err
+		}
+		if seen[server.index] {
+			return nil, fmt.Errorf("Wrong index: %d", server.index)
+		}
+		seen[server.index] = true
+	}
+
+	R := make([]abstract.Point, len(servers))
+	for i := range servers {
+		commit, err := servers[i].RotateRoundSecret(context)
+		if err != nil {
+			return nil, err
+		}
+		R[servers[i].index] = commit
+	}
+	return context.NextRound(R)
+}
+
+func generateTestContext(c, s int) (clients []Client, servers []Server, context *Context, err error) {
+	return generateTestContextSuite(Suite, c, s)
+}
+
+func generateTestContextSuite(suite abstract.Suite, c, s int) (clients []Client, servers []Server, context *Context, err error) {
+	context = &Context{suite: suite}
 	if c <= 0 {
 		return nil, nil, nil, fmt.Errorf("Invalid number of client asked: %d", c)
 	}
@@ -45,7 +166,15 @@ func generateTestContext(c, s int) (clients []Client, servers []Server, context
 
 	//Generates s servers
 	for i := 0; i < s; i++ {
-		new := Server{index: i, private: suite.Scalar().Pick(random.Stream)}
+		generators, err := NewGeneratorCache(suite)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		contexts, err := NewContextCache(DefaultContextCacheSize)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		new := Server{suite: suite, index: i, private: suite.Scalar().Pick(random.Stream), workers: runtime.NumCPU(), generators: generators, contexts: contexts}
 		context.G.Y = append(context.G.Y, suite.Point().Mul(nil, new.private))
 		servers = append(servers, new)
 	}
@@ -58,11 +187,11 @@ func generateTestContext(c, s int) (clients []Client, servers []Server, context
 
 	//Generates c clients with their per-round generators
 	for i := 0; i < c; i++ {
-		new := Client{index: i, private: suite.Scalar().Pick(random.Stream)}
+		new := Client{suite: suite, index: i, private: suite.Scalar().Pick(random.Stream)}
 		context.G.X = append(context.G.X, suite.Point().Mul(nil, new.private))
 		clients = append(clients, new)
 
-		temp, err := GenerateClientGenerator(i, &context.R)
+		temp, err := GenerateClientGenerator(suite, i, &context.R)
 		if err != nil {
 			return nil, nil, nil, fmt.Errorf("Error in client's generators:\n%s", err)
 		}
@@ -81,25 +81,182 @@ func TestGenerateClientGenerator(t *testing.T) {
 	for i := 0; i < rand.Intn(10)+1; i++ {
 		commits = append(commits, suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream)))
 	}
-	h, err := GenerateClientGenerator(index, &commits)
+	h, err := GenerateClientGenerator(suite, index, &commits)
 	if err != nil || h == nil {
 		t.Errorf("Cannot generate generator with index: %d", index)
 	}
-	h, err = GenerateClientGenerator(0, &commits)
+	h, err = GenerateClientGenerator(suite, 0, &commits)
 	if err != nil || h == nil {
 		t.Error("Cannot generate generator with index 0")
 	}
 
 	//Test wrong execution of the function
 	neg := -rand.Int()
-	h, err = GenerateClientGenerator(neg, &commits)
+	h, err = GenerateClientGenerator(suite, neg, &commits)
 	if h != nil || err == nil {
 		t.Errorf("Error in handling negative index: %d", index)
 	}
 
-	h, err = GenerateClientGenerator(index, new([]abstract.Point))
+	h, err = GenerateClientGenerator(suite, index, new([]abstract.Point))
 	if h != nil || err == nil {
 		t.Errorf("Error in handling empty commits")
 	}
 
+	h, err = GenerateClientGenerator(nil, index, &commits)
+	if h != nil || err == nil {
+		t.Errorf("Error in handling empty suite")
+	}
+
+}
+
+func TestRotateRound(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	i := rand.Intn(len(clients))
+	old, err := clients[i].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	var secrets []abstract.Scalar
+	for _, server := range servers {
+		secrets = append(secrets, server.r)
+	}
+
+	//Normal execution
+	next, err := RotateRound(context, servers)
+	if err != nil || next == nil {
+		t.Fatalf("Cannot rotate the round: %s", err)
+	}
+	if next.Round() != context.Round()+1 {
+		t.Errorf("Wrong round: %d", next.Round())
+	}
+	for j, server := range servers {
+		if server.round != next.Round() {
+			t.Errorf("Server %d not moved to the new round", j)
+		}
+		if !secrets[j].Equal(suite.Scalar().Zero()) {
+			t.Errorf("Previous secret of server %d not erased", j)
+		}
+		if !next.R[j].Equal(suite.Point().Mul(nil, server.r)) || next.R[j].Equal(context.R[j]) {
+			t.Errorf("Wrong commitment for server %d", j)
+		}
+	}
+	for j := range next.H {
+		expected, _ := GenerateClientGenerator(suite, j, &next.R)
+		if !next.H[j].Equal(expected) {
+			t.Errorf("Wrong generator for client %d", j)
+		}
+	}
+	endpoint := &localEndpoint{context: next, servers: servers}
+	session, _ := NewClientSession(&clients[i], next)
+	if _, err = session.Authenticate(endpoint); err != nil {
+		t.Errorf("Cannot authenticate in the new round: %s", err)
+	}
+
+	//Request built against the previous round
+	if _, err = endpoint.SubmitMessage(old); err == nil {
+		t.Error("Wrong check: Request of the previous round")
+	}
+
+	//Servers not at the round of the context
+	if _, err = RotateRound(context, servers); err == nil {
+		t.Error("Wrong check: Rotation from a previous round")
+	}
+
+	//Missing server
+	if _, err = RotateRound(next, servers[1:]); err == nil {
+		t.Error("Wrong check: Missing server")
+	}
+	if _, err = RotateRound(nil, servers); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
+}
+
+func TestNextRound(t *testing.T) {
+	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	var R []abstract.Point
+	for i := range servers {
+		R = append(R, servers[i].GenerateNewRoundSecret())
+	}
+
+	//Normal execution
+	next, err := context.NextRound(R)
+	if err != nil || next.Round() != 1 || len(next.H) != len(context.H) {
+		t.Fatalf("Cannot create the next context: %s", err)
+	}
+
+	//Wrong number of commitments
+	if _, err = context.NextRound(R[1:]); err == nil {
+		t.Error("Wrong check: Missing commitment")
+	}
+
+	//Commitment not renewed
+	if _, err = next.NextRound(R); err == nil {
+		t.Error("Wrong check: Commitment not renewed")
+	}
+
+	//Empty commitment
+	R[0] = nil
+	if _, err = context.NextRound(R); err == nil {
+		t.Error("Wrong check: Empty commitment")
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+
+	//Normal execution
+	generated, err := GenerateContext(suite, context.G, context.R)
+	if err != nil {
+		t.Fatalf("Cannot generate the context: %s", err)
+	}
+	id, _ := context.ID()
+	if generatedID, _ := generated.ID(); generatedID != id {
+		t.Error("Wrong context")
+	}
+	if err = ValidateContext(generated, nil); err != nil {
+		t.Errorf("Generated context rejected: %s", err)
+	}
+	endpoint := &localEndpoint{context: generated, servers: servers}
+	session, _ := NewClientSession(&clients[0], generated)
+	if _, err = session.Authenticate(endpoint); err != nil {
+		t.Errorf("Cannot authenticate in the generated context: %s", err)
+	}
+
+	//Wrong inputs
+	if _, err = GenerateContext(nil, context.G, context.R); err == nil {
+		t.Error("Wrong check: Empty suite")
+	}
+	if _, err = GenerateContext(suite, Members{Y: context.G.Y}, context.R); err == nil {
+		t.Error("Wrong check: Empty members")
+	}
+	if _, err = GenerateContext(suite, context.G, context.R[1:]); err == nil {
+		t.Error("Wrong check: Missing commitment")
+	}
+}
+
+func TestAddClients(t *testing.T) {
+	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	client, _ := CreateClient(suite, len(context.G.X), nil)
+
+	//Normal execution
+	added, err := context.AddClients([]abstract.Point{client.GetPublicKey()})
+	if err != nil {
+		t.Fatalf("Cannot add the client: %s", err)
+	}
+	if len(added.G.X) != len(context.G.X)+1 || added.Round() != context.Round() {
+		t.Error("Wrong context")
+	}
+	if err = ValidateContext(added, nil); err != nil {
+		t.Errorf("Context rejected: %s", err)
+	}
+	endpoint := &localEndpoint{context: added, servers: servers}
+	session, _ := NewClientSession(&client, added)
+	if _, err = session.Authenticate(endpoint); err != nil {
+		t.Errorf("Cannot authenticate the new client: %s", err)
+	}
+
+	//Empty list
+	if _, err = context.AddClients(nil); err == nil {
+		t.Error("Wrong check: No client")
+	}
 }
@@ -0,0 +1,298 @@
+package daga
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+//keyFileVersion is the version of the file format written by SaveKey
+const keyFileVersion = 1
+
+//Parameters of the scrypt key derivation
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+//PasswordEnv is the environment variable holding the password of the key files when no password file is given
+const PasswordEnv = "DAGA_PASSWORD"
+
+//Kinds of keys stored in a key file
+const (
+	keyKindServer = "server"
+	keyKindClient = "client"
+)
+
+/*keyHeader is the public part of a key file
+It is authenticated along with the encrypted secrets*/
+type keyHeader struct {
+	Version int
+	Kind    string
+	Suite   string
+	Index   int
+	Round   uint64
+	Salt    []byte
+	Nonce   []byte
+}
+
+/*keyFile is the content of a file storing a private key encrypted with a password*/
+type keyFile struct {
+	Header     keyHeader
+	Ciphertext []byte
+}
+
+/*keySecrets holds the encrypted values
+R is the per-round secret of a server, if it was generated*/
+type keySecrets struct {
+	Private []byte
+	R       []byte
+}
+
+/*SaveKey writes the private key and the per-round secret of the server to a file encrypted with the password
+The file is only readable by its owner*/
+func (server *Server) SaveKey(path string, password []byte) error {
+	return server.saveKey(path, password, server.r, server.round)
+}
+
+/*saveKey writes the private key of the server with the per-round secret r of the given round*/
+func (server *Server) saveKey(path string, password []byte, r abstract.Scalar, round uint64) error {
+	secrets := keySecrets{}
+	var err error
+	if secrets.Private, err = server.private.MarshalBinary(); err != nil {
+		return fmt.Errorf("Error in private key: %s", err)
+	}
+	if r != nil {
+		if secrets.R, err = r.MarshalBinary(); err != nil {
+			return fmt.Errorf("Error in round secret: %s", err)
+		}
+	}
+	return saveKey(path, password, keyHeader{Kind: keyKindServer, Suite: server.suite.String(), Index: server.index, Round: round}, secrets)
+}
+
+/*RotateKey moves the server to the round following the one of the context like RotateRoundSecret, and saves the new secret to its key file
+The file is replaced before the previous secret is erased, so a failure leaves both the server and the file at the previous round*/
+func (server *Server) RotateKey(context *Context, path string, password []byte) (R abstract.Point, err error) {
+	if err = server.checkRotation(context); err != nil {
+		return nil, err
+	}
+	r := server.suite.Scalar().Pick(random.Stream)
+	if err = server.saveKey(path, password, r, context.round+1); err != nil {
+		eraseScalar(r)
+		return nil, err
+	}
+	server.eraseRoundSecret()
+	server.r = r
+	server.nextRound(context)
+	return server.suite.Point().Mul(nil, r), nil
+}
+
+/*LoadServer reads a server written by SaveKey, including its per-round secret and round*/
+func LoadServer(path string, password []byte) (server Server, err error) {
+	header, secrets, err := loadKey(path, password, keyKindServer)
+	if err != nil {
+		return Server{}, err
+	}
+	suite, err := LookupSuite(header.Suite)
+	if err != nil {
+		return Server{}, err
+	}
+	private, err := decodeScalar(suite, secrets.Private)
+	if err != nil {
+		return Server{}, fmt.Errorf("Error in private key: %s", err)
+	}
+	server, err = CreateServer(suite, header.Index, private)
+	if err != nil {
+		return Server{}, err
+	}
+	server.round = header.Round
+	if len(secrets.R) != 0 {
+		if server.r, err = decodeScalar(suite, secrets.R); err != nil {
+			return Server{}, fmt.Errorf("Error in round secret: %s", err)
+		}
+	}
+	return server, nil
+}
+
+/*SaveKey writes the private key of the client to a file encrypted with the password
+The file is only readable by its owner*/
+func (client *Client) SaveKey(path string, password []byte) error {
+	private, err := client.private.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("Error in private key: %s", err)
+	}
+	return saveKey(path, password, keyHeader{Kind: keyKindClient, Suite: client.suite.String(), Index: client.index}, keySecrets{Private: private})
+}
+
+/*LoadClient reads a client written by SaveKey*/
+func LoadClient(path string, password []byte) (client Client, err error) {
+	header, secrets, err := loadKey(path, password, keyKindClient)
+	if err != nil {
+		return Client{}, err
+	}
+	suite, err := LookupSuite(header.Suite)
+	if err != nil {
+		return Client{}, err
+	}
+	private, err := decodeScalar(suite, secrets.Private)
+	if err != nil {
+		return Client{}, fmt.Errorf("Error in private key: %s", err)
+	}
+	return CreateClient(suite, header.Index, private)
+}
+
+/*ReadPassword reads the password of a key file from the file at path, or from PasswordEnv if path is empty
+The line ending of the file is removed*/
+func ReadPassword(path string) ([]byte, error) {
+	if path == "" {
+		password := os.Getenv(PasswordEnv)
+		if password == "" {
+			return nil, fmt.Errorf("No password: no password file given and %s not set", PasswordEnv)
+		}
+		return []byte(password), nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the password: %s", err)
+	}
+	return []byte(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+/*keyCipher derives the encryption key from the password and the salt*/
+func keyCipher(password, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(password, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("Error in key derivation: %s", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func saveKey(path string, password []byte, header keyHeader, secrets keySecrets) error {
+	if len(password) == 0 {
+		return fmt.Errorf("Empty password")
+	}
+	header.Version = keyFileVersion
+	header.Salt = make([]byte, 32)
+	if _, err := rand.Read(header.Salt); err != nil {
+		return fmt.Errorf("Cannot generate the salt: %s", err)
+	}
+	aead, err := keyCipher(password, header.Salt)
+	if err != nil {
+		return err
+	}
+	header.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(header.Nonce); err != nil {
+		return fmt.Errorf("Cannot generate the nonce: %s", err)
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	additional, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keyFile{Header: header, Ciphertext: aead.Seal(nil, header.Nonce, plaintext, additional)}, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(path, data)
+}
+
+/*writeKeyFile replaces the file at path with data, readable by its owner only
+The data is synced to a new file of the same directory which is then renamed over path, so a crash leaves either the previous key or the new one*/
+func writeKeyFile(path string, data []byte) (err error) {
+	suffix := make([]byte, 8)
+	if _, err = rand.Read(suffix); err != nil {
+		return fmt.Errorf("Cannot name the temporary key file: %s", err)
+	}
+	temp := fmt.Sprintf("%s.%s.tmp", path, hex.EncodeToString(suffix))
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("Cannot write the key: %s", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(temp)
+		}
+	}()
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("Cannot write the key: %s", err)
+	}
+	if err = os.Rename(temp, path); err != nil {
+		return fmt.Errorf("Cannot replace the key: %s", err)
+	}
+	//The rename is only durable once the directory is synced
+	if dir, e := os.Open(filepath.Dir(path)); e == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+func loadKey(path string, password []byte, kind string) (header keyHeader, secrets keySecrets, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return header, secrets, fmt.Errorf("Cannot read the key: %s", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return header, secrets, fmt.Errorf("Permissions %#o of %s are too open, the key must only be accessible by its owner", info.Mode().Perm(), path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return header, secrets, fmt.Errorf("Cannot read the key: %s", err)
+	}
+	var file keyFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return header, secrets, fmt.Errorf("Cannot json unmarshal the key: %s", err)
+	}
+	header = file.Header
+	if header.Version != keyFileVersion {
+		return header, secrets, fmt.Errorf("Unsupported key file version: %d", header.Version)
+	}
+	if header.Kind != kind {
+		return header, secrets, fmt.Errorf("Wrong kind of key: got %s expected %s", header.Kind, kind)
+	}
+	aead, err := keyCipher(password, header.Salt)
+	if err != nil {
+		return header, secrets, err
+	}
+	if len(header.Nonce) != aead.NonceSize() {
+		return header, secrets, fmt.Errorf("Invalid nonce")
+	}
+	additional, err := json.Marshal(header)
+	if err != nil {
+		return header, secrets, err
+	}
+	plaintext, err := aead.Open(nil, header.Nonce, file.Ciphertext, additional)
+	if err != nil {
+		return header, secrets, fmt.Errorf("Cannot decrypt the key: wrong password or corrupted file")
+	}
+	if err = json.Unmarshal(plaintext, &secrets); err != nil {
+		return header, secrets, fmt.Errorf("Cannot json unmarshal the secrets: %s", err)
+	}
+	return header, secrets, nil
+}
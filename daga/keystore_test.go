@@ -0,0 +1,211 @@
+package daga
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoadServerKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatalf("Cannot create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.key")
+	password := []byte("correct horse battery staple")
+
+	_, servers, _, _ := generateTestContext(1, rand.Intn(10)+1)
+	server := servers[rand.Intn(len(servers))]
+	server.round = uint64(rand.Intn(10))
+
+	//Normal execution
+	if err = server.SaveKey(path, password); err != nil {
+		t.Fatalf("Cannot save the key: %s", err)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Wrong permissions: %#o", info.Mode().Perm())
+	}
+	loaded, err := LoadServer(path, password)
+	if err != nil {
+		t.Fatalf("Cannot load the key: %s", err)
+	}
+	if loaded.index != server.index || !loaded.private.Equal(server.private) {
+		t.Error("Loaded key differs from the saved one")
+	}
+	if loaded.r == nil || !loaded.r.Equal(server.r) || loaded.round != server.round {
+		t.Error("Round secret not restored")
+	}
+
+	//Wrong password
+	if _, err = LoadServer(path, []byte("wrong")); err == nil {
+		t.Error("Wrong check: Wrong password")
+	}
+
+	//Wrong kind of key
+	if _, err = LoadClient(path, password); err == nil {
+		t.Error("Wrong check: Server key loaded as a client")
+	}
+
+	//Tampered header
+	data, _ := ioutil.ReadFile(path)
+	var file keyFile
+	json.Unmarshal(data, &file)
+	file.Header.Index++
+	data, _ = json.Marshal(file)
+	ioutil.WriteFile(path, data, 0600)
+	if _, err = LoadServer(path, password); err == nil {
+		t.Error("Wrong check: Tampered header")
+	}
+
+	//Permissions too open
+	server.SaveKey(path, password)
+	os.Chmod(path, 0644)
+	if _, err = LoadServer(path, password); err == nil {
+		t.Error("Wrong check: Permissions too open")
+	}
+
+	//The key replaces the previous file, with restricted permissions and without leaving a temporary file
+	if err = server.SaveKey(path, password); err != nil {
+		t.Fatalf("Cannot replace the key: %s", err)
+	}
+	if info, _ = os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Wrong permissions of the replaced key: %#o", info.Mode().Perm())
+	}
+	if _, err = LoadServer(path, password); err != nil {
+		t.Errorf("Cannot load the replaced key: %s", err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Wrong number of files: %d instead of 1", len(files))
+	}
+
+	//Missing directory
+	if err = server.SaveKey(filepath.Join(dir, "missing", "server.key"), password); err == nil {
+		t.Error("Wrong check: Missing directory")
+	}
+
+	//Empty password
+	if err = server.SaveKey(filepath.Join(dir, "empty.key"), nil); err == nil {
+		t.Error("Wrong check: Empty password")
+	}
+
+	//Missing file
+	if _, err = LoadServer(filepath.Join(dir, "missing.key"), password); err == nil {
+		t.Error("Wrong check: Missing file")
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatalf("Cannot create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.key")
+	password := []byte("correct horse battery staple")
+
+	_, servers, context, _ := generateTestContext(1, rand.Intn(10)+1)
+	server := servers[rand.Intn(len(servers))]
+	server.SaveKey(path, password)
+	previous := server.r
+
+	//Normal execution
+	R, err := server.RotateKey(context, path, password)
+	if err != nil {
+		t.Fatalf("Cannot rotate the key: %s", err)
+	}
+	if server.round != context.round+1 || !R.Equal(suite.Point().Mul(nil, server.r)) {
+		t.Error("Server not moved to the next round")
+	}
+	if !previous.Equal(suite.Scalar().Zero()) {
+		t.Error("Previous r was not erased")
+	}
+	loaded, err := LoadServer(path, password)
+	if err != nil || loaded.round != server.round || !loaded.r.Equal(server.r) {
+		t.Errorf("Round secret of the next round not saved: %s", err)
+	}
+
+	//Context of another round
+	if _, err = server.RotateKey(context, path, password); err == nil {
+		t.Error("Wrong check: Context of another round")
+	}
+
+	//A failed save leaves the server at its round
+	server.round = context.round
+	r := suite.Scalar().Set(server.r)
+	if _, err = server.RotateKey(context, filepath.Join(dir, "missing", "server.key"), password); err == nil {
+		t.Error("Wrong check: Missing directory")
+	}
+	if server.round != context.round || !server.r.Equal(r) {
+		t.Error("Server rotated despite the failed save")
+	}
+}
+
+func TestSaveLoadClientKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatalf("Cannot create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "client.key")
+	password := []byte("correct horse battery staple")
+
+	p256, _ := LookupSuite("P256")
+	clients, _, _, _ := generateTestContextSuite(p256, rand.Intn(10)+1, 1)
+	client := clients[rand.Intn(len(clients))]
+
+	//Normal execution
+	if err = client.SaveKey(path, password); err != nil {
+		t.Fatalf("Cannot save the key: %s", err)
+	}
+	loaded, err := LoadClient(path, password)
+	if err != nil {
+		t.Fatalf("Cannot load the key: %s", err)
+	}
+	if loaded.suite != p256 || loaded.index != client.index || !loaded.private.Equal(client.private) {
+		t.Error("Loaded key differs from the saved one")
+	}
+
+	//Wrong password
+	if _, err = LoadClient(path, []byte("wrong")); err == nil {
+		t.Error("Wrong check: Wrong password")
+	}
+
+	//Wrong kind of key
+	if _, err = LoadServer(path, password); err == nil {
+		t.Error("Wrong check: Client key loaded as a server")
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv(PasswordEnv, os.Getenv(PasswordEnv))
+
+	//Normal execution, from a file or the environment
+	path := filepath.Join(dir, "password")
+	ioutil.WriteFile(path, []byte("secret\r\n"), 0600)
+	if password, err := ReadPassword(path); err != nil || string(password) != "secret" {
+		t.Errorf("Wrong password from the file: %q %s", password, err)
+	}
+	os.Setenv(PasswordEnv, "other")
+	if password, err := ReadPassword(""); err != nil || string(password) != "other" {
+		t.Errorf("Wrong password from the environment: %q %s", password, err)
+	}
+
+	//No password
+	os.Setenv(PasswordEnv, "")
+	if _, err = ReadPassword(""); err == nil {
+		t.Error("Wrong check: No password")
+	}
+	if _, err = ReadPassword(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Wrong check: Missing file")
+	}
+}
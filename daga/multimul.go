@@ -0,0 +1,148 @@
+package daga
+
+import (
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//multiMulWindow is the number of bits of the scalars handled at each step of MultiMul and BaseTable
+const multiMulWindow = 4
+
+/*littleEndian tells the byte order of the marshaling of the scalars of the suite
+One is marshaled with a single non-zero byte, the least significant one*/
+func littleEndian(suite abstract.Suite) bool {
+	one, err := suite.Scalar().One().MarshalBinary()
+	return err == nil && len(one) > 1 && one[0] == 1
+}
+
+/*scalarDigits returns the 4-bit digits of the scalar, from the most significant one*/
+func scalarDigits(s abstract.Scalar, little bool) ([]byte, error) {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("Error in scalar: %s", err)
+	}
+	digits := make([]byte, 2*len(data))
+	for i, b := range data {
+		if little {
+			b = data[len(data)-1-i]
+		}
+		digits[2*i] = b >> multiMulWindow
+		digits[2*i+1] = b & (1<<multiMulWindow - 1)
+	}
+	return digits, nil
+}
+
+/*multiples returns the multiples of the point by every digit, starting from the neutral element*/
+func multiples(suite abstract.Suite, base abstract.Point) []abstract.Point {
+	table := make([]abstract.Point, 1<<multiMulWindow)
+	table[0] = suite.Point().Null()
+	for d := 1; d < len(table); d++ {
+		table[d] = suite.Point().Add(table[d-1], base)
+	}
+	return table
+}
+
+/*MultiMul computes the sum of the points multiplied by their scalar, a nil point standing for the base point of the suite.
+The products are interleaved (Straus' method) so that they share the doublings, which makes the sum cheaper than separate multiplications.
+Its running time depends on the digits of the scalars, it must only be given public scalars such as the ones of a proof being verified*/
+func MultiMul(suite abstract.Suite, points []abstract.Point, scalars []abstract.Scalar) (abstract.Point, error) {
+	if suite == nil || len(points) != len(scalars) {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	little := littleEndian(suite)
+	tables := make([][]abstract.Point, len(points))
+	digits := make([][]byte, len(points))
+	for j := range points {
+		var err error
+		if digits[j], err = scalarDigits(scalars[j], little); err != nil {
+			return nil, fmt.Errorf("Error in scalar %d: %s", j, err)
+		}
+		if len(digits[j]) != len(digits[0]) {
+			return nil, fmt.Errorf("Wrong scalar length at index %d", j)
+		}
+		base := points[j]
+		if base == nil {
+			base = suite.Point().Base()
+		}
+		tables[j] = multiples(suite, base)
+	}
+
+	sum := suite.Point().Null()
+	if len(points) == 0 {
+		return sum, nil
+	}
+	started := false
+	for k := range digits[0] {
+		if started {
+			for b := 0; b < multiMulWindow; b++ {
+				sum.Add(sum, sum)
+			}
+		}
+		for j := range digits {
+			if d := digits[j][k]; d != 0 {
+				sum.Add(sum, tables[j][d])
+				started = true
+			}
+		}
+	}
+	return sum, nil
+}
+
+/*BaseTable holds the multiples of a fixed point by every digit at every position of a scalar,
+so that multiplying that point costs an addition per digit and no doubling.
+Building it costs about four multiplications, it pays off when the point is multiplied by many scalars.
+Like MultiMul, it runs in variable time and must only multiply public scalars*/
+type BaseTable struct {
+	suite  abstract.Suite
+	little bool
+	table  [][]abstract.Point //table[k][d] is d times the base times the weight of the digit k
+}
+
+/*NewBaseTable precomputes the table of the point, a nil point standing for the base point of the suite*/
+func NewBaseTable(suite abstract.Suite, base abstract.Point) (*BaseTable, error) {
+	if suite == nil {
+		return nil, fmt.Errorf("Empty suite")
+	}
+	if base == nil {
+		base = suite.Point().Base()
+	}
+	positions := 2 * suite.ScalarLen()
+	table := BaseTable{suite: suite, little: littleEndian(suite), table: make([][]abstract.Point, positions)}
+	weighted := suite.Point().Set(base)
+	for k := positions - 1; k >= 0; k-- {
+		table.table[k] = multiples(suite, weighted)
+		for b := 0; b < multiMulWindow; b++ {
+			weighted.Add(weighted, weighted)
+		}
+	}
+	return &table, nil
+}
+
+/*Mul multiplies the point of the table by the scalar*/
+func (table *BaseTable) Mul(s abstract.Scalar) (abstract.Point, error) {
+	digits, err := scalarDigits(s, table.little)
+	if err != nil {
+		return nil, err
+	}
+	if len(digits) != len(table.table) {
+		return nil, fmt.Errorf("Wrong scalar length: %d", len(digits)/2)
+	}
+	product := table.suite.Point().Null()
+	for k, d := range digits {
+		if d != 0 {
+			product.Add(product, table.table[k][d])
+		}
+	}
+	return product, nil
+}
+
+/*mulAdd returns P*a + Q*b with a single MultiMul, a nil point standing for the base point of the suite*/
+func mulAdd(suite abstract.Suite, P abstract.Point, a abstract.Scalar, Q abstract.Point, b abstract.Scalar) abstract.Point {
+	sum, err := MultiMul(suite, []abstract.Point{P, Q}, []abstract.Scalar{a, b})
+	if err != nil {
+		//Scalars that cannot be marshaled are multiplied separately
+		return suite.Point().Add(suite.Point().Mul(P, a), suite.Point().Mul(Q, b))
+	}
+	return sum
+}
@@ -0,0 +1,162 @@
+package daga
+
+import (
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+func TestMultiMul(t *testing.T) {
+	for _, name := range []string{"Ed25519", "P256"} {
+		suite, _ := LookupSuite(name)
+		n := rand.Intn(10) + 1
+		points := make([]abstract.Point, n)
+		scalars := make([]abstract.Scalar, n)
+		expected := suite.Point().Null()
+		for i := range points {
+			if i > 0 {
+				points[i] = suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+			}
+			scalars[i] = suite.Scalar().Pick(random.Stream)
+			expected = suite.Point().Add(expected, suite.Point().Mul(points[i], scalars[i]))
+		}
+
+		//Normal execution, the first point being the base point
+		sum, err := MultiMul(suite, points, scalars)
+		if err != nil || !sum.Equal(expected) {
+			t.Errorf("%s: Wrong sum: %s", name, err)
+		}
+
+		//Small scalars
+		scalars[0] = suite.Scalar().One()
+		scalars[n-1] = suite.Scalar().Zero()
+		expected = suite.Point().Null()
+		for i := range points {
+			expected = suite.Point().Add(expected, suite.Point().Mul(points[i], scalars[i]))
+		}
+		if sum, err = MultiMul(suite, points, scalars); err != nil || !sum.Equal(expected) {
+			t.Errorf("%s: Wrong sum with small scalars: %s", name, err)
+		}
+
+		//Empty inputs
+		if sum, err = MultiMul(suite, nil, nil); err != nil || !sum.Equal(suite.Point().Null()) {
+			t.Errorf("%s: Empty sum is not the neutral element: %s", name, err)
+		}
+		if _, err = MultiMul(nil, points, scalars); err == nil {
+			t.Errorf("%s: Wrong check: Empty suite", name)
+		}
+		if _, err = MultiMul(suite, points, scalars[1:]); err == nil {
+			t.Errorf("%s: Wrong check: Mismatching lengths", name)
+		}
+	}
+}
+
+func TestBaseTable(t *testing.T) {
+	for _, name := range []string{"Ed25519", "P256"} {
+		suite, _ := LookupSuite(name)
+		base := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+
+		//Normal execution
+		table, err := NewBaseTable(suite, base)
+		if err != nil {
+			t.Fatalf("%s: Cannot build the table: %s", name, err)
+		}
+		for _, s := range []abstract.Scalar{suite.Scalar().Pick(random.Stream), suite.Scalar().One(), suite.Scalar().Zero()} {
+			product, err := table.Mul(s)
+			if err != nil || !product.Equal(suite.Point().Mul(base, s)) {
+				t.Errorf("%s: Wrong product: %s", name, err)
+			}
+		}
+
+		//Base point
+		table, _ = NewBaseTable(suite, nil)
+		s := suite.Scalar().Pick(random.Stream)
+		if product, err := table.Mul(s); err != nil || !product.Equal(suite.Point().Mul(nil, s)) {
+			t.Errorf("%s: Wrong product of the base point: %s", name, err)
+		}
+
+		//Empty inputs
+		if _, err = NewBaseTable(nil, base); err == nil {
+			t.Errorf("%s: Wrong check: Empty suite", name)
+		}
+	}
+}
+
+func TestVerifyClientCommitments_Tables(t *testing.T) {
+	clients, _, context, _ := generateTestContext(baseTableMembers, 1)
+	msg, err := clients[rand.Intn(len(clients))].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+
+	//Normal execution
+	if newProofTables(msg) == nil {
+		t.Fatal("Tables not built")
+	}
+	if !verifyClientCommitments(msg, 1, nil) {
+		t.Error("Cannot verify the commitments with the tables")
+	}
+
+	//Wrong commitment
+	i := rand.Intn(len(clients))
+	msg.proof.t[3*i+2] = suite.Point().Add(msg.proof.t[3*i+2], suite.Point().Base())
+	if verifyClientCommitments(msg, 1, nil) {
+		t.Error("Wrong check: Commitment")
+	}
+}
+
+func benchmarkMulAdd(b *testing.B, multi bool) {
+	P := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	Q := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	x := suite.Scalar().Pick(random.Stream)
+	y := suite.Scalar().Pick(random.Stream)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if multi {
+			mulAdd(suite, P, x, Q, y)
+		} else {
+			suite.Point().Add(suite.Point().Mul(P, x), suite.Point().Mul(Q, y))
+		}
+	}
+}
+
+func BenchmarkMulAdd_Separate(b *testing.B) { benchmarkMulAdd(b, false) }
+func BenchmarkMulAdd_MultiMul(b *testing.B) { benchmarkMulAdd(b, true) }
+
+func benchmarkFixedBase(b *testing.B, table bool) {
+	P := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	x := suite.Scalar().Pick(random.Stream)
+	precomputed, _ := NewBaseTable(suite, P)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if table {
+			precomputed.Mul(x)
+		} else {
+			suite.Point().Mul(P, x)
+		}
+	}
+}
+
+func BenchmarkFixedBase_Mul(b *testing.B)   { benchmarkFixedBase(b, false) }
+func BenchmarkFixedBase_Table(b *testing.B) { benchmarkFixedBase(b, true) }
+
+func BenchmarkNewBaseTable(b *testing.B) {
+	P := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	for i := 0; i < b.N; i++ {
+		NewBaseTable(suite, P)
+	}
+}
+
+func benchmarkGenerateProofCommitments(b *testing.B, c int) {
+	clients, _, context, _ := generateTestContext(c, 1)
+	T0, _, s, _ := clients[0].CreateRequest(context)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clients[0].GenerateProofCommitments(context, T0, s)
+	}
+}
+
+func BenchmarkGenerateProofCommitments_16(b *testing.B)  { benchmarkGenerateProofCommitments(b, 16) }
+func BenchmarkGenerateProofCommitments_256(b *testing.B) { benchmarkGenerateProofCommitments(b, 256) }
@@ -24,11 +24,13 @@ type NetMembers struct {
 	Y []NetPoint
 }
 
-/*NetContextEd25519 provides a JSON compatible representation of the ContextEd25519 struct*/
-type NetContextEd25519 struct {
-	G NetMembers
-	R []NetPoint
-	H []NetPoint
+/*NetContext provides a JSON compatible representation of the Context struct*/
+type NetContext struct {
+	Suite string
+	Round uint64
+	G     NetMembers
+	R     []NetPoint
+	H     []NetPoint
 }
 
 /*NetServerSignature provides a JSON compatible representation of the serverSignature struct*/
@@ -46,6 +48,7 @@ type NetCommitment struct {
 /*NetChallengeCheck provides a JSON compatible representation of the ChallengeCheck struct*/
 type NetChallengeCheck struct {
 	Cs       NetScalar
+	Digest   []byte
 	Sigs     []NetServerSignature
 	Commits  []NetCommitment
 	Openings []NetScalar
@@ -53,43 +56,50 @@ type NetChallengeCheck struct {
 
 /*NetChallenge provides a JSON compatible representation of the Challenge struct*/
 type NetChallenge struct {
-	Cs   NetScalar
-	Sigs []NetServerSignature
+	Cs     NetScalar
+	Digest []byte
+	Sigs   []NetServerSignature
 }
 
 /*NetClientProof provides a JSON compatible representation of the ClientProof struct*/
 type NetClientProof struct {
-	Cs NetScalar
-	T  []NetPoint
-	C  []NetScalar
-	R  []NetScalar
+	Cs             NetScalar
+	Sigs           []NetServerSignature `json:",omitempty"`
+	T              []NetPoint
+	C              []NetScalar
+	R              []NetScalar
+	NonInteractive bool
 }
 
 /*NetClientMessage provides a JSON compatible representation of the ClientMessage struct*/
 type NetClientMessage struct {
-	Context NetContextEd25519
-	SArray  []NetPoint
-	T0      NetPoint
-	Proof   NetClientProof
+	ContextID ContextID
+	Context   NetContext
+	SArray    []NetPoint
+	T0        NetPoint
+	Proof     NetClientProof
 }
 
-/*NetServerProof provides a JSON compatible representation of the ServerProof struct*/
+/*NetServerProof provides a JSON compatible representation of the ServerProof struct
+R2 is left empty for the proof of a misbehaving client*/
 type NetServerProof struct {
 	T1 NetPoint
 	T2 NetPoint
 	T3 NetPoint
 	C  NetScalar
 	R1 NetScalar
-	R2 NetScalar
+	R2 *NetScalar `json:",omitempty"`
 }
 
-/*NetServerMessage provides a JSON compatible representation of the ServerMessage struct*/
+/*NetServerMessage provides a JSON compatible representation of the ServerMessage struct
+ContextID repeats the ID of the request so that a server can reject an unknown context before decoding the message*/
 type NetServerMessage struct {
-	Request NetClientMessage
-	Tags    []NetPoint
-	Proofs  []NetServerProof
-	Indexes []int
-	Sigs    []NetServerSignature
+	ContextID ContextID
+	Request   NetClientMessage
+	Tags      []NetPoint
+	Proofs    []NetServerProof
+	Indexes   []int
+	Sigs      []NetServerSignature
 }
 
 func NetEncodePoint(point abstract.Point) (*NetPoint, error) {
@@ -101,14 +111,9 @@ func NetEncodePoint(point abstract.Point) (*NetPoint, error) {
 	return &NetPoint{Value: value}, nil
 }
 
-func (netpoint *NetPoint) NetDecode() (abstract.Point, error) {
-	point := suite.Point().Null()
-	err := point.UnmarshalBinary(netpoint.Value)
-	if err != nil {
-		return nil, fmt.Errorf("Decode error\n%s", err)
-	}
-
-	return point, nil
+/*NetDecode decodes the point, rejecting the neutral element, the points outside of the prime-order subgroup and the non-canonical encodings*/
+func (netpoint *NetPoint) NetDecode(suite abstract.Suite) (abstract.Point, error) {
+	return decodePoint(suite, netpoint.Value)
 }
 
 func NetEncodeScalar(scalar abstract.Scalar) (*NetScalar, error) {
@@ -120,7 +125,7 @@ func NetEncodeScalar(scalar abstract.Scalar) (*NetScalar, error) {
 	return &NetScalar{Value: value}, nil
 }
 
-func (netscalar *NetScalar) NetDecode() (abstract.Scalar, error) {
+func (netscalar *NetScalar) NetDecode(suite abstract.Suite) (abstract.Scalar, error) {
 	scalar := suite.Scalar().Zero()
 	err := scalar.UnmarshalBinary(netscalar.Value)
 	if err != nil {
@@ -142,14 +147,19 @@ func NetEncodePoints(points []abstract.Point) ([]NetPoint, error) {
 	return netpoints, nil
 }
 
-func NetDecodePoints(netpoints []NetPoint) ([]abstract.Point, error) {
+/*NetDecodePoints decodes the points with NetPoint.NetDecode*/
+func NetDecodePoints(suite abstract.Suite, netpoints []NetPoint) ([]abstract.Point, error) {
+	return netDecodePoints(suite, netpoints, decodePoint)
+}
+
+/*netDecodePoints decodes the points with the given decoder*/
+func netDecodePoints(suite abstract.Suite, netpoints []NetPoint, decode func(abstract.Suite, []byte) (abstract.Point, error)) ([]abstract.Point, error) {
 	var points []abstract.Point
 	if len(netpoints) == 0 {
 		return nil, fmt.Errorf("Empty array")
 	}
 	for i, p := range netpoints {
-		temp := suite.Point().Null()
-		err := temp.UnmarshalBinary(p.Value)
+		temp, err := decode(suite, p.Value)
 		if err != nil {
 			return nil, fmt.Errorf("Decode error at index %d\n%s", i, err)
 		}
@@ -170,7 +180,7 @@ func NetEncodeScalars(scalars []abstract.Scalar) ([]NetScalar, error) {
 	return netscalars, nil
 }
 
-func NetDecodeScalars(netscalars []NetScalar) ([]abstract.Scalar, error) {
+func NetDecodeScalars(suite abstract.Suite, netscalars []NetScalar) ([]abstract.Scalar, error) {
 	var scalars []abstract.Scalar
 	if len(netscalars) == 0 {
 		return nil, fmt.Errorf("Empty array")
@@ -204,16 +214,16 @@ func (members *Members) NetEncode() (*NetMembers, error) {
 	return &netmembers, nil
 }
 
-func (netmembers *NetMembers) NetDecode() (*Members, error) {
+func (netmembers *NetMembers) NetDecode(suite abstract.Suite) (*Members, error) {
 	members := Members{}
 
-	X, err := NetDecodePoints(netmembers.X)
+	X, err := NetDecodePoints(suite, netmembers.X)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in X\n%s", err)
 	}
 	members.X = X
 
-	Y, err := NetDecodePoints(netmembers.Y)
+	Y, err := NetDecodePoints(suite, netmembers.Y)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in Y\n%s", err)
 	}
@@ -222,8 +232,8 @@ func (netmembers *NetMembers) NetDecode() (*Members, error) {
 	return &members, nil
 }
 
-func (context *ContextEd25519) NetEncode() (*NetContextEd25519, error) {
-	netcontext := NetContextEd25519{}
+func (context *Context) NetEncode() (*NetContext, error) {
+	netcontext := NetContext{Suite: context.Suite().String(), Round: context.round}
 
 	G, err := context.G.NetEncode()
 	if err != nil {
@@ -246,26 +256,33 @@ func (context *ContextEd25519) NetEncode() (*NetContextEd25519, error) {
 	return &netcontext, nil
 }
 
-func (netcontext *NetContextEd25519) NetDecode() (*ContextEd25519, error) {
-	context := ContextEd25519{}
+func (netcontext *NetContext) NetDecode() (*Context, error) {
+	suite, err := LookupSuite(netcontext.Suite)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for suite\n%s", err)
+	}
+	context := Context{suite: suite, round: netcontext.Round}
 
-	G, err := netcontext.G.NetDecode()
+	G, err := netcontext.G.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for members\n%s", err)
 	}
 	context.G = *G
 
-	R, err := NetDecodePoints(netcontext.R)
+	R, err := NetDecodePoints(suite, netcontext.R)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in R\n%s", err)
 	}
 	context.R = R
 
-	H, err := NetDecodePoints(netcontext.H)
-	if err != nil {
-		return nil, fmt.Errorf("Decode error in H\n%s", err)
+	//H may be left empty, the generators being computed from R
+	if len(netcontext.H) != 0 {
+		H, err := NetDecodePoints(suite, netcontext.H)
+		if err != nil {
+			return nil, fmt.Errorf("Decode error in H\n%s", err)
+		}
+		context.H = H
 	}
-	context.H = H
 
 	return &context, nil
 }
@@ -294,10 +311,10 @@ func (com *Commitment) NetEncode() (*NetCommitment, error) {
 	return &netcom, nil
 }
 
-func (netcom *NetCommitment) NetDecode() (*Commitment, error) {
+func (netcom *NetCommitment) NetDecode(suite abstract.Suite) (*Commitment, error) {
 	com := Commitment{sig: netcom.Sig.netDecode()}
 
-	commit, err := netcom.Commit.NetDecode()
+	commit, err := netcom.Commit.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in commit\n%s", err)
 	}
@@ -307,7 +324,7 @@ func (netcom *NetCommitment) NetDecode() (*Commitment, error) {
 }
 
 func (chall *ChallengeCheck) NetEncode() (*NetChallengeCheck, error) {
-	netchall := NetChallengeCheck{}
+	netchall := NetChallengeCheck{Digest: append([]byte(nil), chall.digest[:]...)}
 
 	for _, sig := range chall.sigs {
 		netchall.Sigs = append(netchall.Sigs, sig.netEncode())
@@ -336,7 +353,7 @@ func (chall *ChallengeCheck) NetEncode() (*NetChallengeCheck, error) {
 	return &netchall, nil
 }
 
-func (netchall *NetChallengeCheck) NetDecode() (*ChallengeCheck, error) {
+func (netchall *NetChallengeCheck) NetDecode(suite abstract.Suite) (*ChallengeCheck, error) {
 	chall := ChallengeCheck{}
 
 	for _, sig := range netchall.Sigs {
@@ -344,20 +361,24 @@ func (netchall *NetChallengeCheck) NetDecode() (*ChallengeCheck, error) {
 	}
 
 	for i, com := range netchall.Commits {
-		temp, err := com.NetDecode()
+		temp, err := com.NetDecode(suite)
 		if err != nil {
 			return nil, fmt.Errorf("Decode error for commit %d\n%s", i, err)
 		}
 		chall.commits = append(chall.commits, *temp)
 	}
 
-	cs, err := netchall.Cs.NetDecode()
+	cs, err := netchall.Cs.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for cs\n%s", err)
 	}
 	chall.cs = cs
 
-	openings, err := NetDecodeScalars(netchall.Openings)
+	if chall.digest, err = decodeProofCommitsDigest(netchall.Digest); err != nil {
+		return nil, fmt.Errorf("Decode error for digest\n%s", err)
+	}
+
+	openings, err := NetDecodeScalars(suite, netchall.Openings)
 	if err != nil {
 		return nil, fmt.Errorf("Encode error in openings\n%s", err)
 	}
@@ -367,7 +388,7 @@ func (netchall *NetChallengeCheck) NetDecode() (*ChallengeCheck, error) {
 }
 
 func (chall *Challenge) NetEncode() (*NetChallenge, error) {
-	netchall := NetChallenge{}
+	netchall := NetChallenge{Digest: append([]byte(nil), chall.digest[:]...)}
 	for _, sig := range chall.sigs {
 		netchall.Sigs = append(netchall.Sigs, sig.netEncode())
 	}
@@ -381,29 +402,37 @@ func (chall *Challenge) NetEncode() (*NetChallenge, error) {
 	return &netchall, nil
 }
 
-func (netchall *NetChallenge) NetDecode() (*Challenge, error) {
+func (netchall *NetChallenge) NetDecode(suite abstract.Suite) (*Challenge, error) {
 	chall := Challenge{}
 	for _, sig := range netchall.Sigs {
 		chall.sigs = append(chall.sigs, sig.netDecode())
 	}
 
-	cs, err := netchall.Cs.NetDecode()
+	cs, err := netchall.Cs.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for cs\n%s", err)
 	}
 	chall.cs = cs
 
+	if chall.digest, err = decodeProofCommitsDigest(netchall.Digest); err != nil {
+		return nil, fmt.Errorf("Decode error for digest\n%s", err)
+	}
+
 	return &chall, nil
 }
 
 func (proof *ClientProof) NetEncode() (*NetClientProof, error) {
-	netproof := NetClientProof{}
+	netproof := NetClientProof{NonInteractive: proof.nonInteractive}
 	cs, err := NetEncodeScalar(proof.cs)
 	if err != nil {
 		return nil, fmt.Errorf("Encode error for cs\n%s", err)
 	}
 	netproof.Cs = *cs
 
+	for _, sig := range proof.sigs {
+		netproof.Sigs = append(netproof.Sigs, sig.netEncode())
+	}
+
 	T, err := NetEncodePoints(proof.t)
 	if err != nil {
 		return nil, fmt.Errorf("Encode error for t\n%s", err)
@@ -425,27 +454,31 @@ func (proof *ClientProof) NetEncode() (*NetClientProof, error) {
 	return &netproof, nil
 }
 
-func (netproof *NetClientProof) NetDecode() (*ClientProof, error) {
-	proof := ClientProof{}
-	cs, err := netproof.Cs.NetDecode()
+func (netproof *NetClientProof) NetDecode(suite abstract.Suite) (*ClientProof, error) {
+	proof := ClientProof{nonInteractive: netproof.NonInteractive}
+	cs, err := netproof.Cs.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for cs\n%s", err)
 	}
 	proof.cs = cs
 
-	t, err := NetDecodePoints(netproof.T)
+	for _, sig := range netproof.Sigs {
+		proof.sigs = append(proof.sigs, sig.netDecode())
+	}
+
+	t, err := NetDecodePoints(suite, netproof.T)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for t\n%s", err)
 	}
 	proof.t = t
 
-	c, err := NetDecodeScalars(netproof.C)
+	c, err := NetDecodeScalars(suite, netproof.C)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for c\n%s", err)
 	}
 	proof.c = c
 
-	r, err := NetDecodeScalars(netproof.R)
+	r, err := NetDecodeScalars(suite, netproof.R)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for r\n%s", err)
 	}
@@ -455,13 +488,21 @@ func (netproof *NetClientProof) NetDecode() (*ClientProof, error) {
 }
 
 func (msg *ClientMessage) NetEncode() (*NetClientMessage, error) {
-	netmsg := NetClientMessage{}
-
 	context, err := msg.context.NetEncode()
 	if err != nil {
 		return nil, fmt.Errorf("Encode error for context\n%s", err)
 	}
+	netmsg, err := msg.netEncodeRequest()
+	if err != nil {
+		return nil, err
+	}
 	netmsg.Context = *context
+	return netmsg, nil
+}
+
+//netEncodeRequest encodes everything but the context of the message
+func (msg *ClientMessage) netEncodeRequest() (*NetClientMessage, error) {
+	netmsg := NetClientMessage{ContextID: msg.contextID}
 
 	s, err := NetEncodePoints(msg.sArray)
 	if err != nil {
@@ -485,27 +526,31 @@ func (msg *ClientMessage) NetEncode() (*NetClientMessage, error) {
 }
 
 func (netmsg *NetClientMessage) NetDecode() (*ClientMessage, error) {
-	msg := ClientMessage{}
-
 	context, err := netmsg.Context.NetDecode()
 	if err != nil {
 		return nil, fmt.Errorf("Decode error for context\n%s", err)
 	}
-	msg.context = *context
+	return netmsg.netDecodeRequest(context)
+}
+
+//netDecodeRequest decodes the message in the given context, ignoring the encoded one
+func (netmsg *NetClientMessage) netDecodeRequest(context *Context) (*ClientMessage, error) {
+	msg := ClientMessage{context: *context, contextID: netmsg.ContextID}
+	suite := context.Suite()
 
-	s, err := NetDecodePoints(netmsg.SArray)
+	s, err := NetDecodePoints(suite, netmsg.SArray)
 	if err != nil {
 		return nil, fmt.Errorf("Decode errof for sArray\n%s", err)
 	}
 	msg.sArray = s
 
-	t0, err := netmsg.T0.NetDecode()
+	t0, err := netmsg.T0.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in t0\n%s", err)
 	}
 	msg.t0 = t0
 
-	proof, err := netmsg.Proof.NetDecode()
+	proof, err := netmsg.Proof.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in proof\n%s", err)
 	}
@@ -546,64 +591,73 @@ func (proof *serverProof) NetEncode() (*NetServerProof, error) {
 	}
 	netproof.R1 = *r1
 
-	r2, err := NetEncodeScalar(proof.r2)
-	if err != nil {
-		return nil, fmt.Errorf("Encode error for r2\n%s", err)
+	if proof.r2 != nil {
+		if netproof.R2, err = NetEncodeScalar(proof.r2); err != nil {
+			return nil, fmt.Errorf("Encode error for r2\n%s", err)
+		}
 	}
-	netproof.R2 = *r2
 
 	return &netproof, nil
 }
 
-func (netproof *NetServerProof) NetDecode() (*serverProof, error) {
+func (netproof *NetServerProof) NetDecode(suite abstract.Suite) (*serverProof, error) {
 	proof := serverProof{}
-	t1, err := netproof.T1.NetDecode()
+	//t1 follows the previous tag, it is the neutral element once a client was found misbehaving
+	t1, err := decodeTag(suite, netproof.T1.Value)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in t1\n%s", err)
 	}
 	proof.t1 = t1
 
-	t2, err := netproof.T2.NetDecode()
+	t2, err := netproof.T2.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in t2\n%s", err)
 	}
 	proof.t2 = t2
 
-	t3, err := netproof.T3.NetDecode()
+	t3, err := netproof.T3.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in t3\n%s", err)
 	}
 	proof.t3 = t3
 
-	c, err := netproof.C.NetDecode()
+	c, err := netproof.C.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in c\n%s", err)
 	}
 	proof.c = c
 
-	r1, err := netproof.R1.NetDecode()
+	r1, err := netproof.R1.NetDecode(suite)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in r1\n%s", err)
 	}
 	proof.r1 = r1
 
-	r2, err := netproof.R2.NetDecode()
-	if err != nil {
-		return nil, fmt.Errorf("Decode error in r2\n%s", err)
+	if netproof.R2 != nil {
+		if proof.r2, err = netproof.R2.NetDecode(suite); err != nil {
+			return nil, fmt.Errorf("Decode error in r2\n%s", err)
+		}
 	}
-	proof.r2 = r2
 
 	return &proof, nil
 }
 
 func (msg *ServerMessage) NetEncode() (*NetServerMessage, error) {
-	netmsg := NetServerMessage{Indexes: msg.indexes}
-
 	request, err := msg.request.NetEncode()
 	if err != nil {
 		return nil, fmt.Errorf("Encode error in request\n%s", err)
 	}
+	netmsg, err := msg.netEncodeProofs()
+	if err != nil {
+		return nil, err
+	}
 	netmsg.Request = *request
+	return netmsg, nil
+}
+
+//netEncodeProofs encodes everything but the request of the message
+func (msg *ServerMessage) netEncodeProofs() (*NetServerMessage, error) {
+	netmsg := NetServerMessage{ContextID: msg.request.contextID, Indexes: msg.indexes}
 
 	tags, err := NetEncodePoints(msg.tags)
 	if err != nil {
@@ -628,22 +682,29 @@ func (msg *ServerMessage) NetEncode() (*NetServerMessage, error) {
 }
 
 func (netmsg *NetServerMessage) NetDecode() (*ServerMessage, error) {
-	msg := ServerMessage{indexes: netmsg.Indexes}
-
 	request, err := netmsg.Request.NetDecode()
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in request\n%s", err)
 	}
-	msg.request = *request
+	return netmsg.netDecodeProofs(request)
+}
+
+//netDecodeProofs decodes the message around an already decoded request, ignoring the encoded one
+func (netmsg *NetServerMessage) netDecodeProofs(request *ClientMessage) (*ServerMessage, error) {
+	if request.contextID != netmsg.ContextID {
+		return nil, fmt.Errorf("Mismatching context IDs: %s and %s", netmsg.ContextID, request.contextID)
+	}
+	msg := ServerMessage{request: *request, indexes: netmsg.Indexes}
+	suite := request.context.Suite()
 
-	tags, err := NetDecodePoints(netmsg.Tags)
+	tags, err := netDecodePoints(suite, netmsg.Tags, decodeTag)
 	if err != nil {
 		return nil, fmt.Errorf("Decode error in tags\n%s", err)
 	}
 	msg.tags = tags
 
 	for i, p := range netmsg.Proofs {
-		temp, err := p.NetDecode()
+		temp, err := p.NetDecode(suite)
 		if err != nil {
 			return nil, fmt.Errorf("Decode error in proof at index %d\n%s", i, err)
 		}
@@ -1 +1,54 @@
 package daga
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+func TestNetContext(t *testing.T) {
+	p256, _ := LookupSuite("P256")
+	for _, s := range []abstract.Suite{suite, p256} {
+		_, _, context, _ := generateTestContextSuite(s, rand.Intn(10)+1, rand.Intn(10)+1)
+		context.round = uint64(rand.Intn(10))
+
+		//Normal execution
+		netcontext, err := context.NetEncode()
+		if err != nil || netcontext.Suite != s.String() {
+			t.Errorf("Cannot encode a context in suite %s", s)
+		}
+		data, _ := json.Marshal(netcontext)
+		var received NetContext
+		json.Unmarshal(data, &received)
+		decoded, err := received.NetDecode()
+		if err != nil || decoded.Suite() != s || decoded.Round() != context.Round() {
+			t.Errorf("Cannot decode a context in suite %s", s)
+		}
+		for i := range context.H {
+			if !decoded.H[i].Equal(context.H[i]) {
+				t.Errorf("Wrong H at index %d", i)
+			}
+		}
+
+		//Context without the client generators
+		lazy := *context
+		lazy.H = nil
+		netcontext, _ = lazy.NetEncode()
+		data, _ = json.Marshal(netcontext)
+		var withoutH NetContext
+		json.Unmarshal(data, &withoutH)
+		decoded, err = withoutH.NetDecode()
+		if err != nil || len(decoded.H) != 0 {
+			t.Errorf("Cannot decode a context without generators in suite %s: %v", s, err)
+		}
+
+		//Unknown suite
+		received.Suite = "Unknown"
+		decoded, err = received.NetDecode()
+		if err == nil || decoded != nil {
+			t.Error("Wrong check: Unknown suite")
+		}
+	}
+}
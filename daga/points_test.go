@@ -0,0 +1,199 @@
+package daga
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+type maliciousPoint struct {
+	name string
+	data []byte
+	//tag tells whether the encoding is a valid linkage tag, i.e. the canonical neutral element
+	tag bool
+}
+
+func mustHex(s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+/*maliciousPoints returns encodings that every decoder must reject
+The Ed25519 ones are the points of small order (the neutral element included), non-canonical encodings and a point of mixed order*/
+func maliciousPoints(suite abstract.Suite) []maliciousPoint {
+	null, _ := suite.Point().Null().MarshalBinary()
+	base, _ := suite.Point().Base().MarshalBinary()
+	vectors := []maliciousPoint{
+		{"neutral element", null, true},
+		{"truncated", base[:len(base)-1], false},
+		{"extended", append(append([]byte{}, base...), 0), false},
+	}
+	if suite.String() != "Ed25519" {
+		return vectors
+	}
+
+	vectors = append(vectors,
+		//Small order points
+		maliciousPoint{"order 2", mustHex("ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), false},
+		maliciousPoint{"order 4", mustHex("0000000000000000000000000000000000000000000000000000000000000000"), false},
+		maliciousPoint{"order 4, negated", mustHex("0000000000000000000000000000000000000000000000000000000000000080"), false},
+		maliciousPoint{"order 8", mustHex("26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05"), false},
+		maliciousPoint{"order 8, negated", mustHex("c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a"), false},
+		//Non-canonical encodings of the neutral element: y = p+1, and y = 1 with the sign of x set
+		maliciousPoint{"y = p+1", mustHex("eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), false},
+		maliciousPoint{"negative zero x", mustHex("0100000000000000000000000000000000000000000000000000000000000080"), false},
+	)
+
+	//The base point plus a point of order 2 is on the curve but outside of the prime-order subgroup
+	torsion := suite.Point()
+	if err := torsion.UnmarshalBinary(vectors[3].data); err == nil {
+		mixed, _ := suite.Point().Add(suite.Point().Base(), torsion).MarshalBinary()
+		vectors = append(vectors, maliciousPoint{"mixed order", mixed, false})
+	}
+	return vectors
+}
+
+func TestDecodePoint_Malicious(t *testing.T) {
+	for _, name := range []string{"Ed25519", "P256"} {
+		suite, _ := LookupSuite(name)
+
+		//Normal execution
+		valid, _ := suite.Point().Mul(nil, suite.Scalar().Pick(suite.Cipher([]byte("point")))).MarshalBinary()
+		if _, err := decodePoint(suite, valid); err != nil {
+			t.Errorf("%s: Valid point rejected: %s", name, err)
+		}
+		netpoint := NetPoint{Value: valid}
+		if _, err := netpoint.NetDecode(suite); err != nil {
+			t.Errorf("%s: Valid point rejected by NetDecode: %s", name, err)
+		}
+
+		//Malicious encodings
+		for _, v := range maliciousPoints(suite) {
+			if _, err := decodePoint(suite, v.data); err == nil {
+				t.Errorf("%s: Wrong check: %s", name, v.name)
+			}
+			netpoint := NetPoint{Value: v.data}
+			if _, err := netpoint.NetDecode(suite); err == nil {
+				t.Errorf("%s: Wrong check in NetDecode: %s", name, v.name)
+			}
+			if _, err := decodeTag(suite, v.data); (err == nil) != v.tag {
+				t.Errorf("%s: Wrong check of tag: %s: %v", name, v.name, err)
+			}
+		}
+	}
+}
+
+func TestDecodeMessages_Malicious(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(5)+1, rand.Intn(5)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	request, err := clients[0].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	msg, err := endpoint.SubmitMessage(request)
+	if err != nil {
+		t.Fatalf("Cannot process the request: %s", err)
+	}
+
+	for _, v := range maliciousPoints(suite) {
+		//T0 of the request in every encoding
+		netrequest, _ := request.NetEncode()
+		netrequest.T0 = NetPoint{Value: v.data}
+		if _, err = netrequest.NetDecode(); err == nil {
+			t.Errorf("Wrong check in JSON encoding: %s", v.name)
+		}
+		pbrequest, _ := request.ProtoEncode()
+		pbrequest.T0 = v.data
+		if _, err = ProtoDecodeClientMessage(pbrequest); err == nil {
+			t.Errorf("Wrong check in Protocol Buffers encoding: %s", v.name)
+		}
+
+		//Tags, which may be the neutral element
+		netmsg, _ := msg.NetEncode()
+		netmsg.Tags[0] = NetPoint{Value: v.data}
+		if _, err = netmsg.NetDecode(); (err == nil) != v.tag {
+			t.Errorf("Wrong check of tag in JSON encoding: %s: %v", v.name, err)
+		}
+	}
+
+	//Binary encoding, the point being replaced in place
+	data, _ := request.MarshalBinary()
+	T0, _ := request.t0.MarshalBinary()
+	for _, v := range maliciousPoints(suite) {
+		if len(v.data) != len(T0) {
+			continue
+		}
+		forged := replaceBytes(data, T0, v.data)
+		if _, err = UnmarshalClientMessage(forged); err == nil {
+			t.Errorf("Wrong check in binary encoding: %s", v.name)
+		}
+	}
+}
+
+func replaceBytes(data, old, new []byte) []byte {
+	out := append([]byte{}, data...)
+	for i := 0; i+len(old) <= len(out); i++ {
+		if string(out[i:i+len(old)]) == string(old) {
+			copy(out[i:], new)
+			return out
+		}
+	}
+	return out
+}
+
+func TestProtocol_MaliciousPoints(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(5)+1, rand.Intn(5)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	request, err := clients[0].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	for _, v := range maliciousPoints(suite) {
+		point := suite.Point()
+		if v.tag || point.UnmarshalBinary(v.data) != nil {
+			continue
+		}
+
+		//ServerProtocol rejects the request
+		forged := *request
+		forged.t0 = point
+		if err = servers[0].ServerProtocol(context, servers[0].InitializeServerMessage(&forged)); err == nil {
+			t.Errorf("Wrong check in ServerProtocol: %s", v.name)
+		}
+
+		//GetFinalLinkageTag rejects the tags
+		msg, err := endpoint.SubmitMessage(request)
+		if err != nil {
+			t.Fatalf("Cannot process the request: %s", err)
+		}
+		msg.tags[0] = point
+		if _, err = clients[0].GetFinalLinkageTag(context, msg); err == nil {
+			t.Errorf("Wrong check in GetFinalLinkageTag: %s", v.name)
+		}
+	}
+
+	//Commitments to the round secrets
+	for _, v := range maliciousPoints(suite) {
+		point := suite.Point()
+		if point.UnmarshalBinary(v.data) != nil {
+			continue
+		}
+		commits := make([]Commitment, len(servers))
+		for i := range servers {
+			com, _, err := servers[i].GenerateCommitment(context)
+			if err != nil {
+				t.Fatalf("Cannot generate the commitment: %s", err)
+			}
+			commits[i] = *com
+		}
+		commits[0].commit = point
+		if err = VerifyCommitmentSignature(context, commits); err == nil {
+			t.Errorf("Wrong check of commitment: %s", v.name)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package daga
+
+//go:generate protoc --proto_path=pb --go_out=pb pb/daga.proto
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dedis/student_17_pop_fs/daga/pb"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//Provides conversions between the daga types and the Protocol Buffers messages of the pb package
+//The messages can then be marshaled with github.com/golang/protobuf/proto to be read by non-Go peers
+
+func protoEncodePoints(points []abstract.Point) ([][]byte, error) {
+	var data [][]byte
+	for i, p := range points {
+		temp, err := p.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("Encode error at index %d\n%s", i, err)
+		}
+		data = append(data, temp)
+	}
+	return data, nil
+}
+
+func protoDecodePoints(suite abstract.Suite, data [][]byte) ([]abstract.Point, error) {
+	return protoDecodePointsWith(suite, data, decodePoint)
+}
+
+func protoDecodePointsWith(suite abstract.Suite, data [][]byte, decode func(abstract.Suite, []byte) (abstract.Point, error)) ([]abstract.Point, error) {
+	var points []abstract.Point
+	for i, d := range data {
+		temp, err := decode(suite, d)
+		if err != nil {
+			return nil, fmt.Errorf("Decode error at index %d\n%s", i, err)
+		}
+		points = append(points, temp)
+	}
+	return points, nil
+}
+
+func protoEncodeScalars(scalars []abstract.Scalar) ([][]byte, error) {
+	var data [][]byte
+	for i, s := range scalars {
+		temp, err := s.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("Encode error at index %d\n%s", i, err)
+		}
+		data = append(data, temp)
+	}
+	return data, nil
+}
+
+func protoDecodeScalars(suite abstract.Suite, data [][]byte) ([]abstract.Scalar, error) {
+	var scalars []abstract.Scalar
+	for i, d := range data {
+		temp, err := decodeScalar(suite, d)
+		if err != nil {
+			return nil, fmt.Errorf("Decode error at index %d\n%s", i, err)
+		}
+		scalars = append(scalars, temp)
+	}
+	return scalars, nil
+}
+
+func protoEncodeSigs(sigs []serverSignature) []*pb.ServerSignature {
+	var pbsigs []*pb.ServerSignature
+	for _, sig := range sigs {
+		pbsigs = append(pbsigs, &pb.ServerSignature{Index: int32(sig.index), Sig: sig.sig})
+	}
+	return pbsigs
+}
+
+func protoDecodeSigs(pbsigs []*pb.ServerSignature) ([]serverSignature, error) {
+	var sigs []serverSignature
+	for i, sig := range pbsigs {
+		if sig == nil {
+			return nil, fmt.Errorf("Empty signature at index %d", i)
+		}
+		sigs = append(sigs, serverSignature{index: int(sig.Index), sig: sig.Sig})
+	}
+	return sigs, nil
+}
+
+/*ProtoEncode converts the context into its Protocol Buffers message*/
+func (context *Context) ProtoEncode() (*pb.Context, error) {
+	pbcontext := pb.Context{Suite: context.Suite().String(), Round: context.round, G: &pb.Members{}}
+	var err error
+	if pbcontext.G.X, err = protoEncodePoints(context.G.X); err != nil {
+		return nil, fmt.Errorf("Encode error in X\n%s", err)
+	}
+	if pbcontext.G.Y, err = protoEncodePoints(context.G.Y); err != nil {
+		return nil, fmt.Errorf("Encode error in Y\n%s", err)
+	}
+	if pbcontext.R, err = protoEncodePoints(context.R); err != nil {
+		return nil, fmt.Errorf("Encode error in R\n%s", err)
+	}
+	if pbcontext.H, err = protoEncodePoints(context.H); err != nil {
+		return nil, fmt.Errorf("Encode error in H\n%s", err)
+	}
+	return &pbcontext, nil
+}
+
+/*ProtoDecodeContext converts a Protocol Buffers message into a context, in the suite it names*/
+func ProtoDecodeContext(pbcontext *pb.Context) (*Context, error) {
+	if pbcontext == nil || pbcontext.G == nil {
+		return nil, fmt.Errorf("Empty context")
+	}
+	suite, err := LookupSuite(pbcontext.Suite)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for suite\n%s", err)
+	}
+	context := Context{suite: suite, round: pbcontext.Round}
+	if context.G.X, err = protoDecodePoints(suite, pbcontext.G.X); err != nil {
+		return nil, fmt.Errorf("Decode error in X\n%s", err)
+	}
+	if context.G.Y, err = protoDecodePoints(suite, pbcontext.G.Y); err != nil {
+		return nil, fmt.Errorf("Decode error in Y\n%s", err)
+	}
+	if context.R, err = protoDecodePoints(suite, pbcontext.R); err != nil {
+		return nil, fmt.Errorf("Decode error in R\n%s", err)
+	}
+	if context.H, err = protoDecodePoints(suite, pbcontext.H); err != nil {
+		return nil, fmt.Errorf("Decode error in H\n%s", err)
+	}
+	return &context, nil
+}
+
+/*ProtoEncode converts the commitment into its Protocol Buffers message*/
+func (com *Commitment) ProtoEncode() (*pb.Commitment, error) {
+	commit, err := com.commit.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("Encode error in commit\n%s", err)
+	}
+	return &pb.Commitment{Commit: commit, Sig: &pb.ServerSignature{Index: int32(com.sig.index), Sig: com.sig.sig}}, nil
+}
+
+/*ProtoDecodeCommitment converts a Protocol Buffers message into a commitment*/
+func ProtoDecodeCommitment(suite abstract.Suite, pbcom *pb.Commitment) (*Commitment, error) {
+	if pbcom == nil || pbcom.Sig == nil {
+		return nil, fmt.Errorf("Empty commitment")
+	}
+	commit, err := decodePoint(suite, pbcom.Commit)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error in commit\n%s", err)
+	}
+	return &Commitment{commit: commit, sig: serverSignature{index: int(pbcom.Sig.Index), sig: pbcom.Sig.Sig}}, nil
+}
+
+/*ProtoEncode converts the challenge check into its Protocol Buffers message*/
+func (chall *ChallengeCheck) ProtoEncode() (*pb.ChallengeCheck, error) {
+	cs, err := chall.cs.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("Encode error for cs\n%s", err)
+	}
+	pbchall := pb.ChallengeCheck{Cs: cs, Digest: append([]byte(nil), chall.digest[:]...), Sigs: protoEncodeSigs(chall.sigs)}
+	for i, com := range chall.commits {
+		temp, err := com.ProtoEncode()
+		if err != nil {
+			return nil, fmt.Errorf("Encode error for commit %d\n%s", i, err)
+		}
+		pbchall.Commits = append(pbchall.Commits, temp)
+	}
+	if pbchall.Openings, err = protoEncodeScalars(chall.openings); err != nil {
+		return nil, fmt.Errorf("Encode error in openings\n%s", err)
+	}
+	return &pbchall, nil
+}
+
+/*ProtoDecodeChallengeCheck converts a Protocol Buffers message into a challenge check*/
+func ProtoDecodeChallengeCheck(suite abstract.Suite, pbchall *pb.ChallengeCheck) (*ChallengeCheck, error) {
+	if pbchall == nil {
+		return nil, fmt.Errorf("Empty challenge check")
+	}
+	chall := ChallengeCheck{}
+	var err error
+	if chall.cs, err = decodeScalar(suite, pbchall.Cs); err != nil {
+		return nil, fmt.Errorf("Decode error for cs\n%s", err)
+	}
+	if chall.digest, err = decodeProofCommitsDigest(pbchall.Digest); err != nil {
+		return nil, fmt.Errorf("Decode error for digest\n%s", err)
+	}
+	if chall.sigs, err = protoDecodeSigs(pbchall.Sigs); err != nil {
+		return nil, err
+	}
+	for i, pbcom := range pbchall.Commits {
+		temp, err := ProtoDecodeCommitment(suite, pbcom)
+		if err != nil {
+			return nil, fmt.Errorf("Decode error for commit %d\n%s", i, err)
+		}
+		chall.commits = append(chall.commits, *temp)
+	}
+	if chall.openings, err = protoDecodeScalars(suite, pbchall.Openings); err != nil {
+		return nil, fmt.Errorf("Decode error in openings\n%s", err)
+	}
+	return &chall, nil
+}
+
+/*ProtoEncode converts the challenge into its Protocol Buffers message*/
+func (chall *Challenge) ProtoEncode() (*pb.Challenge, error) {
+	cs, err := chall.cs.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("Encode error for cs\n%s", err)
+	}
+	return &pb.Challenge{Cs: cs, Digest: append([]byte(nil), chall.digest[:]...), Sigs: protoEncodeSigs(chall.sigs)}, nil
+}
+
+/*ProtoDecodeChallenge converts a Protocol Buffers message into a challenge*/
+func ProtoDecodeChallenge(suite abstract.Suite, pbchall *pb.Challenge) (*Challenge, error) {
+	if pbchall == nil {
+		return nil, fmt.Errorf("Empty challenge")
+	}
+	cs, err := decodeScalar(suite, pbchall.Cs)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for cs\n%s", err)
+	}
+	digest, err := decodeProofCommitsDigest(pbchall.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for digest\n%s", err)
+	}
+	sigs, err := protoDecodeSigs(pbchall.Sigs)
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{cs: cs, digest: digest, sigs: sigs}, nil
+}
+
+/*ProtoEncode converts the client message, including its context, into its Protocol Buffers message*/
+func (msg *ClientMessage) ProtoEncode() (*pb.ClientMessage, error) {
+	context, err := msg.context.ProtoEncode()
+	if err != nil {
+		return nil, fmt.Errorf("Encode error for context\n%s", err)
+	}
+	pbmsg := pb.ClientMessage{ContextId: msg.contextID[:], Context: context, Proof: &pb.ClientProof{Sigs: protoEncodeSigs(msg.proof.sigs), NonInteractive: msg.proof.nonInteractive}}
+	if pbmsg.SArray, err = protoEncodePoints(msg.sArray); err != nil {
+		return nil, fmt.Errorf("Encode error for sArray\n%s", err)
+	}
+	if pbmsg.T0, err = msg.t0.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("Encode error in t0\n%s", err)
+	}
+	if pbmsg.Proof.Cs, err = msg.proof.cs.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("Encode error for cs\n%s", err)
+	}
+	if pbmsg.Proof.T, err = protoEncodePoints(msg.proof.t); err != nil {
+		return nil, fmt.Errorf("Encode error for t\n%s", err)
+	}
+	if pbmsg.Proof.C, err = protoEncodeScalars(msg.proof.c); err != nil {
+		return nil, fmt.Errorf("Encode error for c\n%s", err)
+	}
+	if pbmsg.Proof.R, err = protoEncodeScalars(msg.proof.r); err != nil {
+		return nil, fmt.Errorf("Encode error for r\n%s", err)
+	}
+	return &pbmsg, nil
+}
+
+/*ProtoDecodeClientMessage converts a Protocol Buffers message into a client message, in the suite named by its context*/
+func ProtoDecodeClientMessage(pbmsg *pb.ClientMessage) (*ClientMessage, error) {
+	if pbmsg == nil || pbmsg.Proof == nil {
+		return nil, fmt.Errorf("Empty client message")
+	}
+	context, err := ProtoDecodeContext(pbmsg.Context)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error for context\n%s", err)
+	}
+	suite := context.Suite()
+	msg := ClientMessage{context: *context, proof: ClientProof{nonInteractive: pbmsg.Proof.NonInteractive}}
+	if len(pbmsg.ContextId) != len(msg.contextID) {
+		return nil, fmt.Errorf("Wrong context ID length: %d", len(pbmsg.ContextId))
+	}
+	copy(msg.contextID[:], pbmsg.ContextId)
+	if msg.sArray, err = protoDecodePoints(suite, pbmsg.SArray); err != nil {
+		return nil, fmt.Errorf("Decode error for sArray\n%s", err)
+	}
+	if msg.t0, err = decodePoint(suite, pbmsg.T0); err != nil {
+		return nil, fmt.Errorf("Decode error in t0\n%s", err)
+	}
+	if msg.proof.cs, err = decodeScalar(suite, pbmsg.Proof.Cs); err != nil {
+		return nil, fmt.Errorf("Decode error for cs\n%s", err)
+	}
+	if msg.proof.sigs, err = protoDecodeSigs(pbmsg.Proof.Sigs); err != nil {
+		return nil, err
+	}
+	if msg.proof.t, err = protoDecodePoints(suite, pbmsg.Proof.T); err != nil {
+		return nil, fmt.Errorf("Decode error for t\n%s", err)
+	}
+	if msg.proof.c, err = protoDecodeScalars(suite, pbmsg.Proof.C); err != nil {
+		return nil, fmt.Errorf("Decode error for c\n%s", err)
+	}
+	if msg.proof.r, err = protoDecodeScalars(suite, pbmsg.Proof.R); err != nil {
+		return nil, fmt.Errorf("Decode error for r\n%s", err)
+	}
+	return &msg, nil
+}
+
+/*ProtoEncode converts the proof into its Protocol Buffers message
+r2 is left empty for the proof of a misbehaving client*/
+func (proof *serverProof) ProtoEncode() (*pb.ServerProof, error) {
+	pbproof := pb.ServerProof{}
+	var err error
+	if pbproof.T1, err = proof.t1.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("Encode error in t1\n%s", err)
+	}
+	if pbproof.T2, err = proof.t2.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("Encode error in t2\n%s", err)
+	}
+	if pbproof.T3, err = proof.t3.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("Encode error in t3\n%s", err)
+	}
+	if pbproof.C, err = proof.c.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("Encode error for c\n%s", err)
+	}
+	if pbproof.R1, err = proof.r1.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("Encode error for r1\n%s", err)
+	}
+	if proof.r2 != nil {
+		if pbproof.R2, err = proof.r2.MarshalBinary(); err != nil {
+			return nil, fmt.Errorf("Encode error for r2\n%s", err)
+		}
+	}
+	return &pbproof, nil
+}
+
+func protoDecodeServerProof(suite abstract.Suite, pbproof *pb.ServerProof) (*serverProof, error) {
+	if pbproof == nil {
+		return nil, fmt.Errorf("Empty proof")
+	}
+	proof := serverProof{}
+	var err error
+	//t1 follows the previous tag, it is the neutral element once a client was found misbehaving
+	if proof.t1, err = decodeTag(suite, pbproof.T1); err != nil {
+		return nil, fmt.Errorf("Decode error in t1\n%s", err)
+	}
+	if proof.t2, err = decodePoint(suite, pbproof.T2); err != nil {
+		return nil, fmt.Errorf("Decode error in t2\n%s", err)
+	}
+	if proof.t3, err = decodePoint(suite, pbproof.T3); err != nil {
+		return nil, fmt.Errorf("Decode error in t3\n%s", err)
+	}
+	if proof.c, err = decodeScalar(suite, pbproof.C); err != nil {
+		return nil, fmt.Errorf("Decode error in c\n%s", err)
+	}
+	if proof.r1, err = decodeScalar(suite, pbproof.R1); err != nil {
+		return nil, fmt.Errorf("Decode error in r1\n%s", err)
+	}
+	if len(pbproof.R2) != 0 {
+		if proof.r2, err = decodeScalar(suite, pbproof.R2); err != nil {
+			return nil, fmt.Errorf("Decode error in r2\n%s", err)
+		}
+	}
+	return &proof, nil
+}
+
+/*ProtoEncode converts the server message into its Protocol Buffers message*/
+func (msg *ServerMessage) ProtoEncode() (*pb.ServerMessage, error) {
+	request, err := msg.request.ProtoEncode()
+	if err != nil {
+		return nil, fmt.Errorf("Encode error in request\n%s", err)
+	}
+	pbmsg := pb.ServerMessage{ContextId: msg.request.contextID[:], Request: request, Sigs: protoEncodeSigs(msg.sigs)}
+	if pbmsg.Tags, err = protoEncodePoints(msg.tags); err != nil {
+		return nil, fmt.Errorf("Encode error in tags\n%s", err)
+	}
+	for i, p := range msg.proofs {
+		temp, err := p.ProtoEncode()
+		if err != nil {
+			return nil, fmt.Errorf("Encode error in proof at index %d\n%s", i, err)
+		}
+		pbmsg.Proofs = append(pbmsg.Proofs, temp)
+	}
+	for _, index := range msg.indexes {
+		pbmsg.Indexes = append(pbmsg.Indexes, int32(index))
+	}
+	return &pbmsg, nil
+}
+
+/*ProtoDecodeServerMessage converts a Protocol Buffers message into a server message, in the suite named by the context of the request*/
+func ProtoDecodeServerMessage(pbmsg *pb.ServerMessage) (*ServerMessage, error) {
+	if pbmsg == nil {
+		return nil, fmt.Errorf("Empty server message")
+	}
+	request, err := ProtoDecodeClientMessage(pbmsg.Request)
+	if err != nil {
+		return nil, fmt.Errorf("Decode error in request\n%s", err)
+	}
+	if !bytes.Equal(pbmsg.ContextId, request.contextID[:]) {
+		return nil, fmt.Errorf("Mismatching context IDs")
+	}
+	suite := request.context.Suite()
+	msg := ServerMessage{request: *request}
+	if msg.tags, err = protoDecodePointsWith(suite, pbmsg.Tags, decodeTag); err != nil {
+		return nil, fmt.Errorf("Decode error in tags\n%s", err)
+	}
+	for i, p := range pbmsg.Proofs {
+		temp, err := protoDecodeServerProof(suite, p)
+		if err != nil {
+			return nil, fmt.Errorf("Decode error in proof at index %d\n%s", i, err)
+		}
+		msg.proofs = append(msg.proofs, *temp)
+	}
+	for _, index := range pbmsg.Indexes {
+		msg.indexes = append(msg.indexes, int(index))
+	}
+	if msg.sigs, err = protoDecodeSigs(pbmsg.Sigs); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
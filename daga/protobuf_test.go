@@ -0,0 +1,167 @@
+package daga
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/dedis/student_17_pop_fs/daga/pb"
+	"github.com/golang/protobuf/proto"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+func TestProto_Context(t *testing.T) {
+	p256, _ := LookupSuite("P256")
+	_, _, context, _ := generateTestContextSuite(p256, rand.Intn(10)+1, rand.Intn(10)+1)
+
+	//Normal execution
+	pbcontext, err := context.ProtoEncode()
+	if err != nil || pbcontext.Suite != "P256" {
+		t.Fatalf("Cannot encode the context: %s", err)
+	}
+	data, err := proto.Marshal(pbcontext)
+	if err != nil {
+		t.Fatalf("Cannot marshal the context: %s", err)
+	}
+	var received pb.Context
+	if err = proto.Unmarshal(data, &received); err != nil {
+		t.Fatalf("Cannot unmarshal the context: %s", err)
+	}
+	decoded, err := ProtoDecodeContext(&received)
+	if err != nil || decoded.Suite() != p256 || len(decoded.H) != len(context.H) {
+		t.Fatalf("Cannot decode the context: %s", err)
+	}
+	for i := range context.H {
+		if !decoded.H[i].Equal(context.H[i]) {
+			t.Errorf("Wrong H at index %d", i)
+		}
+	}
+
+	//Invalid inputs
+	if _, err = ProtoDecodeContext(nil); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
+	received.Suite = "Unknown"
+	if _, err = ProtoDecodeContext(&received); err == nil {
+		t.Error("Wrong check: Unknown suite")
+	}
+	received.Suite = "P256"
+	received.R[0] = []byte{1, 2, 3}
+	if _, err = ProtoDecodeContext(&received); err == nil {
+		t.Error("Wrong check: Invalid point")
+	}
+}
+
+func TestProto_Challenge(t *testing.T) {
+	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+
+	var commits []Commitment
+	var openings []abstract.Scalar
+	for i := range servers {
+		com, open, _ := servers[i].GenerateCommitment(context)
+		commits = append(commits, *com)
+		openings = append(openings, open)
+	}
+	check, _ := InitializeChallenge(context, testProofCommitments(context), commits, openings)
+	for i := range servers {
+		servers[i].CheckUpdateChallenge(context, check)
+	}
+
+	//ChallengeCheck
+	pbcheck, err := check.ProtoEncode()
+	if err != nil {
+		t.Fatalf("Cannot encode the challenge check: %s", err)
+	}
+	data, _ := proto.Marshal(pbcheck)
+	var receivedCheck pb.ChallengeCheck
+	proto.Unmarshal(data, &receivedCheck)
+	decodedCheck, err := ProtoDecodeChallengeCheck(suite, &receivedCheck)
+	if err != nil {
+		t.Fatalf("Cannot decode the challenge check: %s", err)
+	}
+	if err = servers[0].CheckUpdateChallenge(context, decodedCheck); err != nil {
+		t.Errorf("Decoded challenge check not accepted: %s", err)
+	}
+
+	//Challenge
+	challenge, _ := FinalizeChallenge(context, check)
+	pbchall, err := challenge.ProtoEncode()
+	if err != nil {
+		t.Fatalf("Cannot encode the challenge: %s", err)
+	}
+	data, _ = proto.Marshal(pbchall)
+	var receivedChall pb.Challenge
+	proto.Unmarshal(data, &receivedChall)
+	decodedChall, err := ProtoDecodeChallenge(suite, &receivedChall)
+	if err != nil || !decodedChall.cs.Equal(challenge.cs) || len(decodedChall.sigs) != len(challenge.sigs) {
+		t.Errorf("Challenge does not round-trip: %s", err)
+	}
+
+	//Empty inputs
+	if _, err = ProtoDecodeChallenge(suite, nil); err == nil {
+		t.Error("Wrong check: Empty challenge")
+	}
+	if _, err = ProtoDecodeCommitment(suite, &pb.Commitment{}); err == nil {
+		t.Error("Wrong check: Empty commitment")
+	}
+}
+
+func TestProto_Messages(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	i := rand.Intn(len(clients))
+	request, err := clients[i].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+
+	//ClientMessage
+	pbrequest, err := request.ProtoEncode()
+	if err != nil {
+		t.Fatalf("Cannot encode the client message: %s", err)
+	}
+	data, _ := proto.Marshal(pbrequest)
+	var receivedRequest pb.ClientMessage
+	proto.Unmarshal(data, &receivedRequest)
+	decodedRequest, err := ProtoDecodeClientMessage(&receivedRequest)
+	if err != nil || !verifyNonInteractiveClientProof(*decodedRequest, 1, nil) {
+		t.Fatalf("Client message does not round-trip: %s", err)
+	}
+
+	//ServerMessage
+	msg, err := endpoint.SubmitMessage(decodedRequest)
+	if err != nil {
+		t.Fatalf("Cannot process the request: %s", err)
+	}
+	proof, _ := servers[0].generateMisbehavingProof(context, request.sArray[0])
+	misbehaving := msg.copy()
+	misbehaving.proofs[0] = *proof
+	for _, m := range []*ServerMessage{msg, misbehaving} {
+		pbmsg, err := m.ProtoEncode()
+		if err != nil {
+			t.Fatalf("Cannot encode the server message: %s", err)
+		}
+		data, _ = proto.Marshal(pbmsg)
+		var receivedMsg pb.ServerMessage
+		proto.Unmarshal(data, &receivedMsg)
+		decodedMsg, err := ProtoDecodeServerMessage(&receivedMsg)
+		if err != nil {
+			t.Fatalf("Cannot decode the server message: %s", err)
+		}
+		if (m.proofs[0].r2 == nil) != (decodedMsg.proofs[0].r2 == nil) {
+			t.Error("Optional r2 does not round-trip")
+		}
+		if m == msg {
+			if _, err = clients[i].GetFinalLinkageTag(context, decodedMsg); err != nil {
+				t.Errorf("Decoded server message not accepted by the client: %s", err)
+			}
+		}
+	}
+
+	//Empty inputs
+	if _, err = ProtoDecodeServerMessage(nil); err == nil {
+		t.Error("Wrong check: Empty server message")
+	}
+	if _, err = ProtoDecodeClientMessage(&pb.ClientMessage{}); err == nil {
+		t.Error("Wrong check: Empty client message")
+	}
+}
@@ -0,0 +1,149 @@
+package daga
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//Formats of the exported public keys
+const (
+	KeyFormatHex    = "hex"
+	KeyFormatBase64 = "base64"
+	KeyFormatArmor  = "armor"
+)
+
+//publicKeyBlock is the type of the PEM blocks holding a public key in the armor format
+const publicKeyBlock = "DAGA PUBLIC KEY"
+
+/*EncodePublicKey exports the public key in the given format
+The armor format is a PEM block carrying the suite in its headers, the other ones a single line*/
+func EncodePublicKey(suite abstract.Suite, key abstract.Point, format string) (string, error) {
+	if suite == nil || key == nil {
+		return "", fmt.Errorf("Invalid inputs")
+	}
+	data, err := key.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("Error in key: %s", err)
+	}
+	switch format {
+	case KeyFormatHex:
+		return hex.EncodeToString(data) + "\n", nil
+	case KeyFormatBase64:
+		return base64.StdEncoding.EncodeToString(data) + "\n", nil
+	case KeyFormatArmor:
+		return string(pem.EncodeToMemory(&pem.Block{Type: publicKeyBlock, Headers: map[string]string{"Suite": suite.String()}, Bytes: data})), nil
+	}
+	return "", fmt.Errorf("Unknown key format: %s", format)
+}
+
+/*ReadPublicKeys reads a list of public keys, in the order of their indexes
+Each key is either a line in hex or base64, or an armored block; the formats can be mixed
+Empty lines and the lines starting with # are ignored
+The keys are decoded like the points of the messages, so the invalid and the repeated ones are rejected*/
+func ReadPublicKeys(suite abstract.Suite, r io.Reader) ([]abstract.Point, error) {
+	if suite == nil {
+		return nil, fmt.Errorf("Empty suite")
+	}
+	size := suite.Point().MarshalSize()
+	var keys []abstract.Point
+	seen := make(map[string]int)
+	add := func(data []byte, line int) error {
+		key, err := decodePoint(suite, data)
+		if err != nil {
+			return fmt.Errorf("Line %d: %s", line, err)
+		}
+		if previous, ok := seen[string(data)]; ok {
+			return fmt.Errorf("Line %d: key repeated from line %d", line, previous)
+		}
+		seen[string(data)] = line
+		keys = append(keys, key)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if text == "-----BEGIN "+publicKeyBlock+"-----" {
+			//Collect the whole block for the PEM decoder
+			start := line
+			var block bytes.Buffer
+			block.WriteString(text + "\n")
+			for !strings.HasPrefix(text, "-----END ") {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("Line %d: unterminated armored key", start)
+				}
+				line++
+				text = strings.TrimSpace(scanner.Text())
+				block.WriteString(text + "\n")
+			}
+			decoded, rest := pem.Decode(block.Bytes())
+			if decoded == nil || len(bytes.TrimSpace(rest)) != 0 || decoded.Type != publicKeyBlock {
+				return nil, fmt.Errorf("Line %d: invalid armored key", start)
+			}
+			if name, ok := decoded.Headers["Suite"]; ok && name != suite.String() {
+				return nil, fmt.Errorf("Line %d: key of suite %s instead of %s", start, name, suite)
+			}
+			if err := add(decoded.Bytes, start); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var data []byte
+		var err error
+		if len(text) == hex.EncodedLen(size) {
+			data, err = hex.DecodeString(text)
+		} else {
+			data, err = base64.StdEncoding.DecodeString(text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: invalid key encoding: %s", line, err)
+		}
+		if err = add(data, line); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Cannot read the keys: %s", err)
+	}
+	return keys, nil
+}
+
+/*LoadPublicKeys reads the list of public keys of a file, see ReadPublicKeys*/
+func LoadPublicKeys(suite abstract.Suite, path string) ([]abstract.Point, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the keys: %s", err)
+	}
+	keys, err := ReadPublicKeys(suite, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("No key in %s", path)
+	}
+	return keys, nil
+}
+
+/*LoadMembers imports the public keys of the clients and of the servers from the lists of two files*/
+func LoadMembers(suite abstract.Suite, clients, servers string) (G Members, err error) {
+	if G.X, err = LoadPublicKeys(suite, clients); err != nil {
+		return Members{}, err
+	}
+	if G.Y, err = LoadPublicKeys(suite, servers); err != nil {
+		return Members{}, err
+	}
+	return G, nil
+}
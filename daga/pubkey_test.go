@@ -0,0 +1,125 @@
+package daga
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+func TestEncodePublicKey(t *testing.T) {
+	clients, servers, _, _ := generateTestContext(3, 1)
+	formats := []string{KeyFormatHex, KeyFormatBase64, KeyFormatArmor}
+
+	//Normal execution, each format alone and mixed in a list
+	var list string
+	for i, format := range formats {
+		text, err := EncodePublicKey(suite, clients[i].GetPublicKey(), format)
+		if err != nil {
+			t.Fatalf("Cannot encode the key in %s: %s", format, err)
+		}
+		keys, err := ReadPublicKeys(suite, strings.NewReader(text))
+		if err != nil || len(keys) != 1 || !keys[0].Equal(clients[i].GetPublicKey()) {
+			t.Errorf("Key in %s does not round-trip: %s", format, err)
+		}
+		list += "# client " + format + "\n\n" + text
+	}
+	keys, err := ReadPublicKeys(suite, strings.NewReader(list))
+	if err != nil || len(keys) != len(clients) {
+		t.Fatalf("Cannot read the list: %s", err)
+	}
+	for i := range keys {
+		if !keys[i].Equal(clients[i].GetPublicKey()) {
+			t.Errorf("Wrong key %d", i)
+		}
+	}
+
+	//Wrong inputs
+	if _, err = EncodePublicKey(suite, servers[0].GetPublicKey(), "binary"); err == nil {
+		t.Error("Wrong check: Unknown format")
+	}
+	if _, err = EncodePublicKey(nil, servers[0].GetPublicKey(), KeyFormatHex); err == nil {
+		t.Error("Wrong check: Empty suite")
+	}
+}
+
+func TestReadPublicKeys_Invalid(t *testing.T) {
+	_, servers, _, _ := generateTestContext(1, 1)
+	key, _ := EncodePublicKey(suite, servers[0].GetPublicKey(), KeyFormatHex)
+	armored, _ := EncodePublicKey(suite, servers[0].GetPublicKey(), KeyFormatArmor)
+	p256, _ := LookupSuite("P256")
+
+	for _, input := range []struct {
+		name, text string
+	}{
+		{"Repeated key", key + key},
+		{"Invalid encoding", "not a key\n"},
+		{"Unterminated block", strings.Join(strings.Split(armored, "\n")[:3], "\n")},
+		{"Other block", strings.Replace(armored, publicKeyBlock, "DAGA PRIVATE KEY", -1)},
+	} {
+		if _, err := ReadPublicKeys(suite, strings.NewReader(input.text)); err == nil {
+			t.Errorf("Wrong check: %s", input.name)
+		}
+	}
+	for _, malicious := range maliciousPoints(suite) {
+		if _, err := ReadPublicKeys(suite, strings.NewReader(hex.EncodeToString(malicious.data))); err == nil {
+			t.Errorf("Wrong check: %s", malicious.name)
+		}
+	}
+
+	//Armored key of another suite
+	other, _ := CreateServer(p256, 0, nil)
+	armored, _ = EncodePublicKey(p256, other.GetPublicKey(), KeyFormatArmor)
+	if _, err := ReadPublicKeys(suite, strings.NewReader(armored)); err == nil {
+		t.Error("Wrong check: Suite")
+	}
+}
+
+func TestLoadMembers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatalf("Cannot create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	_, _, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	write := func(name string, keys []abstract.Point) string {
+		var text string
+		for _, key := range keys {
+			encoded, _ := EncodePublicKey(suite, key, KeyFormatBase64)
+			text += encoded
+		}
+		path := filepath.Join(dir, name)
+		ioutil.WriteFile(path, []byte(text), 0644)
+		return path
+	}
+	clients := write("clients.txt", context.G.X)
+	servers := write("servers.txt", context.G.Y)
+
+	//Normal execution
+	G, err := LoadMembers(suite, clients, servers)
+	if err != nil {
+		t.Fatalf("Cannot load the members: %s", err)
+	}
+	generated, err := GenerateContext(suite, G, context.R)
+	if err != nil {
+		t.Fatalf("Cannot generate the context: %s", err)
+	}
+	id, _ := context.ID()
+	if generatedID, _ := generated.ID(); generatedID != id {
+		t.Error("Wrong members")
+	}
+
+	//Empty and missing files
+	empty := write("empty.txt", nil)
+	if _, err = LoadMembers(suite, empty, servers); err == nil {
+		t.Error("Wrong check: Empty list")
+	}
+	if _, err = LoadMembers(suite, clients, filepath.Join(dir, "missing")); err == nil {
+		t.Error("Wrong check: Missing file")
+	}
+}
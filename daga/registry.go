@@ -0,0 +1,240 @@
+package daga
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*TagRecord holds what a service knows about an anonymous client, identified by its final linkage tag in a context
+Recent lists the authentications inside the rate limiting window*/
+type TagRecord struct {
+	First  time.Time
+	Last   time.Time
+	Count  uint64
+	Recent []time.Time
+}
+
+/*TagStore persists the records of the linkage tags of each context
+Tags are given as the hex encoding of their binary marshaling*/
+type TagStore interface {
+	//Get returns the record of the tag in the context, and false if the tag was never recorded
+	Get(context, tag string) (TagRecord, bool, error)
+	//Put replaces the record of the tag in the context
+	Put(context, tag string, record TagRecord) error
+}
+
+/*MemoryTagStore keeps the records in memory, they are lost when the process stops*/
+type MemoryTagStore struct {
+	mutex   sync.Mutex
+	records map[string]map[string]TagRecord
+}
+
+/*NewMemoryTagStore creates an empty in-memory store*/
+func NewMemoryTagStore() *MemoryTagStore {
+	return &MemoryTagStore{records: make(map[string]map[string]TagRecord)}
+}
+
+/*Get implements TagStore*/
+func (store *MemoryTagStore) Get(context, tag string) (TagRecord, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	record, ok := store.records[context][tag]
+	return record, ok, nil
+}
+
+/*Put implements TagStore*/
+func (store *MemoryTagStore) Put(context, tag string, record TagRecord) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.records[context] == nil {
+		store.records[context] = make(map[string]TagRecord)
+	}
+	store.records[context][tag] = record
+	return nil
+}
+
+/*FileTagStore keeps the records of each context in a JSON file of a directory
+The records of a context are loaded on first use and the file is rewritten on every Put*/
+type FileTagStore struct {
+	mutex   sync.Mutex
+	dir     string
+	records map[string]map[string]TagRecord
+}
+
+/*NewFileTagStore creates a store writing to dir, which is created if needed*/
+func NewFileTagStore(dir string) (*FileTagStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("Cannot create the tag directory: %s", err)
+	}
+	return &FileTagStore{dir: dir, records: make(map[string]map[string]TagRecord)}, nil
+}
+
+/*Get implements TagStore*/
+func (store *FileTagStore) Get(context, tag string) (TagRecord, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	records, err := store.load(context)
+	if err != nil {
+		return TagRecord{}, false, err
+	}
+	record, ok := records[tag]
+	return record, ok, nil
+}
+
+/*Put implements TagStore
+The file is replaced atomically so that a crash never leaves it half written*/
+func (store *FileTagStore) Put(context, tag string, record TagRecord) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	records, err := store.load(context)
+	if err != nil {
+		return err
+	}
+	records[tag] = record
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("Cannot json marshal the tags: %s", err)
+	}
+	temp := store.path(context) + ".tmp"
+	if err = ioutil.WriteFile(temp, data, 0600); err != nil {
+		return fmt.Errorf("Cannot write the tags: %s", err)
+	}
+	if err = os.Rename(temp, store.path(context)); err != nil {
+		return fmt.Errorf("Cannot write the tags: %s", err)
+	}
+	return nil
+}
+
+func (store *FileTagStore) path(context string) string {
+	return filepath.Join(store.dir, context+".json")
+}
+
+//load returns the records of the context, reading its file if needed
+func (store *FileTagStore) load(context string) (map[string]TagRecord, error) {
+	if records, ok := store.records[context]; ok {
+		return records, nil
+	}
+	records := make(map[string]TagRecord)
+	data, err := ioutil.ReadFile(store.path(context))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Cannot read the tags: %s", err)
+	}
+	if err == nil {
+		if err = json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("Cannot json unmarshal the tags: %s", err)
+		}
+	}
+	store.records[context] = records
+	return records, nil
+}
+
+/*TagResult is the outcome of the registration of a final linkage tag
+Repeat is set if the same anonymous client already authenticated in the context
+Limited is set if the authentication was refused by the rate limiting, it is then not recorded*/
+type TagResult struct {
+	Repeat  bool
+	Limited bool
+	Record  TagRecord
+}
+
+/*TagRegistry records the final linkage tags accepted by a service
+It tells whether an authentication comes from a client already seen in the context,
+and limits the number of authentications per tag in a sliding window*/
+type TagRegistry struct {
+	mutex  sync.Mutex
+	store  TagStore
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+/*NewTagRegistry creates a registry backed by store
+A tag is allowed limit authentications per window, a limit of 0 disables the rate limiting*/
+func NewTagRegistry(store TagStore, limit int, window time.Duration) (*TagRegistry, error) {
+	if store == nil || limit < 0 || (limit > 0 && window <= 0) {
+		return nil, fmt.Errorf("Invalid parameters")
+	}
+	return &TagRegistry{store: store, limit: limit, window: window, now: time.Now}, nil
+}
+
+/*Register records an authentication of the client with the final linkage tag Tf in the context
+The neutral element, which is the tag of every misbehaving client, is rejected*/
+func (registry *TagRegistry) Register(context *Context, Tf abstract.Point) (*TagResult, error) {
+	if context == nil || Tf == nil {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	if Tf.Equal(context.Suite().Point().Null()) {
+		return nil, fmt.Errorf("Tag of a misbehaving client")
+	}
+	key, tag, err := tagKeys(context, Tf)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	record, found, err := registry.store.Get(key, tag)
+	if err != nil {
+		return nil, fmt.Errorf("Error in tag store: %s", err)
+	}
+
+	now := registry.now()
+	if registry.limit > 0 {
+		var recent []time.Time
+		for _, at := range record.Recent {
+			if now.Sub(at) < registry.window {
+				recent = append(recent, at)
+			}
+		}
+		record.Recent = recent
+		if len(record.Recent) >= registry.limit {
+			return &TagResult{Repeat: found, Limited: true, Record: record}, nil
+		}
+		record.Recent = append(record.Recent, now)
+	}
+	if !found {
+		record.First = now
+	}
+	record.Last = now
+	record.Count++
+	if err = registry.store.Put(key, tag, record); err != nil {
+		return nil, fmt.Errorf("Error in tag store: %s", err)
+	}
+	return &TagResult{Repeat: found, Record: record}, nil
+}
+
+/*Lookup returns the record of the final linkage tag Tf in the context, and false if it was never registered*/
+func (registry *TagRegistry) Lookup(context *Context, Tf abstract.Point) (TagRecord, bool, error) {
+	if context == nil || Tf == nil {
+		return TagRecord{}, false, fmt.Errorf("Invalid inputs")
+	}
+	key, tag, err := tagKeys(context, Tf)
+	if err != nil {
+		return TagRecord{}, false, err
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	return registry.store.Get(key, tag)
+}
+
+//tagKeys returns the keys of the context and of the tag in the store, the context being keyed by its ID
+func tagKeys(context *Context, Tf abstract.Point) (key, tag string, err error) {
+	id, err := context.ID()
+	if err != nil {
+		return "", "", err
+	}
+	key = id.String()
+	data, err := Tf.MarshalBinary()
+	if err != nil {
+		return "", "", fmt.Errorf("Error in tag: %s", err)
+	}
+	return key, hex.EncodeToString(data), nil
+}
@@ -0,0 +1,136 @@
+package daga
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+func TestTagRegistry(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+1)
+	endpoint := &localEndpoint{context: context, servers: servers}
+	registry, err := NewTagRegistry(NewMemoryTagStore(), 0, 0)
+	if err != nil {
+		t.Fatalf("Cannot create the registry: %s", err)
+	}
+
+	//Normal execution
+	authenticate := func(i int) *TagResult {
+		session, _ := NewClientSession(&clients[i], context)
+		Tf, err := session.Authenticate(endpoint)
+		if err != nil {
+			t.Fatalf("Cannot authenticate client %d: %s", i, err)
+		}
+		result, err := registry.Register(context, Tf)
+		if err != nil {
+			t.Fatalf("Cannot register the tag: %s", err)
+		}
+		return result
+	}
+	if result := authenticate(0); result.Repeat || result.Record.Count != 1 {
+		t.Error("First authentication reported as a repeat")
+	}
+	if result := authenticate(0); !result.Repeat || result.Record.Count != 2 {
+		t.Error("Repeated authentication not detected")
+	}
+	if result := authenticate(1); result.Repeat {
+		t.Error("Another client reported as a repeat")
+	}
+
+	//Another context does not share the tags
+	_, _, other, _ := generateTestContext(1, 1)
+	Tf := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	registry.Register(context, Tf)
+	if result, _ := registry.Register(other, Tf); result.Repeat {
+		t.Error("Tags shared between contexts")
+	}
+
+	//Empty inputs
+	if _, err = registry.Register(nil, Tf); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
+	if _, err = registry.Register(context, nil); err == nil {
+		t.Error("Wrong check: Empty tag")
+	}
+
+	//Tag of a misbehaving client
+	if _, err = registry.Register(context, context.Suite().Point().Null()); err == nil {
+		t.Error("Wrong check: Neutral tag")
+	}
+	if _, err = NewTagRegistry(nil, 0, 0); err == nil {
+		t.Error("Wrong check: Empty store")
+	}
+	if _, err = NewTagRegistry(NewMemoryTagStore(), 1, 0); err == nil {
+		t.Error("Wrong check: Rate limit without window")
+	}
+}
+
+func TestTagRegistry_RateLimit(t *testing.T) {
+	_, _, context, _ := generateTestContext(1, 1)
+	registry, _ := NewTagRegistry(NewMemoryTagStore(), 2, time.Minute)
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+	Tf := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+
+	//Normal execution
+	for i := 0; i < 2; i++ {
+		if result, err := registry.Register(context, Tf); err != nil || result.Limited {
+			t.Fatalf("Authentication %d limited: %s", i, err)
+		}
+	}
+
+	//Limit reached
+	result, err := registry.Register(context, Tf)
+	if err != nil || !result.Limited {
+		t.Error("Wrong check: Rate limit")
+	}
+	if record, _, _ := registry.Lookup(context, Tf); record.Count != 2 {
+		t.Errorf("Limited authentication recorded: %d", record.Count)
+	}
+
+	//Window elapsed
+	now = now.Add(time.Minute)
+	if result, err = registry.Register(context, Tf); err != nil || result.Limited || result.Record.Count != 3 {
+		t.Error("Authentication limited after the window")
+	}
+}
+
+func TestFileTagStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daga")
+	if err != nil {
+		t.Fatalf("Cannot create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, _, context, _ := generateTestContext(1, 1)
+	Tf := suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	store, err := NewFileTagStore(dir)
+	if err != nil {
+		t.Fatalf("Cannot create the store: %s", err)
+	}
+	registry, _ := NewTagRegistry(store, 0, 0)
+	registry.Register(context, Tf)
+
+	//The records survive a restart
+	store, _ = NewFileTagStore(dir)
+	registry, _ = NewTagRegistry(store, 0, 0)
+	record, found, err := registry.Lookup(context, Tf)
+	if err != nil || !found || record.Count != 1 {
+		t.Errorf("Record not persisted: %s", err)
+	}
+	if result, _ := registry.Register(context, Tf); !result.Repeat {
+		t.Error("Repeated authentication not detected after a restart")
+	}
+
+	//Corrupted file
+	id, _ := context.ID()
+	ioutil.WriteFile(store.path(id.String()), []byte("{"), 0600)
+	store, _ = NewFileTagStore(dir)
+	if _, _, err = store.Get(id.String(), "tag"); err == nil {
+		t.Error("Wrong check: Corrupted file")
+	}
+}
@@ -0,0 +1,496 @@
+package daga
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*RoundPhase is the step reached by a Round*/
+type RoundPhase int
+
+//Phases of a Round, in the order they are reached
+const (
+	PhaseCommitment RoundPhase = iota //The leader collects the commitments of the servers
+	PhaseOpening                      //The leader collects the openings of the servers
+	PhaseChallenge                    //The challenge goes through the servers for signature
+	PhaseTag                          //The client's request goes through the servers for the linkage tags
+	PhaseDone                         //All the servers processed the request
+	PhaseAborted                      //A step failed or a server did not answer in time
+)
+
+func (phase RoundPhase) String() string {
+	switch phase {
+	case PhaseCommitment:
+		return "commitment"
+	case PhaseOpening:
+		return "opening"
+	case PhaseChallenge:
+		return "challenge"
+	case PhaseTag:
+		return "tag"
+	case PhaseDone:
+		return "done"
+	case PhaseAborted:
+		return "aborted"
+	}
+	return fmt.Sprintf("unknown(%d)", int(phase))
+}
+
+//DefaultRoundTimeout is the time allowed to the servers to complete a phase of a round
+const DefaultRoundTimeout = 30 * time.Second
+
+/*Round tracks an authentication request on the server that coordinates it
+The coordinator goes through the commitment, opening, challenge and tag phases with the other servers
+Every phase must be completed before its deadline, otherwise the round is aborted*/
+type Round struct {
+	server  *Server
+	context *Context
+	timeout time.Duration
+	now     func() time.Time
+
+	phase    RoundPhase
+	deadline time.Time
+	err      error
+
+	//Challenge generation
+	t        []abstract.Point //Commitments of the client the challenge is generated for
+	commits  []*Commitment
+	openings []abstract.Scalar
+	check    *ChallengeCheck
+	final    *Challenge
+	issued   *IssuedChallenge
+
+	//Linkage tags
+	msg *ServerMessage
+}
+
+/*IssuedChallenge is a challenge generated by the servers for the proof commitments t of a client
+A request is only accepted as the answer to the challenge issued for its own commitments:
+a client choosing its commitments after seeing the challenge could simulate the proof without any private key*/
+type IssuedChallenge struct {
+	t         []abstract.Point
+	challenge *Challenge
+}
+
+//Challenge returns the challenge to send to the client
+func (issued *IssuedChallenge) Challenge() *Challenge {
+	return issued.challenge
+}
+
+/*check verifies that the request answers the challenge with the commitments it was issued for*/
+func (issued *IssuedChallenge) check(request *ClientMessage) error {
+	if request.proof.nonInteractive || request.proof.cs == nil || !request.proof.cs.Equal(issued.challenge.cs) {
+		return fmt.Errorf("Request does not answer the challenge of the round")
+	}
+	if len(request.proof.t) != len(issued.t) {
+		return fmt.Errorf("Request does not use the commitments the challenge was issued for")
+	}
+	for i := range issued.t {
+		if request.proof.t[i] == nil || !request.proof.t[i].Equal(issued.t[i]) {
+			return fmt.Errorf("Request does not use the commitments the challenge was issued for")
+		}
+	}
+	return nil
+}
+
+//NewRound starts a round coordinated by server, beginning with the generation of the challenge for the commitments t of a client
+//It returns the round and the coordinator's commitment to send to the other servers
+func NewRound(server *Server, context *Context, t []abstract.Point, timeout time.Duration) (*Round, *Commitment, error) {
+	round, err := newRound(server, context, timeout, PhaseCommitment)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(t) != 3*len(context.G.X) {
+		return nil, nil, fmt.Errorf("Wrong number of commitments: got %d expected %d", len(t), 3*len(context.G.X))
+	}
+	for i, p := range t {
+		if err = checkPoint(context.Suite(), p, false); err != nil {
+			return nil, nil, fmt.Errorf("Invalid commitment %d: %s", i, err)
+		}
+	}
+	round.t = append([]abstract.Point(nil), t...)
+
+	commit, opening, err := server.GenerateCommitment(context)
+	if err != nil {
+		return nil, nil, err
+	}
+	round.commits = make([]*Commitment, len(context.G.Y))
+	round.openings = make([]abstract.Scalar, len(context.G.Y))
+	round.commits[server.index] = commit
+	round.openings[server.index] = opening
+
+	//A coordinator alone in the context does not wait for anyone
+	if err = round.checkCommitments(); err != nil {
+		return nil, nil, err
+	}
+	return round, commit, nil
+}
+
+//NewTagRound starts a round coordinated by server for a request whose challenge was already generated
+//Without an issued challenge, only non-interactive requests are accepted
+func NewTagRound(server *Server, context *Context, issued *IssuedChallenge, timeout time.Duration) (*Round, error) {
+	round, err := newRound(server, context, timeout, PhaseTag)
+	if err != nil {
+		return nil, err
+	}
+	if issued != nil {
+		round.final = issued.challenge
+		round.issued = issued
+	}
+	return round, nil
+}
+
+func newRound(server *Server, context *Context, timeout time.Duration, phase RoundPhase) (*Round, error) {
+	if server == nil || context == nil {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	if server.index >= len(context.G.Y) {
+		return nil, fmt.Errorf("Server index %d out of range", server.index)
+	}
+	if timeout <= 0 {
+		timeout = DefaultRoundTimeout
+	}
+	round := &Round{server: server, context: context, timeout: timeout, now: time.Now}
+	round.enter(phase)
+	return round, nil
+}
+
+//Phase returns the current phase of the round
+func (round *Round) Phase() RoundPhase {
+	return round.phase
+}
+
+//Err returns the reason why the round was aborted, nil otherwise
+func (round *Round) Err() error {
+	return round.err
+}
+
+//Remaining returns the time left before the deadline of the current phase
+func (round *Round) Remaining() time.Duration {
+	left := round.deadline.Sub(round.now())
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+//CheckDeadline aborts the round if the current phase was not completed in time
+//It returns the error of the round if it is aborted
+func (round *Round) CheckDeadline() error {
+	if round.phase == PhaseAborted {
+		return round.err
+	}
+	if round.phase == PhaseDone || !round.now().After(round.deadline) {
+		return nil
+	}
+
+	var missing []int
+	switch round.phase {
+	case PhaseCommitment:
+		for i, com := range round.commits {
+			if com == nil {
+				missing = append(missing, i)
+			}
+		}
+	case PhaseOpening:
+		for i, open := range round.openings {
+			if open == nil {
+				missing = append(missing, i)
+			}
+		}
+	case PhaseChallenge:
+		missing = append(missing, round.next(len(round.check.sigs)))
+	case PhaseTag:
+		if round.msg != nil {
+			missing = append(missing, round.next(len(round.msg.indexes)))
+		}
+	}
+	if len(missing) == 0 {
+		return round.Abort(fmt.Errorf("Timeout in phase %s", round.phase))
+	}
+	return round.Abort(fmt.Errorf("Timeout in phase %s: no answer from servers %v", round.phase, missing))
+}
+
+//Abort stops the round with the given reason
+//The error returned describes the phase in which the round stopped
+func (round *Round) Abort(reason error) error {
+	if round.phase == PhaseAborted {
+		return round.err
+	}
+	round.err = fmt.Errorf("Round aborted in phase %s: %s", round.phase, reason)
+	round.phase = PhaseAborted
+	return round.err
+}
+
+//AddCommitment records the commitment of another server
+//Once all the commitments are received and verified, the round moves to the opening phase
+func (round *Round) AddCommitment(commit *Commitment) error {
+	if err := round.expect(PhaseCommitment); err != nil {
+		return err
+	}
+	if commit == nil || commit.commit == nil {
+		return fmt.Errorf("Empty commitment")
+	}
+	index := commit.sig.index
+	if index < 0 || index >= len(round.commits) {
+		return fmt.Errorf("Invalid commitment index %d", index)
+	}
+	if round.commits[index] != nil {
+		return fmt.Errorf("Duplicate commitment for server %d", index)
+	}
+	round.commits[index] = commit
+	return round.checkCommitments()
+}
+
+//Opening returns the coordinator's opening to reveal to the other servers
+//It is only available once all the commitments are received
+func (round *Round) Opening() (abstract.Scalar, error) {
+	if err := round.expect(PhaseOpening); err != nil {
+		return nil, err
+	}
+	return round.openings[round.server.index], nil
+}
+
+//AddOpening records the opening of the server of index i
+//Once all the openings are received, the challenge is computed and signed by the coordinator and the round moves to the challenge phase
+func (round *Round) AddOpening(i int, opening abstract.Scalar) error {
+	if err := round.expect(PhaseOpening); err != nil {
+		return err
+	}
+	if opening == nil {
+		return fmt.Errorf("Empty opening")
+	}
+	if i < 0 || i >= len(round.openings) {
+		return fmt.Errorf("Invalid opening index %d", i)
+	}
+	if round.openings[i] != nil {
+		return fmt.Errorf("Duplicate opening for server %d", i)
+	}
+	round.openings[i] = opening
+	return round.checkOpenings()
+}
+
+//ChallengeCheck returns the challenge to send to the next server for signature, and the index of that server
+func (round *Round) ChallengeCheck() (check *ChallengeCheck, next int, err error) {
+	if err = round.expect(PhaseChallenge); err != nil {
+		return nil, 0, err
+	}
+	//The servers append their signature to the challenge, the round keeps its own copy
+	check = &ChallengeCheck{cs: round.check.cs, digest: round.check.digest, commits: round.check.commits, openings: round.check.openings}
+	check.sigs = append(check.sigs, round.check.sigs...)
+	return check, round.next(len(round.check.sigs)), nil
+}
+
+//UpdateChallenge records the challenge signed by the next server
+//Once all the servers signed it, the coordinator checks it and the round moves to the tag phase
+func (round *Round) UpdateChallenge(check *ChallengeCheck) error {
+	if err := round.expect(PhaseChallenge); err != nil {
+		return err
+	}
+	if check == nil || check.cs == nil || !check.cs.Equal(round.check.cs) || check.digest != round.check.digest {
+		return round.Abort(fmt.Errorf("Challenge does not match"))
+	}
+	if len(check.sigs) != len(round.check.sigs)+1 {
+		return round.Abort(fmt.Errorf("Wrong number of signatures: got %d expected %d", len(check.sigs), len(round.check.sigs)+1))
+	}
+	expected := round.next(len(round.check.sigs))
+	if check.sigs[len(check.sigs)-1].index != expected {
+		return round.Abort(fmt.Errorf("Signature of server %d instead of %d", check.sigs[len(check.sigs)-1].index, expected))
+	}
+	round.check = check
+	return round.checkChallenge()
+}
+
+//Challenge returns the challenge signed by all the servers, to send to the client
+func (round *Round) Challenge() (*Challenge, error) {
+	if round.phase == PhaseAborted {
+		return nil, round.err
+	}
+	if round.final == nil {
+		return nil, fmt.Errorf("Challenge not available in phase %s", round.phase)
+	}
+	return round.final, nil
+}
+
+//Issued returns the challenge signed by all the servers along with the commitments it was issued for
+func (round *Round) Issued() (*IssuedChallenge, error) {
+	if round.phase == PhaseAborted {
+		return nil, round.err
+	}
+	if round.issued == nil {
+		return nil, fmt.Errorf("Challenge not available in phase %s", round.phase)
+	}
+	return round.issued, nil
+}
+
+//ProcessRequest runs the server protocol of the coordinator on the client's request
+//If a challenge was issued for the round, the request must answer it with the commitments it was issued for, otherwise it must be non-interactive
+//It returns the ServerMessage to send to the next server and the index of that server, or -1 if the round is done
+func (round *Round) ProcessRequest(request *ClientMessage) (msg *ServerMessage, next int, err error) {
+	if err = round.expect(PhaseTag); err != nil {
+		return nil, 0, err
+	}
+	if round.msg != nil {
+		return nil, 0, fmt.Errorf("Request already processed")
+	}
+	if request == nil {
+		return nil, 0, fmt.Errorf("Empty request")
+	}
+	if round.issued != nil {
+		if err = round.issued.check(request); err != nil {
+			return nil, 0, round.Abort(err)
+		}
+	} else if !request.proof.nonInteractive {
+		return nil, 0, round.Abort(fmt.Errorf("No challenge issued for the request"))
+	}
+
+	msg = round.server.InitializeServerMessage(request)
+	if err = round.server.ServerProtocol(round.context, msg); err != nil {
+		return nil, 0, round.Abort(err)
+	}
+	round.msg = msg.copy()
+	if round.completeTags() {
+		return msg, -1, nil
+	}
+	return msg, round.next(len(msg.indexes)), nil
+}
+
+//UpdateServerMessage records the ServerMessage processed by the next server
+//Once all the servers processed it, the round is done
+//It returns the index of the next server, or -1 if the round is done
+func (round *Round) UpdateServerMessage(msg *ServerMessage) (next int, err error) {
+	if err = round.expect(PhaseTag); err != nil {
+		return 0, err
+	}
+	if round.msg == nil {
+		return 0, fmt.Errorf("Request not processed yet")
+	}
+	if msg == nil || len(msg.indexes) != len(round.msg.indexes)+1 {
+		return 0, round.Abort(fmt.Errorf("Server message does not extend the previous one"))
+	}
+	expected := round.next(len(round.msg.indexes))
+	if msg.indexes[len(msg.indexes)-1] != expected {
+		return 0, round.Abort(fmt.Errorf("Server message processed by server %d instead of %d", msg.indexes[len(msg.indexes)-1], expected))
+	}
+	if !msg.request.t0.Equal(round.msg.request.t0) {
+		return 0, round.Abort(fmt.Errorf("Server message for another request"))
+	}
+	round.msg = msg.copy()
+
+	if round.completeTags() {
+		return -1, nil
+	}
+	return round.next(len(msg.indexes)), nil
+}
+
+//ServerMessage returns the message processed by all the servers, to send to the client
+func (round *Round) ServerMessage() (*ServerMessage, error) {
+	if round.phase == PhaseAborted {
+		return nil, round.err
+	}
+	if round.phase != PhaseDone {
+		return nil, fmt.Errorf("Server message not available in phase %s", round.phase)
+	}
+	return round.msg, nil
+}
+
+/*expect checks the deadline and that the round is in the given phase*/
+func (round *Round) expect(phase RoundPhase) error {
+	if err := round.CheckDeadline(); err != nil {
+		return err
+	}
+	if round.phase != phase {
+		return fmt.Errorf("Out of phase message: expected phase %s, round in phase %s", phase, round.phase)
+	}
+	return nil
+}
+
+/*enter moves the round to a new phase and sets its deadline*/
+func (round *Round) enter(phase RoundPhase) {
+	round.phase = phase
+	round.deadline = round.now().Add(round.timeout)
+}
+
+/*checkCommitments verifies the commitments and moves the round to the opening phase once all of them are received*/
+func (round *Round) checkCommitments() error {
+	commits := make([]Commitment, len(round.commits))
+	for i, com := range round.commits {
+		if com == nil {
+			return nil
+		}
+		commits[i] = *com
+	}
+	if err := VerifyCommitmentSignature(round.context, commits); err != nil {
+		return round.Abort(err)
+	}
+	round.enter(PhaseOpening)
+	return round.checkOpenings()
+}
+
+/*checkOpenings computes and signs the challenge once all the openings are received, then moves the round to the challenge phase*/
+func (round *Round) checkOpenings() error {
+	for _, open := range round.openings {
+		if open == nil {
+			return nil
+		}
+	}
+	commits := make([]Commitment, len(round.commits))
+	for i, com := range round.commits {
+		commits[i] = *com
+	}
+	check, err := InitializeChallenge(round.context, round.t, commits, round.openings)
+	if err != nil {
+		return round.Abort(err)
+	}
+	if err = round.server.CheckUpdateChallenge(round.context, check); err != nil {
+		return round.Abort(err)
+	}
+	round.check = check
+	round.enter(PhaseChallenge)
+	return round.checkChallenge()
+}
+
+/*checkChallenge finalizes the challenge once all the servers signed it, then moves the round to the tag phase*/
+func (round *Round) checkChallenge() error {
+	if len(round.check.sigs) != len(round.context.G.Y) {
+		return nil
+	}
+	if err := round.server.CheckUpdateChallenge(round.context, round.check); err != nil {
+		return round.Abort(err)
+	}
+	final, err := FinalizeChallenge(round.context, round.check)
+	if err != nil {
+		return round.Abort(err)
+	}
+	round.final = final
+	round.issued = &IssuedChallenge{t: round.t, challenge: final}
+	round.enter(PhaseTag)
+	return nil
+}
+
+/*completeTags moves the round to the done phase if all the servers processed the request*/
+func (round *Round) completeTags() bool {
+	if len(round.msg.indexes) != len(round.context.G.Y) {
+		return false
+	}
+	round.enter(PhaseDone)
+	return true
+}
+
+/*next returns the index of the server following the coordinator after count servers*/
+func (round *Round) next(count int) int {
+	return (round.server.index + count) % len(round.context.G.Y)
+}
+
+/*copy returns a ServerMessage that the servers can extend without modifying the original*/
+func (msg *ServerMessage) copy() *ServerMessage {
+	return &ServerMessage{
+		request: msg.request,
+		tags:    append([]abstract.Point(nil), msg.tags...),
+		proofs:  append([]serverProof(nil), msg.proofs...),
+		indexes: append([]int(nil), msg.indexes...),
+		sigs:    append([]serverSignature(nil), msg.sigs...),
+	}
+}
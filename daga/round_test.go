@@ -0,0 +1,331 @@
+package daga
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*runChallengePhases drives a round through the challenge generation with all the other servers*/
+func runChallengePhases(t *testing.T, round *Round, servers []Server, context *Context) {
+	leader := round.server.index
+	openings := make([]abstract.Scalar, len(servers))
+	for i := range servers {
+		if i == leader {
+			continue
+		}
+		commit, open, _ := servers[i].GenerateCommitment(context)
+		openings[i] = open
+		if err := round.AddCommitment(commit); err != nil {
+			t.Fatalf("Cannot add the commitment of server %d: %s", i, err)
+		}
+	}
+	if len(servers) > 1 {
+		if _, err := round.Opening(); err != nil {
+			t.Fatalf("Cannot get the leader's opening: %s", err)
+		}
+	}
+	for i := range servers {
+		if i == leader {
+			continue
+		}
+		if err := round.AddOpening(i, openings[i]); err != nil {
+			t.Fatalf("Cannot add the opening of server %d: %s", i, err)
+		}
+	}
+	for round.Phase() == PhaseChallenge {
+		check, next, err := round.ChallengeCheck()
+		if err != nil {
+			t.Fatalf("Cannot get the challenge: %s", err)
+		}
+		if err = servers[next].CheckUpdateChallenge(context, check); err != nil {
+			t.Fatalf("Server %d cannot sign the challenge: %s", next, err)
+		}
+		if err = round.UpdateChallenge(check); err != nil {
+			t.Fatalf("Cannot update the challenge: %s", err)
+		}
+	}
+}
+
+/*clientCommitments returns the proof commitments t of a new request of the client*/
+func clientCommitments(client *Client, context *Context) []abstract.Point {
+	T0, _, s, _ := client.CreateRequest(context)
+	t, _, _ := client.GenerateProofCommitments(context, T0, s)
+	return *t
+}
+
+func TestNewRound(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+2)
+	tclient := clientCommitments(&clients[0], context)
+
+	//Normal execution
+	round, commit, err := NewRound(&servers[0], context, tclient, time.Minute)
+	if err != nil || round == nil || commit == nil {
+		t.Error("Cannot start a round")
+	}
+	if round.Phase() != PhaseCommitment {
+		t.Errorf("Wrong phase: %s", round.Phase())
+	}
+
+	//Empty inputs
+	round, commit, err = NewRound(nil, context, tclient, time.Minute)
+	if err == nil || round != nil || commit != nil {
+		t.Error("Wrong check: Empty server")
+	}
+	round, commit, err = NewRound(&servers[0], nil, tclient, time.Minute)
+	if err == nil || round != nil || commit != nil {
+		t.Error("Wrong check: Empty context")
+	}
+
+	//Wrong commitments
+	round, commit, err = NewRound(&servers[0], context, tclient[1:], time.Minute)
+	if err == nil || round != nil || commit != nil {
+		t.Error("Wrong check: Number of commitments")
+	}
+	wrong := append([]abstract.Point{suite.Point().Null()}, tclient[1:]...)
+	round, commit, err = NewRound(&servers[0], context, wrong, time.Minute)
+	if err == nil || round != nil || commit != nil {
+		t.Error("Wrong check: Neutral commitment")
+	}
+
+	//Server outside of the context
+	outside, _ := CreateServer(suite, len(context.G.Y), nil)
+	round, commit, err = NewRound(&outside, context, tclient, time.Minute)
+	if err == nil || round != nil || commit != nil {
+		t.Error("Wrong check: Server index out of range")
+	}
+
+	//A single server goes directly to the tag phase
+	lonely, alone, single, _ := generateTestContext(1, 1)
+	round, _, err = NewRound(&alone[0], single, clientCommitments(&lonely[0], single), time.Minute)
+	if err != nil || round.Phase() != PhaseTag {
+		t.Error("Single server round not ready for the tags")
+	}
+	if challenge, err := round.Challenge(); err != nil || challenge == nil {
+		t.Error("Cannot get the challenge of a single server round")
+	}
+	if issued, err := round.Issued(); err != nil || issued == nil || issued.Challenge() == nil {
+		t.Error("Cannot get the issued challenge of a single server round")
+	}
+}
+
+func TestRound_Full(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+2)
+	j := rand.Intn(len(servers))
+
+	//The client commits before the challenge is generated
+	i := rand.Intn(len(clients))
+	T0, S, s, _ := clients[i].CreateRequest(context)
+	tclient, v, w := clients[i].GenerateProofCommitments(context, T0, s)
+	round, _, _ := NewRound(&servers[j], context, *tclient, time.Minute)
+
+	runChallengePhases(t, round, servers, context)
+	if round.Phase() != PhaseTag {
+		t.Fatalf("Wrong phase after the challenge generation: %s", round.Phase())
+	}
+	challenge, err := round.Challenge()
+	if err != nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+
+	//The client answers the challenge
+	c, r, _ := clients[i].GenerateProofResponses(context, s, challenge, v, w)
+	request := clients[i].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+
+	msg, next, err := round.ProcessRequest(request)
+	if err != nil || msg == nil {
+		t.Fatalf("Cannot process the request: %s", err)
+	}
+	for next != -1 {
+		if err = servers[next].ServerProtocol(context, msg); err != nil {
+			t.Fatalf("Server protocol failed at server %d: %s", next, err)
+		}
+		if next, err = round.UpdateServerMessage(msg); err != nil {
+			t.Fatalf("Cannot update the server message: %s", err)
+		}
+	}
+	if round.Phase() != PhaseDone {
+		t.Errorf("Wrong phase at the end of the round: %s", round.Phase())
+	}
+
+	final, err := round.ServerMessage()
+	if err != nil {
+		t.Fatalf("Cannot get the server message: %s", err)
+	}
+	Tf, err := clients[i].GetFinalLinkageTag(context, final)
+	if err != nil || Tf.Equal(suite.Point().Null()) {
+		t.Error("Invalid final linkage tag")
+	}
+}
+
+func TestRound_OutOfPhase(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(2, rand.Intn(10)+2)
+	T0, S, s, _ := clients[0].CreateRequest(context)
+	tclient, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	round, _, _ := NewRound(&servers[0], context, *tclient, time.Minute)
+
+	//Messages of later phases during the commitment phase
+	_, open, _ := servers[1].GenerateCommitment(context)
+	if err := round.AddOpening(1, open); err == nil {
+		t.Error("Wrong check: Opening during the commitment phase")
+	}
+	if _, err := round.Opening(); err == nil {
+		t.Error("Wrong check: Leader's opening revealed during the commitment phase")
+	}
+	if _, _, err := round.ChallengeCheck(); err == nil {
+		t.Error("Wrong check: Challenge during the commitment phase")
+	}
+	if _, _, err := round.ProcessRequest(&ClientMessage{}); err == nil {
+		t.Error("Wrong check: Request during the commitment phase")
+	}
+	if _, err := round.Challenge(); err == nil {
+		t.Error("Wrong check: Challenge available during the commitment phase")
+	}
+	if round.Phase() != PhaseCommitment {
+		t.Error("Out of phase messages changed the phase")
+	}
+
+	//Duplicate commitment
+	commit, _, _ := servers[0].GenerateCommitment(context)
+	if err := round.AddCommitment(commit); err == nil {
+		t.Error("Wrong check: Duplicate commitment")
+	}
+
+	//Messages of earlier phases during the tag phase
+	runChallengePhases(t, round, servers, context)
+	commit, _, _ = servers[1].GenerateCommitment(context)
+	if err := round.AddCommitment(commit); err == nil {
+		t.Error("Wrong check: Commitment during the tag phase")
+	}
+	if _, err := round.UpdateServerMessage(&ServerMessage{}); err == nil {
+		t.Error("Wrong check: Server message before the request")
+	}
+
+	//Request answering another challenge
+	challenge, _ := round.Challenge()
+	other := &Challenge{cs: suite.Scalar().Add(challenge.cs, suite.Scalar().One()), digest: challenge.digest, sigs: challenge.sigs}
+	c, r, _ := clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	request := clients[0].AssembleMessage(context, &S, T0, other, tclient, c, r)
+	if _, _, err := round.ProcessRequest(request); err == nil || round.Phase() != PhaseAborted {
+		t.Error("Wrong check: Request for another challenge")
+	}
+	if round.Err() == nil {
+		t.Error("No error for an aborted round")
+	}
+}
+
+func TestRound_Timeout(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(1, rand.Intn(10)+3)
+	round, _, _ := NewRound(&servers[0], context, clientCommitments(&clients[0], context), time.Minute)
+
+	//Control the clock of the round
+	now := time.Now()
+	round.now = func() time.Time { return now }
+
+	commit, _, _ := servers[1].GenerateCommitment(context)
+	if err := round.AddCommitment(commit); err != nil {
+		t.Errorf("Cannot add a commitment in time: %s", err)
+	}
+	if round.Remaining() <= 0 {
+		t.Error("No time remaining before the deadline")
+	}
+
+	//The other servers do not answer
+	now = now.Add(2 * time.Minute)
+	if round.Remaining() != 0 {
+		t.Error("Time remaining after the deadline")
+	}
+	commit, _, _ = servers[2].GenerateCommitment(context)
+	err := round.AddCommitment(commit)
+	if err == nil || round.Phase() != PhaseAborted {
+		t.Fatal("Wrong check: Deadline exceeded")
+	}
+	if !strings.Contains(err.Error(), "commitment") || !strings.Contains(err.Error(), "2") {
+		t.Errorf("Unclear timeout error: %s", err)
+	}
+
+	//An aborted round stays aborted
+	if err2 := round.CheckDeadline(); err2 == nil || err2.Error() != err.Error() {
+		t.Error("Aborted round error changed")
+	}
+	if _, err2 := round.Challenge(); err2 == nil {
+		t.Error("Challenge available in an aborted round")
+	}
+}
+
+func TestNewTagRound(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(1, rand.Intn(10)+1)
+
+	round, err := NewTagRound(&servers[0], context, nil, 0)
+	if err != nil || round == nil || round.Phase() != PhaseTag {
+		t.Error("Cannot start a tag round")
+	}
+	if round.timeout != DefaultRoundTimeout {
+		t.Error("Default timeout not used")
+	}
+	if _, _, err = round.ProcessRequest(nil); err == nil {
+		t.Error("Wrong check: Empty request")
+	}
+
+	round, err = NewTagRound(nil, context, nil, 0)
+	if err == nil || round != nil {
+		t.Error("Wrong check: Empty server")
+	}
+
+	//Non-interactive requests need no issued challenge
+	request, _ := clients[0].CreateNonInteractiveMessage(context)
+	round, _ = NewTagRound(&servers[0], context, nil, 0)
+	if _, _, err = round.ProcessRequest(request); err != nil {
+		t.Errorf("Cannot process a non-interactive request: %s", err)
+	}
+
+	//Interactive requests must answer the challenge issued for their commitments
+	T0, S, s, _ := clients[0].CreateRequest(context)
+	tclient, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	generator, _, _ := NewRound(&servers[0], context, *tclient, 0)
+	runChallengePhases(t, generator, servers, context)
+	issued, err := generator.Issued()
+	if err != nil {
+		t.Fatalf("Cannot get the issued challenge: %s", err)
+	}
+	c, r, _ := clients[0].GenerateProofResponses(context, s, issued.Challenge(), v, w)
+	answer := clients[0].AssembleMessage(context, &S, T0, issued.Challenge(), tclient, c, r)
+
+	round, _ = NewTagRound(&servers[0], context, nil, 0)
+	if _, _, err = round.ProcessRequest(answer); err == nil {
+		t.Error("Wrong check: Interactive request without an issued challenge")
+	}
+	round, _ = NewTagRound(&servers[0], context, issued, 0)
+	if _, _, err = round.ProcessRequest(request); err == nil {
+		t.Error("Wrong check: Non-interactive request for an issued challenge")
+	}
+
+	//The same challenge answered with commitments chosen afterwards
+	T0, S, s, _ = clients[0].CreateRequest(context)
+	tother, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	c, r, _ = clients[0].GenerateProofResponses(context, s, issued.Challenge(), v, w)
+	forged := clients[0].AssembleMessage(context, &S, T0, issued.Challenge(), tother, c, r)
+	round, _ = NewTagRound(&servers[0], context, issued, 0)
+	if _, _, err = round.ProcessRequest(forged); err == nil {
+		t.Error("Wrong check: Commitments chosen after the challenge")
+	}
+
+	round, _ = NewTagRound(&servers[0], context, issued, 0)
+	if _, _, err = round.ProcessRequest(answer); err != nil {
+		t.Errorf("Cannot process the answer to the issued challenge: %s", err)
+	}
+}
+
+func TestRoundPhase_String(t *testing.T) {
+	phases := []RoundPhase{PhaseCommitment, PhaseOpening, PhaseChallenge, PhaseTag, PhaseDone, PhaseAborted}
+	seen := map[string]bool{}
+	for _, phase := range phases {
+		if seen[phase.String()] {
+			t.Errorf("Duplicate name for phase %d", phase)
+		}
+		seen[phase.String()] = true
+	}
+}
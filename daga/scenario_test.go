@@ -15,12 +15,14 @@ func TestScenario(test *testing.T) {
 	c := 20
 	//Number of servers
 	s := 10
+	//Suite in which the protocol runs
+	suite := daga.Suite
 
 	//Generates clients
 	var X []abstract.Point
 	var clients []daga.Client
 	for i := 0; i < c; i++ {
-		client, err := daga.CreateClient(i, nil)
+		client, err := daga.CreateClient(suite, i, nil)
 		if err != nil {
 			fmt.Printf("Cannot create clients:\n%s\n", err)
 			return
@@ -33,7 +35,7 @@ func TestScenario(test *testing.T) {
 	var Y []abstract.Point
 	var servers []daga.Server
 	for j := 0; j < s; j++ {
-		server, err := daga.CreateServer(j, nil)
+		server, err := daga.CreateServer(suite, j, nil)
 		if err != nil {
 			fmt.Printf("Cannot create servers:\n%s\n", err)
 			return
@@ -51,7 +53,7 @@ func TestScenario(test *testing.T) {
 	//Generate client's generators
 	var H []abstract.Point
 	for i := 0; i < len(X); i++ {
-		temp, err := daga.GenerateClientGenerator(i, &R)
+		temp, err := daga.GenerateClientGenerator(suite, i, &R)
 		if err != nil {
 			fmt.Printf("Error in client's geenrators:\n%s\n", err)
 			return
@@ -59,7 +61,11 @@ func TestScenario(test *testing.T) {
 		H = append(H, temp)
 	}
 
-	serviceContext := daga.ContextEd25519{G: daga.Members{X: X, Y: Y}, R: R, H: H}
+	serviceContext, err := daga.NewContext(suite, daga.Members{X: X, Y: Y}, R, H)
+	if err != nil {
+		fmt.Printf("Cannot create the context\n%s\n", err)
+		return
+	}
 
 	//Simulate the transfer of the context from the service to the client
 	//Encoding
@@ -75,7 +81,7 @@ func TestScenario(test *testing.T) {
 	}
 	//Network transfer
 	//Decoding
-	var netContext daga.NetContextEd25519
+	var netContext daga.NetContext
 	err = json.Unmarshal(netdata, &netContext)
 	if err != nil || &netContext == nil {
 		fmt.Printf("Cannot json unmarshal the context\n%s\n", err)
@@ -116,7 +122,7 @@ func TestScenario(test *testing.T) {
 		fmt.Printf("Cannot json unmarshal the commitments t\n%s\n", err)
 		return
 	}
-	tserver, err := daga.NetDecodePoints(nett)
+	tserver, err := daga.NetDecodePoints(suite, nett)
 	if err != nil || tserver == nil {
 		fmt.Printf("Error in t decoding\n%s\n", err)
 		return
@@ -132,7 +138,7 @@ func TestScenario(test *testing.T) {
 	//Initialize both arrays
 	for num := 0; num < len(context.G.Y); num++ {
 		commits = append(commits, daga.Commitment{})
-		openings = append(openings, daga.Suite.Scalar().Zero())
+		openings = append(openings, suite.Scalar().Zero())
 	}
 
 	//The leader asks other servers to generates commitments by publishing its own signed commitment
@@ -163,7 +169,7 @@ func TestScenario(test *testing.T) {
 		fmt.Printf("Error when json unmarshal the commitment of the leader %d\n%s\n", j, err)
 		return
 	}
-	_, err = rcvCom.NetDecode()
+	_, err = rcvCom.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the commitment of the leader %d\n%s\n", j, err)
 		return
@@ -201,7 +207,7 @@ func TestScenario(test *testing.T) {
 			fmt.Printf("Error when json unmarshal the commitment of server %d\n%s\n", num, e)
 			return
 		}
-		_, e = rcvCom.NetDecode()
+		_, e = rcvCom.NetDecode(suite)
 		if e != nil {
 			fmt.Printf("Error when decoding the commitment of server %d\n%s\n", num, e)
 			return
@@ -235,7 +241,7 @@ func TestScenario(test *testing.T) {
 		fmt.Printf("Error when json unmarshal the opening of the leader %d\n", j)
 		return
 	}
-	_, err = rcvOpen.NetDecode()
+	_, err = rcvOpen.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the opening of the leader %d\n", j)
 		return
@@ -266,7 +272,7 @@ func TestScenario(test *testing.T) {
 			return
 		}
 		//No need to check that this valkue is the same as the one before transfer, this is done in the test of the network functions in daga
-		_, e = rcvOpen.NetDecode()
+		_, e = rcvOpen.NetDecode(suite)
 		if e != nil {
 			fmt.Printf("Error when decoding the opening of server %d\n%s\n", num, e)
 			return
@@ -274,7 +280,7 @@ func TestScenario(test *testing.T) {
 	}
 
 	//After receiving all the openings, server j veerifies them and initializes the challenge structure
-	challenge, err := daga.InitializeChallenge(context, commits, openings)
+	challenge, err := daga.InitializeChallenge(context, *t, commits, openings)
 	if err != nil {
 		fmt.Printf("Error when initializing the challenge\n%s\n", err)
 		return
@@ -308,7 +314,7 @@ func TestScenario(test *testing.T) {
 			fmt.Printf("Error when json unmarshal the challenge at server %d\n%s\n", index, e)
 			return
 		}
-		serverChallenge, e := rcvChall.NetDecode()
+		serverChallenge, e := rcvChall.NetDecode(suite)
 		if e != nil {
 			fmt.Printf("Error when decoding the challenge at server %d\n%s\n", index, e)
 			return
@@ -338,7 +344,7 @@ func TestScenario(test *testing.T) {
 		fmt.Printf("Error when json unmarshal the challenge back at the leader %d\n%s\n", j, err)
 		return
 	}
-	finalChallenge, err := rcvfinalChall.NetDecode()
+	finalChallenge, err := rcvfinalChall.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the challenge at the leader %d\n%s\n", j, err)
 		return
@@ -371,7 +377,7 @@ func TestScenario(test *testing.T) {
 		fmt.Printf("Error when json unmarshal the challenge at client %d\n%s\n", i, err)
 		return
 	}
-	_, err = rcvclientChall.NetDecode()
+	_, err = rcvclientChall.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the challenge at client %d\n%s\n", i, err)
 		return
@@ -459,7 +465,7 @@ func TestScenario(test *testing.T) {
 		return
 	} else {
 		//A Null value means that the authentication is rejected
-		if Tf.Equal(daga.Suite.Point().Null()) {
+		if Tf.Equal(suite.Point().Null()) {
 			fmt.Printf("Authentication rejected\n")
 			return
 		}
@@ -1,21 +1,30 @@
 package daga
 
 import (
+	"crypto/sha256"
 	"crypto/sha512"
 	"fmt"
 	"io"
+	"runtime"
 	"strconv"
 
 	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/nist"
 	"gopkg.in/dedis/crypto.v0/random"
 )
 
-/*Server is used to store the server's private key and index.
+/*Server is used to store the server's private key, index and suite.
 All the server's methods are attached to it */
 type Server struct {
+	suite   abstract.Suite
 	private abstract.Scalar
 	index   int
 	r       abstract.Scalar //Per round secret
+	round   uint64          //Round of the context r was generated for
+	workers int             //Goroutines verifying the commitments of a client's proof
+
+	generators *GeneratorCache //Generators of the clients for the contexts without H
+	contexts   *ContextCache   //Contexts already found valid
 }
 
 /*Commitment stores the index of the server, the commitment value and the signature for the commitment*/
@@ -33,16 +42,58 @@ type serverSignature struct {
 /*ChallengeCheck stores all the information passed along the servers to check and sign the challenge*/
 type ChallengeCheck struct {
 	cs       abstract.Scalar
-	sigs     []serverSignature //Signatures for cs only
+	digest   proofCommitsDigest //Digest of the client's commitments t the challenge is generated for
+	sigs     []serverSignature  //Signatures for cs and the digest only
 	commits  []Commitment
 	openings []abstract.Scalar
 }
 
 /*Challenge stores the collectively generated challenge and the signatures of the servers
-This is the structure sent to the client*/
+This is the structure sent to the client, who adds the signatures to its proof*/
 type Challenge struct {
-	cs   abstract.Scalar
-	sigs []serverSignature
+	cs     abstract.Scalar
+	digest proofCommitsDigest
+	sigs   []serverSignature
+}
+
+/*proofCommitsDigest is the hash of the commitments t of a client's proof
+The servers sign it along with the challenge, so that the challenge can only be answered with the commitments it was generated for*/
+type proofCommitsDigest [sha256.Size]byte
+
+/*digestProofCommitments hashes the commitments t of a client's proof*/
+func digestProofCommitments(t []abstract.Point) (d proofCommitsDigest, err error) {
+	if len(t) == 0 {
+		return d, fmt.Errorf("Empty commitments")
+	}
+	hasher := sha256.New()
+	for i, p := range t {
+		if p == nil {
+			return d, fmt.Errorf("Empty commitment at index %d", i)
+		}
+		if _, err = p.MarshalTo(hasher); err != nil {
+			return d, fmt.Errorf("Error in commitment %d: %s", i, err)
+		}
+	}
+	copy(d[:], hasher.Sum(nil))
+	return d, nil
+}
+
+/*decodeProofCommitsDigest converts the encoding of a digest back into a proofCommitsDigest*/
+func decodeProofCommitsDigest(data []byte) (d proofCommitsDigest, err error) {
+	if len(data) != len(d) {
+		return d, fmt.Errorf("Wrong digest length: got %d expected %d", len(data), len(d))
+	}
+	copy(d[:], data)
+	return d, nil
+}
+
+/*challengeMessage returns the data signed by the servers for the challenge cs generated for the commitments of the digest*/
+func challengeMessage(cs abstract.Scalar, digest proofCommitsDigest) ([]byte, error) {
+	data, err := cs.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("Error in challenge conversion: %s", err)
+	}
+	return append(data, digest[:]...), nil
 }
 
 /*ServerMessage stores the message sent by a server to one or many others*/
@@ -64,32 +115,59 @@ type serverProof struct {
 	r2 abstract.Scalar
 }
 
-//CreateServer is used to initialize a new server with a given index
+//CreateServer is used to initialize a new server with a given index in the given suite
 //If no private key is given, a random one is chosen
-func CreateServer(i int, s abstract.Scalar) (server Server, err error) {
-	if i < 0 {
+func CreateServer(suite abstract.Suite, i int, s abstract.Scalar) (server Server, err error) {
+	if suite == nil || i < 0 {
 		return Server{}, fmt.Errorf("Invalid parameters")
 	}
 	if s == nil {
 		s = suite.Scalar().Pick(random.Stream)
 	}
-	return Server{index: i, private: s, r: nil}, nil
+	generators, err := NewGeneratorCache(suite)
+	if err != nil {
+		return Server{}, err
+	}
+	contexts, err := NewContextCache(DefaultContextCacheSize)
+	if err != nil {
+		return Server{}, err
+	}
+	return Server{suite: suite, index: i, private: s, r: nil, workers: runtime.NumCPU(), generators: generators, contexts: contexts}, nil
 }
 
 //GetPublicKey returns the public key associated with a server
 func (server *Server) GetPublicKey() abstract.Point {
-	return suite.Point().Mul(nil, server.private)
+	return server.suite.Point().Mul(nil, server.private)
+}
+
+//GetIndex returns the index of the server in the context
+func (server *Server) GetIndex() int {
+	return server.index
+}
+
+//SetVerificationWorkers sets the number of goroutines sharing the verification of the commitments of a client's proof
+//It defaults to the number of CPUs, a value of 1 or less verifies them sequentially
+func (server *Server) SetVerificationWorkers(workers int) {
+	server.workers = workers
+}
+
+//Suite returns the suite in which the server runs the protocol
+func (server *Server) Suite() abstract.Suite {
+	return server.suite
 }
 
 /*GenerateCommitment creates the commitment and its opening for the distributed challenge generation*/
-func (server *Server) GenerateCommitment(context *ContextEd25519) (commit *Commitment, opening abstract.Scalar, err error) {
-	opening = suite.Scalar().Pick(random.Stream)
-	com := suite.Point().Mul(nil, opening)
+func (server *Server) GenerateCommitment(context *Context) (commit *Commitment, opening abstract.Scalar, err error) {
+	if err = checkSuite(server.suite, context); err != nil {
+		return nil, nil, err
+	}
+	opening = server.suite.Scalar().Pick(random.Stream)
+	com := server.suite.Point().Mul(nil, opening)
 	msg, err := com.MarshalBinary()
 	if err != nil {
 		return nil, nil, fmt.Errorf("Error in conversion of commit: %s", err)
 	}
-	sig, err := ECDSASign(server.private, msg)
+	sig, err := ECDSASign(server.suite, server.private, msg)
 	if err != nil {
 		return nil, nil, fmt.Errorf("Error in commit signature generation: %s", err)
 	}
@@ -97,19 +175,28 @@ func (server *Server) GenerateCommitment(context *ContextEd25519) (commit *Commi
 }
 
 /*VerifyCommitmentSignature verifies that all the commitments are valid and correctly signed*/
-func VerifyCommitmentSignature(context *ContextEd25519, commits []Commitment) (err error) {
+func VerifyCommitmentSignature(context *Context, commits []Commitment) (err error) {
+	if context == nil {
+		return fmt.Errorf("Empty context")
+	}
+	if len(commits) != len(context.G.Y) {
+		return fmt.Errorf("Incorrect number of commits: got %d expected %d", len(commits), len(context.G.Y))
+	}
 	for i, com := range commits {
 		if i != com.sig.index {
 			return fmt.Errorf("Wrong index: got %d expected %d", com.sig.index, i)
 		}
-		//TODO: How to check that a point is on the curve?
+		//The point is on the curve since it was decoded, it must also be in the prime-order subgroup
+		if e := checkPoint(context.Suite(), com.commit, false); e != nil {
+			return fmt.Errorf("Invalid commit %d: %s", i, e)
+		}
 
 		//Convert the commitment and verify the signature
 		msg, e := com.commit.MarshalBinary()
 		if e != nil {
 			return fmt.Errorf("Error in conversion of commit for verification: %s", err)
 		}
-		err = ECDSAVerify(context.G.Y[i], msg, com.sig.sig)
+		err = ECDSAVerify(context.Suite(), context.G.Y[i], msg, com.sig.sig)
 		if err != nil {
 			return err
 		}
@@ -118,7 +205,7 @@ func VerifyCommitmentSignature(context *ContextEd25519, commits []Commitment) (e
 }
 
 /*CheckOpenings verifies each opening and returns the computed challenge*/
-func CheckOpenings(context *ContextEd25519, commits []Commitment, openings []abstract.Scalar) (cs abstract.Scalar, err error) {
+func CheckOpenings(context *Context, commits []Commitment, openings []abstract.Scalar) (cs abstract.Scalar, err error) {
 	if context == nil {
 		return nil, fmt.Errorf("Empty context")
 	}
@@ -129,6 +216,7 @@ func CheckOpenings(context *ContextEd25519, commits []Commitment, openings []abs
 		return nil, fmt.Errorf("Incorrect number of openings: got %d expected %d", len(openings), len(context.G.Y))
 	}
 
+	suite := context.Suite()
 	cs = suite.Scalar().Zero()
 	for i := 0; i < len(commits); i++ {
 		c := suite.Point().Mul(nil, openings[i])
@@ -140,37 +228,47 @@ func CheckOpenings(context *ContextEd25519, commits []Commitment, openings []abs
 	return cs, nil
 }
 
-/*InitializeChallenge creates a Challenge structure from a challenge value
+/*InitializeChallenge creates a Challenge structure from a challenge value, for the commitments t of the client
 It checks the openings before doing so*/
-func InitializeChallenge(context *ContextEd25519, commits []Commitment, openings []abstract.Scalar) (*ChallengeCheck, error) {
+func InitializeChallenge(context *Context, t []abstract.Point, commits []Commitment, openings []abstract.Scalar) (*ChallengeCheck, error) {
 	if context == nil || commits == nil || openings == nil || len(commits) == 0 || len(openings) == 0 || len(commits) != len(openings) {
 		return nil, fmt.Errorf("Invalid inputs")
 	}
+	if len(t) != 3*len(context.G.X) {
+		return nil, fmt.Errorf("Wrong number of commitments: got %d expected %d", len(t), 3*len(context.G.X))
+	}
+	digest, err := digestProofCommitments(t)
+	if err != nil {
+		return nil, err
+	}
 	cs, err := CheckOpenings(context, commits, openings)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ChallengeCheck{cs: cs, commits: commits, openings: openings, sigs: nil}, nil
+	return &ChallengeCheck{cs: cs, digest: digest, commits: commits, openings: openings, sigs: nil}, nil
 }
 
 /*CheckUpdateChallenge verifies that all the previous servers computed the same challenges and that their signatures are valid
 It also adds the server's signature to the list if the round-robin is not completed (the challenge has not yet made it back to the leader)
 It must be used after the leader ran InitializeChallenge and after each server received the challenge from the previous server*/
-func (server *Server) CheckUpdateChallenge(context *ContextEd25519, challenge *ChallengeCheck) error {
+func (server *Server) CheckUpdateChallenge(context *Context, challenge *ChallengeCheck) error {
 	//Check the signatures and check for duplicates
-	msg, e := challenge.cs.MarshalBinary()
+	msg, e := challengeMessage(challenge.cs, challenge.digest)
 	if e != nil {
-		return fmt.Errorf("Error in challenge conversion: %s", e)
+		return e
 	}
 	encountered := map[int]bool{}
 	for _, sig := range challenge.sigs {
+		if sig.index < 0 || sig.index >= len(context.G.Y) {
+			return fmt.Errorf("Wrong index: %d", sig.index)
+		}
 		if encountered[sig.index] == true {
 			return fmt.Errorf("Duplicate signature")
 		}
 		encountered[sig.index] = true
 
-		e = ECDSAVerify(context.G.Y[sig.index], msg, sig.sig)
+		e = ECDSAVerify(server.suite, context.G.Y[sig.index], msg, sig.sig)
 		if e != nil {
 			return fmt.Errorf("%s", e)
 		}
@@ -195,7 +293,7 @@ func (server *Server) CheckUpdateChallenge(context *ContextEd25519, challenge *C
 	if len(challenge.sigs) == len(context.G.Y) {
 		return nil
 	}
-	sig, e := ECDSASign(server.private, msg)
+	sig, e := ECDSASign(server.suite, server.private, msg)
 	if e != nil {
 		return e
 	}
@@ -206,7 +304,7 @@ func (server *Server) CheckUpdateChallenge(context *ContextEd25519, challenge *C
 
 /*FinalizeChallenge is used to convert the data passed between the servers into the challenge sent to the client
 It must be used after the leader got the message back and ran CheckUpdateChallenge*/
-func FinalizeChallenge(context *ContextEd25519, challenge *ChallengeCheck) (*Challenge, error) {
+func FinalizeChallenge(context *Context, challenge *ChallengeCheck) (*Challenge, error) {
 	if context == nil || challenge == nil {
 		return nil, fmt.Errorf("Invalid inputs")
 	}
@@ -214,7 +312,40 @@ func FinalizeChallenge(context *ContextEd25519, challenge *ChallengeCheck) (*Cha
 		return nil, fmt.Errorf("Signature count does not match: got %d expected %d", len(challenge.sigs), len(context.G.Y))
 	}
 
-	return &Challenge{cs: challenge.cs, sigs: challenge.sigs}, nil
+	return &Challenge{cs: challenge.cs, digest: challenge.digest, sigs: challenge.sigs}, nil
+}
+
+/*verifyChallenge checks that the challenge of an interactive proof was signed by all the servers of the context for the commitments t of the proof
+Without it, a client could choose the challenge and simulate the proof without any private key*/
+func verifyChallenge(context *Context, proof *ClientProof) error {
+	if proof.cs == nil {
+		return fmt.Errorf("Empty challenge")
+	}
+	if len(proof.sigs) != len(context.G.Y) {
+		return fmt.Errorf("Signature count does not match: got %d expected %d", len(proof.sigs), len(context.G.Y))
+	}
+	digest, err := digestProofCommitments(proof.t)
+	if err != nil {
+		return err
+	}
+	msg, err := challengeMessage(proof.cs, digest)
+	if err != nil {
+		return err
+	}
+	encountered := make(map[int]bool)
+	for _, sig := range proof.sigs {
+		if sig.index < 0 || sig.index >= len(context.G.Y) {
+			return fmt.Errorf("Wrong index: %d", sig.index)
+		}
+		if encountered[sig.index] {
+			return fmt.Errorf("Duplicate signature")
+		}
+		encountered[sig.index] = true
+		if err = ECDSAVerify(context.Suite(), context.G.Y[sig.index], msg, sig.sig); err != nil {
+			return fmt.Errorf("Error in signature of server %d: %s", sig.index, err)
+		}
+	}
+	return nil
 }
 
 //InitializeServerMessage creates a ServerMessage from a ClientMessage to ease further processing
@@ -226,15 +357,44 @@ func (server *Server) InitializeServerMessage(request *ClientMessage) (msg *Serv
 }
 
 /*ServerProtocol runs the server part of DAGA upon receiving a message from either a server or a client*/
-func (server *Server) ServerProtocol(context *ContextEd25519, msg *ServerMessage) error {
+func (server *Server) ServerProtocol(context *Context, msg *ServerMessage) error {
+	suite := server.suite
+	if err := checkSuite(suite, context); err != nil {
+		return err
+	}
+	if err := server.contexts.validate(context, nil, server.generators); err != nil {
+		return err
+	}
+	if err := server.checkRound(context); err != nil {
+		return err
+	}
+	//Requests for another context are rejected before any verification
+	id, err := context.ID()
+	if err != nil {
+		return fmt.Errorf("Error in context: %s", err)
+	}
+	if msg.request.contextID != id {
+		return fmt.Errorf("Unknown context: %s", msg.request.contextID)
+	}
+
 	//Step 1
 	//Verify that the message is correctly formed
+	if err := checkSuite(suite, &msg.request.context); err != nil {
+		return fmt.Errorf("Invalid client's request: %s", err)
+	}
+	//Requests built against the context of another round are rejected
+	if err := server.checkRound(&msg.request.context); err != nil {
+		return fmt.Errorf("Invalid client's request: %s", err)
+	}
 	if !ValidateClientMessage(&msg.request) {
 		return fmt.Errorf("Invalid client's request")
 	}
 	if len(msg.indexes) != len(msg.proofs) || len(msg.proofs) != len(msg.tags) || len(msg.tags) != len(msg.sigs) {
 		return fmt.Errorf("Invalid message")
 	}
+	if err := checkServerMessagePoints(suite, msg); err != nil {
+		return fmt.Errorf("Invalid message: %s", err)
+	}
 
 	//Checks that not all servers already did the protocol
 	if len(msg.indexes) >= len(context.G.Y) {
@@ -262,7 +422,11 @@ func (server *Server) ServerProtocol(context *ContextEd25519, msg *ServerMessage
 
 			data = append(data, []byte(strconv.Itoa(msg.indexes[i]))...)
 
-			err = ECDSAVerify(context.G.Y[msg.sigs[i].index], data, msg.sigs[i].sig)
+			//Each server signs its own step
+			if msg.indexes[i] < 0 || msg.indexes[i] >= len(context.G.Y) || msg.sigs[i].index != msg.indexes[i] {
+				return fmt.Errorf("Wrong index in step %d", i)
+			}
+			err = ECDSAVerify(suite, context.G.Y[msg.sigs[i].index], data, msg.sigs[i].sig)
 			if err != nil {
 				return fmt.Errorf("Error in signature: "+strconv.Itoa(i)+"\n%s", err)
 			}
@@ -270,23 +434,23 @@ func (server *Server) ServerProtocol(context *ContextEd25519, msg *ServerMessage
 	}
 
 	// Check the client proof
-	if !verifyClientProof(msg.request) {
+	//A non-interactive proof does not rely on a challenge generated by the servers
+	var valid bool
+	if msg.request.proof.nonInteractive {
+		valid = verifyNonInteractiveClientProof(msg.request, server.workers, server.generators)
+	} else {
+		if err := verifyChallenge(context, &msg.request.proof); err != nil {
+			return fmt.Errorf("Invalid challenge: %s", err)
+		}
+		valid = verifyClientProof(msg.request, server.workers, server.generators)
+	}
+	if !valid {
 		return fmt.Errorf("Invalid client's proof")
 	}
 
 	//Check all the proofs
-	if len(msg.proofs) != 0 {
-		for i, p := range msg.proofs {
-			var valid bool
-			if p.r2 == nil {
-				valid = verifyMisbehavingProof(context, i, &p, msg.request.sArray[0])
-			} else {
-				valid = verifyServerProof(context, i, msg)
-			}
-			if !valid {
-				return fmt.Errorf("Invalid server proof")
-			}
-		}
+	if i := verifyServerProofs(context, msg); i >= 0 {
+		return fmt.Errorf("Invalid server proof: %d", i)
 	}
 
 	//Step 2: Verify the correct behaviour of the client
@@ -331,7 +495,7 @@ func (server *Server) ServerProtocol(context *ContextEd25519, msg *ServerMessage
 
 	data = append(data, []byte(strconv.Itoa(server.index))...)
 
-	sign, e := ECDSASign(server.private, data)
+	sign, e := ECDSASign(suite, server.private, data)
 	if e != nil {
 		return fmt.Errorf("Error in own signature: %s", e)
 	}
@@ -348,7 +512,7 @@ func (server *Server) ServerProtocol(context *ContextEd25519, msg *ServerMessage
 }
 
 /*generateServerProof creates the server proof for its computations*/
-func (server *Server) generateServerProof(context *ContextEd25519, s abstract.Scalar, T abstract.Point, msg *ServerMessage) (proof *serverProof, err error) {
+func (server *Server) generateServerProof(context *Context, s abstract.Scalar, T abstract.Point, msg *ServerMessage) (proof *serverProof, err error) {
 	//Input validation
 	if context == nil {
 		return nil, fmt.Errorf("Empty context")
@@ -363,6 +527,8 @@ func (server *Server) generateServerProof(context *ContextEd25519, s abstract.Sc
 		return nil, fmt.Errorf("Empty server message")
 	}
 
+	suite := server.suite
+
 	//Step 1
 	v1 := suite.Scalar().Pick(random.Stream)
 	v2 := suite.Scalar().Pick(random.Stream)
@@ -389,23 +555,7 @@ func (server *Server) generateServerProof(context *ContextEd25519, s abstract.Sc
 	} else {
 		Tprevious = msg.tags[len(msg.tags)-1]
 	}
-	//Generating the hash
-	hasher := sha512.New()
-	var writer io.Writer = hasher
-	//hash guarantees that no error are returned on write, so we do not check for error below
-	Tprevious.MarshalTo(writer)
-	T.MarshalTo(writer)
-	context.R[server.index].MarshalTo(writer)
-	suite.Point().Mul(nil, suite.Scalar().One()).MarshalTo(writer)
-	msg.request.sArray[server.index+2].MarshalTo(writer)
-	msg.request.sArray[server.index+1].MarshalTo(writer)
-	t1.MarshalTo(writer)
-	t2.MarshalTo(writer)
-	t3.MarshalTo(writer)
-	challenge := hasher.Sum(nil)
-
-	rand := suite.Cipher(challenge)
-	c := suite.Scalar().Pick(rand)
+	c := serverProofChallenge(context, server.index, Tprevious, T, msg, t1, t2, t3)
 	//Step 3
 	d := suite.Scalar().Mul(c, server.r)
 	r1 := suite.Scalar().Sub(v1, d)
@@ -425,13 +575,13 @@ func (server *Server) generateServerProof(context *ContextEd25519, s abstract.Sc
 }
 
 /*verifyServerProof verifies a server proof*/
-func verifyServerProof(context *ContextEd25519, i int, msg *ServerMessage) bool {
+func verifyServerProof(context *Context, i int, msg *ServerMessage) bool {
 	//Input checks
 	if context == nil || msg == nil {
 		return false
 	}
 
-	if i >= len(msg.proofs) || i < 0 {
+	if i >= len(msg.proofs) || i < 0 || i >= len(msg.tags) || i >= len(msg.indexes) {
 		return false
 	}
 
@@ -441,6 +591,10 @@ func verifyServerProof(context *ContextEd25519, i int, msg *ServerMessage) bool
 	}
 
 	index := msg.indexes[i]
+	if index < 0 || index >= len(context.R) || index+2 >= len(msg.request.sArray) {
+		return false
+	}
+	suite := context.Suite()
 
 	//Step 1
 	var a abstract.Point
@@ -460,6 +614,10 @@ func verifyServerProof(context *ContextEd25519, i int, msg *ServerMessage) bool
 	f := suite.Point().Mul(msg.request.sArray[index+1], msg.proofs[i].r2)
 	g := suite.Point().Mul(msg.request.sArray[index+2], msg.proofs[i].c)
 	t3 := suite.Point().Add(f, g)
+	//The commitments carried by the proof are the ones checked by batchVerifyServerProofs
+	if !t1.Equal(msg.proofs[i].t1) || !t2.Equal(msg.proofs[i].t2) || !t3.Equal(msg.proofs[i].t3) {
+		return false
+	}
 
 	//Step 2
 	var Tprevious abstract.Point
@@ -468,10 +626,58 @@ func verifyServerProof(context *ContextEd25519, i int, msg *ServerMessage) bool
 	} else {
 		Tprevious = msg.tags[i-1]
 	}
+	c := serverProofChallenge(context, index, Tprevious, msg.tags[i], msg, t1, t2, t3)
+
+	if !c.Equal(msg.proofs[i].c) {
+		return false
+	}
+
+	return true
+}
+
+/*checkServerMessagePoints checks every point of the message and of its request with checkPoint, the tags being allowed to be the neutral element
+It protects the messages built in memory, the decoders already rejecting the invalid points*/
+func checkServerMessagePoints(suite abstract.Suite, msg *ServerMessage) error {
+	if err := checkPoint(suite, msg.request.t0, false); err != nil {
+		return fmt.Errorf("T0: %s", err)
+	}
+	for i, p := range msg.request.sArray {
+		if err := checkPoint(suite, p, false); err != nil {
+			return fmt.Errorf("S[%d]: %s", i, err)
+		}
+	}
+	for i, p := range msg.request.proof.t {
+		if err := checkPoint(suite, p, false); err != nil {
+			return fmt.Errorf("t[%d]: %s", i, err)
+		}
+	}
+	for i, p := range msg.tags {
+		if err := checkPoint(suite, p, true); err != nil {
+			return fmt.Errorf("tag %d: %s", i, err)
+		}
+	}
+	for i, proof := range msg.proofs {
+		//t1 follows the previous tag, it is the neutral element once a client was found misbehaving
+		if err := checkPoint(suite, proof.t1, true); err != nil {
+			return fmt.Errorf("proof %d: %s", i, err)
+		}
+		for _, p := range []abstract.Point{proof.t2, proof.t3} {
+			if err := checkPoint(suite, p, false); err != nil {
+				return fmt.Errorf("proof %d: %s", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+/*serverProofChallenge derives the challenge of the proof of the server index from the tags before and after its step and the commitments t1, t2, t3*/
+func serverProofChallenge(context *Context, index int, Tprevious, T abstract.Point, msg *ServerMessage, t1, t2, t3 abstract.Point) abstract.Scalar {
+	suite := context.Suite()
 	hasher := sha512.New()
 	var writer io.Writer = hasher
+	//hash guarantees that no error are returned on write, so we do not check for error below
 	Tprevious.MarshalTo(writer)
-	msg.tags[i].MarshalTo(writer)
+	T.MarshalTo(writer)
 	context.R[index].MarshalTo(writer)
 	suite.Point().Mul(nil, suite.Scalar().One()).MarshalTo(writer)
 	msg.request.sArray[index+2].MarshalTo(writer)
@@ -479,20 +685,11 @@ func verifyServerProof(context *ContextEd25519, i int, msg *ServerMessage) bool
 	t1.MarshalTo(writer)
 	t2.MarshalTo(writer)
 	t3.MarshalTo(writer)
-	challenge := hasher.Sum(nil)
-
-	rand := suite.Cipher(challenge)
-	c := suite.Scalar().Pick(rand)
-
-	if !c.Equal(msg.proofs[i].c) {
-		return false
-	}
-
-	return true
+	return suite.Scalar().Pick(suite.Cipher(hasher.Sum(nil)))
 }
 
 /*generateMisbehavingProof creates the proof of a misbehaving client*/
-func (server *Server) generateMisbehavingProof(context *ContextEd25519, Z abstract.Point) (proof *serverProof, err error) {
+func (server *Server) generateMisbehavingProof(context *Context, Z abstract.Point) (proof *serverProof, err error) {
 	//Input checks
 	if context == nil {
 		return nil, fmt.Errorf("Empty context")
@@ -501,6 +698,7 @@ func (server *Server) generateMisbehavingProof(context *ContextEd25519, Z abstra
 		return nil, fmt.Errorf("Empty Z")
 	}
 
+	suite := server.suite
 	Zs := suite.Point().Mul(Z, server.private)
 
 	//Step 1
@@ -538,7 +736,7 @@ func (server *Server) generateMisbehavingProof(context *ContextEd25519, Z abstra
 }
 
 /*verifyMisbehavingProof verifies a proof of a misbehaving client*/
-func verifyMisbehavingProof(context *ContextEd25519, i int, proof *serverProof, Z abstract.Point) bool {
+func verifyMisbehavingProof(context *Context, i int, proof *serverProof, Z abstract.Point) bool {
 	//Input checks
 	if context == nil || proof == nil || Z == nil {
 		return false
@@ -558,6 +756,8 @@ func verifyMisbehavingProof(context *ContextEd25519, i int, proof *serverProof,
 		return false
 	}
 
+	suite := context.Suite()
+
 	//Step 1
 	a := suite.Point().Mul(Z, proof.r1)       //r1 = r
 	b := suite.Point().Mul(proof.t3, proof.c) //t3 = Zs
@@ -591,8 +791,89 @@ func verifyMisbehavingProof(context *ContextEd25519, i int, proof *serverProof,
 /*GenerateNewRoundSecret creates a new secret for the server, erasing the previous one.
 It returns the commitment to that secret to be included in the context*/
 func (server *Server) GenerateNewRoundSecret() (R abstract.Point) {
-	server.r = suite.Scalar().Pick(random.Stream)
-	return suite.Point().Mul(nil, server.r)
+	server.eraseRoundSecret()
+	server.r = server.suite.Scalar().Pick(random.Stream)
+	return server.suite.Point().Mul(nil, server.r)
+}
+
+/*eraseRoundSecret overwrites the per-round secret so that the tags of past rounds cannot be linked after a compromise
+The scalar is zeroed in place, which also erases it from the copies of the server*/
+func (server *Server) eraseRoundSecret() {
+	if server.r != nil {
+		eraseScalar(server.r)
+		server.r = nil
+	}
+}
+
+/*eraseScalar overwrites the scalar in place
+Zero only sets the length of the big.Int of a nist.Int to 0, so its words are cleared first to remove the value from the backing array*/
+func eraseScalar(s abstract.Scalar) {
+	if i, ok := s.(*nist.Int); ok {
+		words := i.V.Bits()
+		for k := range words {
+			words[k] = 0
+		}
+	}
+	s.Zero()
+}
+
+/*RotateRoundSecret moves the server to the round following the one of the context, erasing its previous secret.
+It returns the commitment to the new secret to be given to NextRound
+The new secret is only kept in memory, see RotateKey to save it in the key file of the server*/
+func (server *Server) RotateRoundSecret(context *Context) (R abstract.Point, err error) {
+	if err = server.checkRotation(context); err != nil {
+		return nil, err
+	}
+	R = server.GenerateNewRoundSecret()
+	server.nextRound(context)
+	return R, nil
+}
+
+/*checkRotation verifies that the server can move to the round following the one of the context*/
+func (server *Server) checkRotation(context *Context) error {
+	if err := checkSuite(server.suite, context); err != nil {
+		return err
+	}
+	if context.round != server.round {
+		return fmt.Errorf("Wrong round: context at round %d, server at round %d", context.round, server.round)
+	}
+	return nil
+}
+
+/*nextRound moves the server to the round following the one of the context, once the secret of that round is set*/
+func (server *Server) nextRound(context *Context) {
+	server.round = context.round + 1
+	//The generators of the previous round are not needed anymore
+	if server.generators != nil {
+		server.generators.Forget(context.R)
+	}
+}
+
+/*checkRound verifies that the context is the one of the current round of the server,
+i.e. that it holds the commitment to the current per-round secret*/
+func (server *Server) checkRound(context *Context) error {
+	if context.round != server.round {
+		return fmt.Errorf("Wrong round: %d instead of %d", context.round, server.round)
+	}
+	if server.r == nil || server.index < 0 || server.index >= len(context.R) || !context.R[server.index].Equal(server.suite.Point().Mul(nil, server.r)) {
+		return fmt.Errorf("Wrong round: commitment %d does not match the round secret", server.index)
+	}
+	return nil
+}
+
+/*CheckContext verifies that the server can run the protocol in the context:
+its public key is at its index in G.Y and the context is the one of its current round*/
+func (server *Server) CheckContext(context *Context) error {
+	if context == nil {
+		return fmt.Errorf("Empty context")
+	}
+	if err := checkSuite(server.suite, context); err != nil {
+		return err
+	}
+	if server.index < 0 || server.index >= len(context.G.Y) || !context.G.Y[server.index].Equal(server.GetPublicKey()) {
+		return fmt.Errorf("Server %d not in the context", server.index)
+	}
+	return server.checkRound(context)
 }
 
 /*ToBytes is a helper function used to convert a ServerProof into []byte to be used in signatures*/
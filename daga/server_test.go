@@ -4,42 +4,121 @@ import (
 	"crypto/sha512"
 	"io"
 	"math/rand"
+	"runtime"
 	"strconv"
 	"testing"
 
 	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/nist"
 	"gopkg.in/dedis/crypto.v0/random"
 )
 
+/*testProofCommitments returns random commitments t of a client's proof, for the challenges generated without a client*/
+func testProofCommitments(context *Context) []abstract.Point {
+	t := make([]abstract.Point, 3*len(context.G.X))
+	for i := range t {
+		t[i] = suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream))
+	}
+	return t
+}
+
+/*signTestChallenge makes every server sign the challenge cs for the commitments t, without running the challenge generation*/
+func signTestChallenge(servers []Server, cs abstract.Scalar, t []abstract.Point) Challenge {
+	digest, _ := digestProofCommitments(t)
+	msg, _ := challengeMessage(cs, digest)
+	challenge := Challenge{cs: cs, digest: digest}
+	for _, server := range servers {
+		sig, _ := ECDSASign(suite, server.private, msg)
+		challenge.sigs = append(challenge.sigs, serverSignature{index: server.index, sig: sig})
+	}
+	return challenge
+}
+
+/*simulateClientProof simulates a client's proof for the challenge cs without any private key, by choosing the commitments t last*/
+func simulateClientProof(context *Context, T0 abstract.Point, S []abstract.Point, cs abstract.Scalar) ClientProof {
+	n := len(context.G.X)
+	Sm := S[len(S)-1]
+	proof := ClientProof{cs: cs, c: make([]abstract.Scalar, n), r: make([]abstract.Scalar, 2*n), t: make([]abstract.Point, 3*n)}
+	sum := suite.Scalar().Zero()
+	for i := 0; i < n; i++ {
+		if i < n-1 {
+			proof.c[i] = suite.Scalar().Pick(random.Stream)
+			sum = suite.Scalar().Add(sum, proof.c[i])
+		} else {
+			proof.c[i] = suite.Scalar().Sub(cs, sum)
+		}
+		proof.r[2*i] = suite.Scalar().Pick(random.Stream)
+		proof.r[2*i+1] = suite.Scalar().Pick(random.Stream)
+		H, _ := context.ClientGenerator(i, nil)
+		proof.t[3*i] = suite.Point().Add(suite.Point().Mul(context.G.X[i], proof.c[i]), suite.Point().Mul(nil, proof.r[2*i]))
+		proof.t[3*i+1] = suite.Point().Add(suite.Point().Mul(Sm, proof.c[i]), suite.Point().Mul(nil, proof.r[2*i+1]))
+		proof.t[3*i+2] = suite.Point().Add(suite.Point().Mul(T0, proof.c[i]), suite.Point().Mul(H, proof.r[2*i+1]))
+	}
+	return proof
+}
+
 func TestCreateServer(t *testing.T) {
 	//Normal execution
 	i := rand.Int()
 	s := suite.Scalar().Pick(random.Stream)
-	server, err := CreateServer(i, s)
+	server, err := CreateServer(suite, i, s)
 	if err != nil || server.index != i || !server.private.Equal(s) {
 		t.Error("Cannot initialize a new server with a given private key")
 	}
 
-	server, err = CreateServer(i, nil)
+	server, err = CreateServer(suite, i, nil)
 	if err != nil {
 		t.Error("Cannot create a new server without a private key")
 	}
 
 	//Invalid input
-	server, err = CreateServer(-2, s)
+	server, err = CreateServer(suite, -2, s)
 	if err == nil {
 		t.Error("Wrong check: Invalid index")
 	}
+	server, err = CreateServer(nil, i, s)
+	if err == nil {
+		t.Error("Wrong check: Empty suite")
+	}
 }
 
 func TestGetPublicKey_Server(t *testing.T) {
-	server, _ := CreateServer(0, suite.Scalar().Pick(random.Stream))
+	server, _ := CreateServer(suite, 0, suite.Scalar().Pick(random.Stream))
 	P := server.GetPublicKey()
 	if P == nil {
 		t.Error("Cannot get public key")
 	}
 }
 
+func TestGetIndex_Server(t *testing.T) {
+	i := rand.Int()
+	server, _ := CreateServer(suite, i, nil)
+	if server.GetIndex() != i {
+		t.Errorf("Wrong index: got %d expected %d", server.GetIndex(), i)
+	}
+}
+
+func TestSetVerificationWorkers(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(5, 2)
+	request, err := clients[0].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	if server, _ := CreateServer(suite, 0, nil); server.workers != runtime.NumCPU() {
+		t.Errorf("Wrong default number of workers: %d", server.workers)
+	}
+
+	//The verification gives the same result with any number of workers
+	servers[0].SetVerificationWorkers(1)
+	servers[1].SetVerificationWorkers(4)
+	msg := servers[0].InitializeServerMessage(request)
+	for _, server := range servers {
+		if err = server.ServerProtocol(context, msg); err != nil {
+			t.Errorf("Error in Server Protocol with %d workers: %s", server.workers, err)
+		}
+	}
+}
+
 func TestGenerateCommitment(t *testing.T) {
 	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
 
@@ -56,7 +135,7 @@ func TestGenerateCommitment(t *testing.T) {
 	if err != nil {
 		t.Error("Invalid commitment")
 	}
-	err = ECDSAVerify(suite.Point().Mul(nil, servers[0].private), msg, commit.sig.sig)
+	err = ECDSAVerify(suite, suite.Point().Mul(nil, servers[0].private), msg, commit.sig.sig)
 	if err != nil {
 		t.Error("Wrong signature")
 	}
@@ -78,6 +157,16 @@ func TestVerifyCommitmentSignature(t *testing.T) {
 		t.Error("Cannot verify the signatures for a legit commit array")
 	}
 
+	//Wrong number of commitments
+	err = VerifyCommitmentSignature(context, commits[:len(commits)-1])
+	if err == nil {
+		t.Error("Wrong check: Missing commitment")
+	}
+	err = VerifyCommitmentSignature(context, append(commits, commits[0]))
+	if err == nil {
+		t.Error("Wrong check: Too many commitments")
+	}
+
 	//Change a random index
 	i := rand.Intn(len(servers))
 	commits[i].sig.index = i + 1
@@ -183,6 +272,7 @@ func TestInitializeChallenge(t *testing.T) {
 	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
 
 	//Generate commitments
+	tproof := testProofCommitments(context)
 	var commits []Commitment
 	var openings []abstract.Scalar
 	for i := 0; i < len(servers); i++ {
@@ -192,34 +282,38 @@ func TestInitializeChallenge(t *testing.T) {
 	}
 
 	//Normal execution
-	challenge, err := InitializeChallenge(context, commits, openings)
+	challenge, err := InitializeChallenge(context, tproof, commits, openings)
 	if challenge == nil || err != nil {
 		t.Error("Cannot initialize challenge")
 	}
 
 	//Empty inputs
-	challenge, err = InitializeChallenge(nil, commits, openings)
+	challenge, err = InitializeChallenge(nil, tproof, commits, openings)
 	if err == nil || challenge != nil {
 		t.Error("Wrong check: Empty cs")
 	}
-	challenge, err = InitializeChallenge(context, nil, openings)
+	challenge, err = InitializeChallenge(context, tproof, nil, openings)
 	if err == nil || challenge != nil {
 		t.Error("Wrong check: Empty commits")
 	}
-	challenge, err = InitializeChallenge(context, commits, nil)
+	challenge, err = InitializeChallenge(context, tproof, commits, nil)
 	if err == nil || challenge != nil {
 		t.Error("Wrong check: Empty openings")
 	}
+	challenge, err = InitializeChallenge(context, tproof[1:], commits, openings)
+	if err == nil || challenge != nil {
+		t.Error("Wrong check: Number of commitments t")
+	}
 
 	//Mismatch length between commits and openings
-	challenge, err = InitializeChallenge(context, commits, openings[:len(openings)-2])
+	challenge, err = InitializeChallenge(context, tproof, commits, openings[:len(openings)-2])
 	if err == nil || challenge != nil {
 		t.Error("Wrong check: Empty openings")
 	}
 
 	//Change an opening
 	openings[0] = suite.Scalar().Zero()
-	challenge, err = InitializeChallenge(context, commits, openings[:len(openings)-2])
+	challenge, err = InitializeChallenge(context, tproof, commits, openings[:len(openings)-2])
 	if err == nil || challenge != nil {
 		t.Error("Invalid opening check")
 	}
@@ -230,6 +324,7 @@ func TestCheckUpdateChallenge(t *testing.T) {
 	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+2)
 
 	//Generate commitments
+	tproof := testProofCommitments(context)
 	var commits []Commitment
 	var openings []abstract.Scalar
 	for i := 0; i < len(servers); i++ {
@@ -238,7 +333,7 @@ func TestCheckUpdateChallenge(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, tproof, commits, openings)
 	cs := challenge.cs
 
 	//Normal execution
@@ -258,6 +353,16 @@ func TestCheckUpdateChallenge(t *testing.T) {
 	}
 	challenge.sigs = []serverSignature{challenge.sigs[0]}
 
+	//Index out of range
+	for _, index := range []int{-1, len(servers)} {
+		challenge.sigs[0].index = index
+		err = servers[0].CheckUpdateChallenge(context, challenge)
+		if err == nil {
+			t.Errorf("Wrong check: Signature index %d", index)
+		}
+	}
+	challenge.sigs[0].index = 0
+
 	//Altered signature
 	fake := append([]byte("A"), challenge.sigs[0].sig...)
 	challenge.sigs[0].sig = fake[:len(challenge.sigs[0].sig)]
@@ -313,6 +418,7 @@ func TestFinalizeChallenge(t *testing.T) {
 	_, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+2)
 
 	//Generate commitments
+	tproof := testProofCommitments(context)
 	var commits []Commitment
 	var openings []abstract.Scalar
 	for i := 0; i < len(servers); i++ {
@@ -321,7 +427,7 @@ func TestFinalizeChallenge(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, tproof, commits, openings)
 
 	//Makes every server update the challenge
 	var err error
@@ -391,7 +497,7 @@ func TestInitializeServerMessage(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, *tclient, commits, openings)
 	cs := challenge.cs
 
 	//Sign the challenge
@@ -404,8 +510,8 @@ func TestInitializeServerMessage(t *testing.T) {
 	c, r, _ := clients[0].GenerateProofResponses(context, s, clientChallenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	//Normal execution
 	servMsg := servers[0].InitializeServerMessage(&clientMessage)
@@ -440,7 +546,7 @@ func TestServerProtocol(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, *tclient, commits, openings)
 	cs := challenge.cs
 
 	//Sign the challenge
@@ -453,8 +559,8 @@ func TestServerProtocol(t *testing.T) {
 	c, r, _ := clients[0].GenerateProofResponses(context, s, clientChallenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 	//Original hash for later test
 	hasher := sha512.New()
 	var writer io.Writer = hasher
@@ -517,6 +623,22 @@ func TestServerProtocol(t *testing.T) {
 		t.Error("Wrong check: different field length of signatures")
 	}
 
+	//Indexes out of range or not matching the signatures
+	for _, index := range []int{-1, len(servers), 1} {
+		wrongMsg = ServerMessage{request: clientMessage, proofs: servMsg.proofs[:1], tags: servMsg.tags[:1],
+			sigs: []serverSignature{servMsg.sigs[0]}, indexes: []int{index}}
+		err = servers[1].ServerProtocol(context, &wrongMsg)
+		if err == nil {
+			t.Errorf("Wrong check: Index %d", index)
+		}
+		wrongMsg.indexes[0] = servMsg.indexes[0]
+		wrongMsg.sigs[0].index = index
+		err = servers[1].ServerProtocol(context, &wrongMsg)
+		if err == nil {
+			t.Errorf("Wrong check: Signature index %d", index)
+		}
+	}
+
 	//Modify the client proof
 	wrongClient := ServerMessage{request: clientMessage, proofs: servMsg.proofs, tags: servMsg.tags, sigs: servMsg.sigs, indexes: servMsg.indexes}
 	wrongClient.request.proof = ClientProof{}
@@ -525,6 +647,38 @@ func TestServerProtocol(t *testing.T) {
 		t.Error("Wrong check: invalid client proof")
 	}
 
+	//Proof simulated for a challenge that the servers did not sign
+	forged := clientMessage
+	forged.proof = simulateClientProof(context, T0, S, suite.Scalar().Pick(random.Stream))
+	if !verifyClientProof(forged, 1, nil) {
+		t.Error("Simulated proof should pass the verification of the proof alone")
+	}
+	err = servers[0].ServerProtocol(context, &ServerMessage{request: forged})
+	if err == nil {
+		t.Error("Wrong check: Unsigned challenge")
+	}
+
+	//Proof simulated for a challenge signed for other commitments
+	forged.proof = simulateClientProof(context, T0, S, cs)
+	forged.proof.sigs = challenge.sigs
+	err = servers[0].ServerProtocol(context, &ServerMessage{request: forged})
+	if err == nil {
+		t.Error("Wrong check: Challenge signed for other commitments")
+	}
+
+	//Missing or duplicate signatures of the challenge
+	forged = clientMessage
+	forged.proof.sigs = challenge.sigs[:1]
+	err = servers[0].ServerProtocol(context, &ServerMessage{request: forged})
+	if err == nil {
+		t.Error("Wrong check: Missing signature of the challenge")
+	}
+	forged.proof.sigs = []serverSignature{challenge.sigs[0], challenge.sigs[0]}
+	err = servers[0].ServerProtocol(context, &ServerMessage{request: forged})
+	if err == nil {
+		t.Error("Wrong check: Duplicate signature of the challenge")
+	}
+
 	//Too many calls
 	err = servers[0].ServerProtocol(context, &servMsg)
 	if err == nil {
@@ -547,7 +701,7 @@ func TestServerProtocol(t *testing.T) {
 
 	//Normal execution for misbehaving client
 	misbehavingMsg := ServerMessage{request: clientMessage, proofs: nil, tags: nil, sigs: nil, indexes: nil}
-	misbehavingMsg.request.sArray[2] = suite.Point().Null() //change the commitment for server 0
+	misbehavingMsg.request.sArray[2] = suite.Point().Mul(nil, suite.Scalar().Pick(random.Stream)) //change the commitment for server 0
 	err = servers[0].ServerProtocol(context, &misbehavingMsg)
 	if err != nil {
 		t.Errorf("Error in Server Protocol for misbehaving client\n%s", err)
@@ -574,7 +728,7 @@ func TestGenerateServerProof(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, *tclient, commits, openings)
 	cs := challenge.cs
 
 	//Sign the challenge
@@ -586,8 +740,8 @@ func TestGenerateServerProof(t *testing.T) {
 	c, r, _ := clients[0].GenerateProofResponses(context, s, clientChallenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	//Create the initial server message
 	servMsg := ServerMessage{request: clientMessage, proofs: nil, tags: nil, sigs: nil, indexes: nil}
@@ -654,7 +808,7 @@ func TestVerifyServerProof(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, *tclient, commits, openings)
 	cs := challenge.cs
 
 	//Normal execution
@@ -666,8 +820,8 @@ func TestVerifyServerProof(t *testing.T) {
 	c, r, _ := clients[0].GenerateProofResponses(context, s, clientChallenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	servMsg := ServerMessage{request: clientMessage, proofs: nil, tags: nil, sigs: nil, indexes: nil}
 
@@ -696,7 +850,7 @@ func TestVerifyServerProof(t *testing.T) {
 	temp, _ = proof.ToBytes()
 	data = append(data, temp...)
 	data = append(data, []byte(strconv.Itoa(servers[0].index))...)
-	sign, _ := ECDSASign(servers[0].private, data)
+	sign, _ := ECDSASign(suite, servers[0].private, data)
 	signature := serverSignature{sig: sign, index: servers[0].index}
 	servMsg.sigs = append(servMsg.sigs, signature)
 
@@ -719,6 +873,15 @@ func TestVerifyServerProof(t *testing.T) {
 		t.Error("Cannot verify valid normal server proof")
 	}
 
+	//Index out of range
+	for _, index := range []int{-1, len(servers)} {
+		servMsg.indexes[1] = index
+		if verifyServerProof(context, 1, &servMsg) {
+			t.Errorf("Wrong check: Index %d", index)
+		}
+	}
+	servMsg.indexes[1] = servers[1].index
+
 	saveProof := serverProof{c: servMsg.proofs[1].c,
 		t1: servMsg.proofs[1].t1,
 		t2: servMsg.proofs[1].t2,
@@ -808,7 +971,7 @@ func TestGenerateMisbehavingProof(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, *tclient, commits, openings)
 	cs := challenge.cs
 
 	//Generate the challenge
@@ -821,8 +984,8 @@ func TestGenerateMisbehavingProof(t *testing.T) {
 	c, r, _ := clients[0].GenerateProofResponses(context, s, clientChallenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	proof, err := servers[0].generateMisbehavingProof(context, clientMessage.sArray[0])
 	if err != nil || proof == nil {
@@ -874,7 +1037,7 @@ func TestVerifyMisbehavingProof(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, *tclient, commits, openings)
 	cs := challenge.cs
 
 	//Normal execution
@@ -887,8 +1050,8 @@ func TestVerifyMisbehavingProof(t *testing.T) {
 	c, r, _ := clients[0].GenerateProofResponses(context, s, clientChallenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	proof, _ := servers[0].generateMisbehavingProof(context, clientMessage.sArray[0])
 
@@ -981,6 +1144,9 @@ func TestVerifyMisbehavingProof(t *testing.T) {
 
 func TestGenerateNewRoundSecret(t *testing.T) {
 	_, servers, _, _ := generateTestContext(1, 1)
+	previous := servers[0].r
+	//The words of the previous secret, which Zero alone would leave in the backing array
+	words := previous.(*nist.Int).V.Bits()
 	R := servers[0].GenerateNewRoundSecret()
 	if R == nil {
 		t.Error("Cannot generate new round secret")
@@ -994,6 +1160,45 @@ func TestGenerateNewRoundSecret(t *testing.T) {
 	if !R.Equal(suite.Point().Mul(nil, servers[0].r)) {
 		t.Error("Mismatch between r and R")
 	}
+	if !previous.Equal(suite.Scalar().Zero()) {
+		t.Error("Previous r was not erased")
+	}
+	for k, word := range words {
+		if word != 0 {
+			t.Errorf("Word %d of the previous r was not erased", k)
+		}
+	}
+}
+
+func TestCheckContext(t *testing.T) {
+	_, servers, context, _ := generateTestContext(1, 2)
+
+	//Normal execution
+	if err := servers[1].CheckContext(context); err != nil {
+		t.Errorf("Valid context rejected: %s", err)
+	}
+
+	//Server of another context
+	_, others, _, _ := generateTestContext(1, 2)
+	if err := others[1].CheckContext(context); err == nil {
+		t.Error("Wrong check: Server not in the context")
+	}
+
+	//Negative index
+	negative := servers[0]
+	negative.index = -1
+	if err := negative.CheckContext(context); err == nil {
+		t.Error("Wrong check: Negative index")
+	}
+
+	//Round secret not committed in the context
+	servers[1].GenerateNewRoundSecret()
+	if err := servers[1].CheckContext(context); err == nil {
+		t.Error("Wrong check: Round secret")
+	}
+	if err := servers[0].CheckContext(nil); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
 }
 
 func TestToBytes_ServerProof(t *testing.T) {
@@ -1010,7 +1215,7 @@ func TestToBytes_ServerProof(t *testing.T) {
 		openings = append(openings, open)
 	}
 
-	challenge, _ := InitializeChallenge(context, commits, openings)
+	challenge, _ := InitializeChallenge(context, *tclient, commits, openings)
 	cs := challenge.cs
 
 	//Create challenge
@@ -1023,8 +1228,8 @@ func TestToBytes_ServerProof(t *testing.T) {
 	c, r, _ := clients[0].GenerateProofResponses(context, s, clientChallenge, v, w)
 
 	//Assemble the client message
-	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context,
-		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r}}
+	clientMessage := ClientMessage{sArray: S, t0: T0, context: *context, contextID: contextIDOf(context),
+		proof: ClientProof{cs: cs, c: *c, t: *tclient, r: *r, sigs: challenge.sigs}}
 
 	servMsg := ServerMessage{request: clientMessage, proofs: nil, tags: nil, sigs: nil, indexes: nil}
 
@@ -0,0 +1,229 @@
+package daga
+
+import (
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*SessionState is the step reached by a ClientSession*/
+type SessionState int
+
+//States of a ClientSession, in the order they are reached
+const (
+	SessionNew       SessionState = iota //Nothing computed yet
+	SessionCommitted                     //Request and proof commitments t generated, waiting for the challenge
+	SessionAnswered                      //Proof responses generated and message assembled, waiting for the servers
+	SessionDone                          //Final linkage tag obtained
+	SessionFailed                        //A step failed, the session cannot be used anymore
+)
+
+func (state SessionState) String() string {
+	switch state {
+	case SessionNew:
+		return "new"
+	case SessionCommitted:
+		return "committed"
+	case SessionAnswered:
+		return "answered"
+	case SessionDone:
+		return "done"
+	case SessionFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("unknown(%d)", int(state))
+}
+
+/*ServerEndpoint is used by a ClientSession to reach the servers of the context*/
+type ServerEndpoint interface {
+	//RequestChallenge sends the commitments t and returns the challenge signed by all the servers
+	RequestChallenge(t []abstract.Point) (*Challenge, error)
+	//SubmitMessage sends the client's message and returns the ServerMessage completed by all the servers
+	SubmitMessage(msg *ClientMessage) (*ServerMessage, error)
+}
+
+/*TransportError is returned by a ServerEndpoint that could not exchange with the servers
+It tells apart the failures of the network from the rejections of the client by the servers*/
+type TransportError struct {
+	Err error
+}
+
+func (err *TransportError) Error() string {
+	return err.Err.Error()
+}
+
+/*ClientSession holds the state of a single authentication attempt of a client
+It owns the secrets of the attempt and enforces the order of the steps
+A session cannot be reused: a new one must be created for every attempt*/
+type ClientSession struct {
+	client  *Client
+	context *Context
+	state   SessionState
+
+	//Request elements
+	t0     abstract.Point
+	sArray []abstract.Point
+	s      abstract.Scalar
+
+	//Proof elements
+	t []abstract.Point
+	v []abstract.Scalar
+	w []abstract.Scalar
+
+	tag abstract.Point
+}
+
+//NewClientSession creates a new authentication attempt for a client in a given context
+func NewClientSession(client *Client, context *Context) (*ClientSession, error) {
+	if client == nil || context == nil {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	if client.index >= len(context.G.X) {
+		return nil, fmt.Errorf("Client index %d out of range", client.index)
+	}
+	return &ClientSession{client: client, context: context, state: SessionNew}, nil
+}
+
+//State returns the current step of the session
+func (session *ClientSession) State() SessionState {
+	return session.state
+}
+
+/*Commit creates the request (T0, S) and the proof commitments t
+The commitments must be sent to the servers to obtain the challenge*/
+func (session *ClientSession) Commit() (t []abstract.Point, err error) {
+	if session.state != SessionNew {
+		return nil, fmt.Errorf("Cannot commit in state %s", session.state)
+	}
+
+	T0, S, s, err := session.client.CreateRequest(session.context)
+	if err != nil {
+		session.fail()
+		return nil, err
+	}
+	session.t0, session.sArray, session.s = T0, S, s
+
+	tp, v, w := session.client.GenerateProofCommitments(session.context, T0, s)
+	if tp == nil {
+		session.fail()
+		return nil, fmt.Errorf("Cannot generate the commitments")
+	}
+	session.t, session.v, session.w = *tp, *v, *w
+
+	session.state = SessionCommitted
+	return session.t, nil
+}
+
+/*Answer generates the responses to the challenge and assembles the message to send to the servers
+The random values of the proof and the secret s are erased afterwards*/
+func (session *ClientSession) Answer(challenge *Challenge) (msg *ClientMessage, err error) {
+	if session.state != SessionCommitted {
+		return nil, fmt.Errorf("Cannot answer a challenge in state %s", session.state)
+	}
+	if challenge == nil {
+		session.fail()
+		return nil, fmt.Errorf("Empty challenge")
+	}
+
+	c, r, err := session.client.GenerateProofResponses(session.context, session.s, challenge, &session.v, &session.w)
+	if err != nil {
+		session.fail()
+		return nil, err
+	}
+	msg = session.client.AssembleMessage(session.context, &session.sArray, session.t0, challenge, &session.t, c, r)
+	session.erase()
+	if msg == nil {
+		session.state = SessionFailed
+		return nil, fmt.Errorf("Cannot assemble the message")
+	}
+
+	session.state = SessionAnswered
+	return msg, nil
+}
+
+/*Finalize checks the message returned by the servers and returns the final linkage tag
+The message must have been built from the request of this session*/
+func (session *ClientSession) Finalize(msg *ServerMessage) (Tf abstract.Point, err error) {
+	if session.state != SessionAnswered {
+		return nil, fmt.Errorf("Cannot finalize in state %s", session.state)
+	}
+	if msg == nil || msg.request.t0 == nil || !msg.request.t0.Equal(session.t0) {
+		session.fail()
+		return nil, fmt.Errorf("Server message does not match the session's request")
+	}
+
+	Tf, err = session.client.GetFinalLinkageTag(session.context, msg)
+	if err != nil {
+		session.fail()
+		return nil, err
+	}
+
+	session.tag = Tf
+	session.state = SessionDone
+	return Tf, nil
+}
+
+/*Authenticate runs all the steps of the session with the servers reached through endpoint
+It returns the final linkage tag of the client*/
+func (session *ClientSession) Authenticate(endpoint ServerEndpoint) (Tf abstract.Point, err error) {
+	if endpoint == nil {
+		return nil, fmt.Errorf("Empty endpoint")
+	}
+
+	t, err := session.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("Error in commitments: %s", err)
+	}
+
+	challenge, err := endpoint.RequestChallenge(t)
+	if err != nil {
+		session.fail()
+		return nil, fmt.Errorf("Error in challenge request: %s", err)
+	}
+
+	msg, err := session.Answer(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("Error in responses: %s", err)
+	}
+
+	servmsg, err := endpoint.SubmitMessage(msg)
+	if err != nil {
+		session.fail()
+		return nil, fmt.Errorf("Error in message submission: %s", err)
+	}
+
+	Tf, err = session.Finalize(servmsg)
+	if err != nil {
+		return nil, fmt.Errorf("Error in server message: %s", err)
+	}
+	return Tf, nil
+}
+
+//Abort erases the secrets of the session and makes it unusable
+func (session *ClientSession) Abort() {
+	if session.state != SessionDone {
+		session.fail()
+	}
+}
+
+/*fail marks the session as failed and erases its secrets*/
+func (session *ClientSession) fail() {
+	session.erase()
+	session.state = SessionFailed
+}
+
+/*erase overwrites the secrets s, v and w of the session*/
+func (session *ClientSession) erase() {
+	if session.s != nil {
+		eraseScalar(session.s)
+		session.s = nil
+	}
+	for _, scalar := range session.v {
+		eraseScalar(scalar)
+	}
+	session.v = nil
+	for _, scalar := range session.w {
+		eraseScalar(scalar)
+	}
+	session.w = nil
+}
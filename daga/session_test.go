@@ -0,0 +1,233 @@
+package daga
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/nist"
+)
+
+/*localEndpoint runs the servers' side of the protocol in-process*/
+type localEndpoint struct {
+	context *Context
+	servers []Server
+}
+
+func (endpoint *localEndpoint) RequestChallenge(t []abstract.Point) (*Challenge, error) {
+	var commits []Commitment
+	var openings []abstract.Scalar
+	for i := range endpoint.servers {
+		commit, open, err := endpoint.servers[i].GenerateCommitment(endpoint.context)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, *commit)
+		openings = append(openings, open)
+	}
+	challenge, err := InitializeChallenge(endpoint.context, t, commits, openings)
+	if err != nil {
+		return nil, err
+	}
+	for i := range endpoint.servers {
+		if err = endpoint.servers[i].CheckUpdateChallenge(endpoint.context, challenge); err != nil {
+			return nil, err
+		}
+	}
+	return FinalizeChallenge(endpoint.context, challenge)
+}
+
+func (endpoint *localEndpoint) SubmitMessage(msg *ClientMessage) (*ServerMessage, error) {
+	servmsg := endpoint.servers[0].InitializeServerMessage(msg)
+	for i := range endpoint.servers {
+		if err := endpoint.servers[i].ServerProtocol(endpoint.context, servmsg); err != nil {
+			return nil, err
+		}
+	}
+	return servmsg, nil
+}
+
+/*failingEndpoint rejects every request*/
+type failingEndpoint struct{}
+
+func (failingEndpoint) RequestChallenge(t []abstract.Point) (*Challenge, error) {
+	return nil, fmt.Errorf("Unreachable")
+}
+
+func (failingEndpoint) SubmitMessage(msg *ClientMessage) (*ServerMessage, error) {
+	return nil, fmt.Errorf("Unreachable")
+}
+
+func TestNewClientSession(t *testing.T) {
+	clients, _, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+
+	//Normal execution
+	session, err := NewClientSession(&clients[0], context)
+	if err != nil || session == nil || session.State() != SessionNew {
+		t.Error("Cannot create a session")
+	}
+
+	//Empty inputs
+	session, err = NewClientSession(nil, context)
+	if err == nil || session != nil {
+		t.Error("Wrong check: Empty client")
+	}
+	session, err = NewClientSession(&clients[0], nil)
+	if err == nil || session != nil {
+		t.Error("Wrong check: Empty context")
+	}
+
+	//Client outside of the context
+	outside, _ := CreateClient(suite, len(context.G.X), nil)
+	session, err = NewClientSession(&outside, context)
+	if err == nil || session != nil {
+		t.Error("Wrong check: Client index out of range")
+	}
+}
+
+func TestClientSession_Authenticate(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	i := rand.Intn(len(clients))
+	endpoint := &localEndpoint{context: context, servers: servers}
+
+	//Normal execution
+	session, _ := NewClientSession(&clients[i], context)
+	Tf, err := session.Authenticate(endpoint)
+	if err != nil || Tf == nil || Tf.Equal(suite.Point().Null()) {
+		t.Errorf("Cannot authenticate: %s", err)
+	}
+	if session.State() != SessionDone {
+		t.Errorf("Wrong state: %s", session.State())
+	}
+
+	//The final tag is the same for every session of the client
+	other, _ := NewClientSession(&clients[i], context)
+	Tf2, err := other.Authenticate(endpoint)
+	if err != nil || !Tf.Equal(Tf2) {
+		t.Error("Final linkage tags differ between sessions")
+	}
+
+	//Context without the client generators
+	lazy := *context
+	lazy.H = nil
+	session, _ = NewClientSession(&clients[i], &lazy)
+	Tf2, err = session.Authenticate(&localEndpoint{context: &lazy, servers: servers})
+	if err != nil || !Tf.Equal(Tf2) {
+		t.Errorf("Cannot authenticate without the generators: %v", err)
+	}
+
+	//A session cannot be reused
+	_, err = session.Authenticate(endpoint)
+	if err == nil {
+		t.Error("Wrong check: Session reused")
+	}
+
+	//Unreachable servers
+	session, _ = NewClientSession(&clients[i], context)
+	_, err = session.Authenticate(failingEndpoint{})
+	if err == nil || session.State() != SessionFailed {
+		t.Error("Wrong check: Unreachable servers")
+	}
+	if session.s != nil || session.v != nil || session.w != nil {
+		t.Error("Secrets not erased after failure")
+	}
+
+	//Empty endpoint
+	session, _ = NewClientSession(&clients[i], context)
+	_, err = session.Authenticate(nil)
+	if err == nil {
+		t.Error("Wrong check: Empty endpoint")
+	}
+}
+
+func TestClientSession_Steps(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+1)
+	i := rand.Intn(len(clients))
+	endpoint := &localEndpoint{context: context, servers: servers}
+	session, _ := NewClientSession(&clients[i], context)
+
+	//Out of order steps
+	if _, err := session.Answer(nil); err == nil {
+		t.Error("Wrong check: Answer before Commit")
+	}
+	if _, err := session.Finalize(nil); err == nil {
+		t.Error("Wrong check: Finalize before Commit")
+	}
+	if session.State() != SessionNew {
+		t.Error("Out of order steps changed the state")
+	}
+
+	tclient, err := session.Commit()
+	if err != nil || len(tclient) != 3*len(context.G.X) || session.State() != SessionCommitted {
+		t.Error("Cannot commit")
+	}
+	if _, err = session.Commit(); err == nil {
+		t.Error("Wrong check: Commit twice")
+	}
+
+	//Keep references to the secrets to check their erasure
+	s := session.s
+	v := session.v
+	w := session.w
+	//The words of s, which Zero alone would leave in the backing array
+	words := s.(*nist.Int).V.Bits()
+
+	challenge, _ := endpoint.RequestChallenge(tclient)
+	msg, err := session.Answer(challenge)
+	if err != nil || msg == nil || session.State() != SessionAnswered {
+		t.Error("Cannot answer the challenge")
+	}
+	if !s.Equal(suite.Scalar().Zero()) {
+		t.Error("s not erased")
+	}
+	for k, word := range words {
+		if word != 0 {
+			t.Errorf("Word %d of s was not erased", k)
+		}
+	}
+	for j := range v {
+		if !v[j].Equal(suite.Scalar().Zero()) {
+			t.Errorf("v not erased at index %d", j)
+		}
+	}
+	for j := range w {
+		if !w[j].Equal(suite.Scalar().Zero()) {
+			t.Errorf("w not erased at index %d", j)
+		}
+	}
+
+	//Erasing the secrets does not alter the message
+	if !verifyClientProof(*msg, 1, nil) {
+		t.Error("Invalid client proof after erasure")
+	}
+
+	//Message from another request
+	otherSession, _ := NewClientSession(&clients[i], context)
+	otherT, _ := otherSession.Commit()
+	otherChallenge, _ := endpoint.RequestChallenge(otherT)
+	otherMsg, _ := otherSession.Answer(otherChallenge)
+	otherServMsg, _ := endpoint.SubmitMessage(otherMsg)
+	if _, err = session.Finalize(otherServMsg); err == nil || session.State() != SessionFailed {
+		t.Error("Wrong check: Server message of another request")
+	}
+
+	//Abort
+	session, _ = NewClientSession(&clients[i], context)
+	session.Commit()
+	session.Abort()
+	if session.State() != SessionFailed || session.s != nil {
+		t.Error("Abort did not erase the session")
+	}
+}
+
+func TestSessionState_String(t *testing.T) {
+	states := []SessionState{SessionNew, SessionCommitted, SessionAnswered, SessionDone, SessionFailed}
+	seen := map[string]bool{}
+	for _, state := range states {
+		if seen[state.String()] {
+			t.Errorf("Duplicate name for state %d", state)
+		}
+		seen[state.String()] = true
+	}
+}
@@ -0,0 +1,176 @@
+package daga
+
+import (
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//contextEndorsementDomain separates the signatures of contexts from the other signatures of the servers
+const contextEndorsementDomain = "DAGA context endorsement v1"
+
+/*SignedContext is a context endorsed by the servers of its G.Y
+Each server signs the ID of the context, so a client holding a SignedContext verified by Verify knows that every server agreed to the context
+The signatures only prove anything against servers the client already trusts, since anyone can endorse a context of its own servers*/
+type SignedContext struct {
+	context *Context
+	id      ContextID
+	sigs    []serverSignature
+}
+
+/*contextEndorsement returns the message signed by the servers endorsing the context of the ID*/
+func contextEndorsement(id ContextID) []byte {
+	return append([]byte(contextEndorsementDomain), id[:]...)
+}
+
+/*SignContext endorses the context after checking that it is valid and is the context of the server's current round, as done by CheckContext
+The signature is to be added to the SignedContext of the context*/
+func (server *Server) SignContext(context *Context) (sig []byte, err error) {
+	if err = server.CheckContext(context); err != nil {
+		return nil, err
+	}
+	if err = ValidateContext(context, nil); err != nil {
+		return nil, err
+	}
+	id, err := context.ID()
+	if err != nil {
+		return nil, fmt.Errorf("Error in context: %s", err)
+	}
+	return ECDSASign(server.suite, server.private, contextEndorsement(id))
+}
+
+/*NewSignedContext creates a SignedContext without signatures*/
+func NewSignedContext(context *Context) (*SignedContext, error) {
+	if context == nil {
+		return nil, fmt.Errorf("Empty context")
+	}
+	id, err := context.ID()
+	if err != nil {
+		return nil, fmt.Errorf("Error in context: %s", err)
+	}
+	return &SignedContext{context: context, id: id}, nil
+}
+
+/*EndorseContext collects the signatures of all the servers on the context*/
+func EndorseContext(context *Context, servers []Server) (*SignedContext, error) {
+	signed, err := NewSignedContext(context)
+	if err != nil {
+		return nil, err
+	}
+	for i := range servers {
+		sig, err := servers[i].SignContext(context)
+		if err != nil {
+			return nil, fmt.Errorf("Error in signature of server %d: %s", servers[i].index, err)
+		}
+		if err = signed.AddSignature(servers[i].index, sig); err != nil {
+			return nil, err
+		}
+	}
+	return signed, nil
+}
+
+/*Context returns the endorsed context*/
+func (signed *SignedContext) Context() *Context {
+	return signed.context
+}
+
+/*AddSignature adds the signature of the server index after verifying it
+A second signature of the same server replaces the first one*/
+func (signed *SignedContext) AddSignature(index int, sig []byte) error {
+	if index < 0 || index >= len(signed.context.G.Y) {
+		return fmt.Errorf("Wrong index: %d", index)
+	}
+	if err := ECDSAVerify(signed.context.Suite(), signed.context.G.Y[index], contextEndorsement(signed.id), sig); err != nil {
+		return fmt.Errorf("Invalid signature of server %d: %s", index, err)
+	}
+	for i := range signed.sigs {
+		if signed.sigs[i].index == index {
+			signed.sigs[i].sig = sig
+			return nil
+		}
+	}
+	signed.sigs = append(signed.sigs, serverSignature{index: index, sig: sig})
+	return nil
+}
+
+/*Verify checks that the context is valid, that its G.Y holds exactly the trusted servers and that every one of them endorsed it*/
+func (signed *SignedContext) Verify(servers []abstract.Point) error {
+	if signed.context == nil {
+		return fmt.Errorf("Empty context")
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("No trusted server")
+	}
+	id, err := signed.context.ID()
+	if err != nil {
+		return fmt.Errorf("Error in context: %s", err)
+	}
+	if id != signed.id {
+		return fmt.Errorf("Signatures for another context: %s", signed.id)
+	}
+	if err = ValidateContext(signed.context, servers); err != nil {
+		return err
+	}
+	endorsed := make([]bool, len(signed.context.G.Y))
+	for _, sig := range signed.sigs {
+		if sig.index < 0 || sig.index >= len(endorsed) {
+			return fmt.Errorf("Wrong index: %d", sig.index)
+		}
+		if err = ECDSAVerify(signed.context.Suite(), signed.context.G.Y[sig.index], contextEndorsement(id), sig.sig); err != nil {
+			return fmt.Errorf("Invalid signature of server %d: %s", sig.index, err)
+		}
+		endorsed[sig.index] = true
+	}
+	for i, ok := range endorsed {
+		if !ok {
+			return fmt.Errorf("Context not endorsed by server %d", i)
+		}
+	}
+	return nil
+}
+
+/*NewSignedClientSession creates a ClientSession in the context once verified that all the trusted servers endorsed it*/
+func NewSignedClientSession(client *Client, signed *SignedContext, servers []abstract.Point) (*ClientSession, error) {
+	if client == nil || signed == nil {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	if err := signed.Verify(servers); err != nil {
+		return nil, err
+	}
+	return NewClientSession(client, signed.context)
+}
+
+/*NetSignedContext provides a JSON compatible representation of the SignedContext struct*/
+type NetSignedContext struct {
+	Context NetContext
+	Sigs    []NetServerSignature
+}
+
+/*NetEncode encodes the context with its signatures*/
+func (signed *SignedContext) NetEncode() (*NetSignedContext, error) {
+	context, err := signed.context.NetEncode()
+	if err != nil {
+		return nil, fmt.Errorf("Encode error in context\n%s", err)
+	}
+	netsigned := NetSignedContext{Context: *context}
+	for _, sig := range signed.sigs {
+		netsigned.Sigs = append(netsigned.Sigs, sig.netEncode())
+	}
+	return &netsigned, nil
+}
+
+/*NetDecode decodes the context and its signatures, which are not verified: see SignedContext.Verify*/
+func (netsigned *NetSignedContext) NetDecode() (*SignedContext, error) {
+	context, err := netsigned.Context.NetDecode()
+	if err != nil {
+		return nil, fmt.Errorf("Decode error in context\n%s", err)
+	}
+	signed, err := NewSignedContext(context)
+	if err != nil {
+		return nil, err
+	}
+	for _, sig := range netsigned.Sigs {
+		signed.sigs = append(signed.sigs, sig.netDecode())
+	}
+	return signed, nil
+}
@@ -0,0 +1,99 @@
+package daga
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestSignedContext(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+1, rand.Intn(10)+2)
+	endpoint := &localEndpoint{context: context, servers: servers}
+
+	//Normal execution
+	signed, err := EndorseContext(context, servers)
+	if err != nil {
+		t.Fatalf("Cannot endorse the context: %s", err)
+	}
+	if err = signed.Verify(context.G.Y); err != nil {
+		t.Errorf("Endorsed context rejected: %s", err)
+	}
+	session, err := NewSignedClientSession(&clients[0], signed, context.G.Y)
+	if err != nil {
+		t.Fatalf("Cannot create the session: %s", err)
+	}
+	if _, err = session.Authenticate(endpoint); err != nil {
+		t.Errorf("Cannot authenticate in the endorsed context: %s", err)
+	}
+
+	//JSON encoding
+	netsigned, _ := signed.NetEncode()
+	data, _ := json.Marshal(netsigned)
+	var received NetSignedContext
+	json.Unmarshal(data, &received)
+	decoded, err := received.NetDecode()
+	if err != nil || decoded.Verify(context.G.Y) != nil {
+		t.Errorf("Signed context does not round-trip: %s", err)
+	}
+
+	//Context of substituted servers, endorsed by themselves
+	_, others, other, _ := generateTestContext(len(clients), len(servers))
+	selfSigned, err := EndorseContext(other, others)
+	if err != nil {
+		t.Fatalf("Cannot endorse the other context: %s", err)
+	}
+	if err = selfSigned.Verify(other.G.Y); err != nil {
+		t.Errorf("Endorsed context rejected: %s", err)
+	}
+	if err = selfSigned.Verify(context.G.Y); err == nil {
+		t.Error("Wrong check: Substituted servers")
+	}
+	if _, err = NewSignedClientSession(&clients[0], selfSigned, context.G.Y); err == nil {
+		t.Error("Wrong check: Session in a context of substituted servers")
+	}
+	if err = signed.Verify(nil); err == nil {
+		t.Error("Wrong check: No trusted server")
+	}
+
+	//Missing endorsement
+	partial, _ := EndorseContext(context, servers[1:])
+	if err = partial.Verify(context.G.Y); err == nil {
+		t.Error("Wrong check: Missing endorsement")
+	}
+	if _, err = NewSignedClientSession(&clients[0], partial, context.G.Y); err == nil {
+		t.Error("Wrong check: Session in a context not endorsed")
+	}
+
+	//Signature of another server or of another context
+	sig, _ := servers[0].SignContext(context)
+	if err = partial.AddSignature(1, sig); err == nil {
+		t.Error("Wrong check: Signature of another server")
+	}
+	otherSig, _ := others[0].SignContext(other)
+	if err = partial.AddSignature(0, otherSig); err == nil {
+		t.Error("Wrong check: Signature of another context")
+	}
+	if err = partial.AddSignature(0, sig); err != nil || partial.Verify(context.G.Y) != nil {
+		t.Errorf("Cannot complete the endorsement: %s", err)
+	}
+
+	//Forged signatures in the encoding
+	received.Sigs[0].Sig = otherSig
+	decoded, _ = received.NetDecode()
+	if err = decoded.Verify(context.G.Y); err == nil {
+		t.Error("Wrong check: Forged signature")
+	}
+
+	//A server does not sign a context it is not part of
+	if _, err = others[0].SignContext(context); err == nil {
+		t.Error("Wrong check: Server not in the context")
+	}
+	negative := servers[0]
+	negative.index = -1
+	if _, err = negative.SignContext(context); err == nil {
+		t.Error("Wrong check: Negative index")
+	}
+	if _, err = NewSignedContext(nil); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
+}
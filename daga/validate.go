@@ -0,0 +1,216 @@
+package daga
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*ContextError lists every violation found in a context by ValidateContext*/
+type ContextError struct {
+	Violations []string
+}
+
+func (err *ContextError) Error() string {
+	return "Invalid context:\n" + strings.Join(err.Violations, "\n")
+}
+
+//DefaultContextCacheSize is the number of contexts a server remembers as valid
+const DefaultContextCacheSize = 1024
+
+/*ContextCache remembers the IDs of the contexts found valid
+The ID covers every element of the context, so a context with a known ID needs not be checked again
+When full, the least recently used context is forgotten*/
+type ContextCache struct {
+	mutex sync.Mutex
+	size  int
+	ids   map[ContextID]*list.Element
+	order *list.List //IDs from the most to the least recently used
+}
+
+/*NewContextCache creates an empty cache remembering up to size contexts*/
+func NewContextCache(size int) (*ContextCache, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid size %d", size)
+	}
+	return &ContextCache{size: size, ids: make(map[ContextID]*list.Element), order: list.New()}, nil
+}
+
+/*Validate checks the context like ValidateContext, skipping the checks of the context itself when its ID is known*/
+func (cache *ContextCache) Validate(context *Context, servers []abstract.Point) error {
+	return cache.validate(context, servers, nil)
+}
+
+/*validate checks the context, with the generators of the clients looked up in generators when it is not nil*/
+func (cache *ContextCache) validate(context *Context, servers []abstract.Point, generators *GeneratorCache) error {
+	if context == nil {
+		return validateContext(context, servers, generators)
+	}
+	id, err := context.ID()
+	if err != nil {
+		return validateContext(context, servers, generators)
+	}
+
+	cache.mutex.Lock()
+	elem, known := cache.ids[id]
+	if known {
+		cache.order.MoveToFront(elem)
+	}
+	cache.mutex.Unlock()
+	if known {
+		return checkServers(context, servers)
+	}
+
+	if cerr := checkContext(context, nil, generators); cerr != nil {
+		return appendServers(cerr, context, servers)
+	}
+
+	cache.mutex.Lock()
+	if _, known = cache.ids[id]; !known {
+		cache.ids[id] = cache.order.PushFront(id)
+		if cache.order.Len() > cache.size {
+			oldest := cache.order.Back()
+			cache.order.Remove(oldest)
+			delete(cache.ids, oldest.Value.(ContextID))
+		}
+	}
+	cache.mutex.Unlock()
+	return checkServers(context, servers)
+}
+
+/*ValidateContext checks that the context is well-formed:
+- the lists of members and commitments are not empty and H is either empty or has a generator per client
+- no point is missing, the neutral element, outside of the prime-order subgroup or repeated
+- every H[i] is the generator computed by GenerateClientGenerator from i and R
+- Y holds exactly the expected servers, when servers is not nil
+It returns a *ContextError listing every violation*/
+func ValidateContext(context *Context, servers []abstract.Point) error {
+	return validateContext(context, servers, nil)
+}
+
+/*validateContext checks the context and the expected servers, with the generators of the clients looked up in generators when it is not nil*/
+func validateContext(context *Context, servers []abstract.Point, generators *GeneratorCache) error {
+	if context == nil {
+		return &ContextError{Violations: []string{"Empty context"}}
+	}
+	_, err := context.ID()
+	return appendServers(checkContext(context, err, generators), context, servers)
+}
+
+/*checkContext checks the elements of a non-empty context, idErr being the error met when computing its ID
+It returns a *ContextError listing every violation*/
+func checkContext(context *Context, idErr error, generators *GeneratorCache) *ContextError {
+	suite := context.Suite()
+
+	var violations []string
+	violate := func(format string, a ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, a...))
+	}
+	if idErr != nil {
+		violate("Cannot compute the ID: %s", idErr)
+	}
+
+	if len(context.G.X) == 0 {
+		violate("No client")
+	}
+	if len(context.G.Y) == 0 {
+		violate("No server")
+	}
+	//An empty H leaves the generators to be computed from R when needed
+	if len(context.H) != 0 && len(context.H) != len(context.G.X) {
+		violate("Wrong number of generators: got %d expected %d", len(context.H), len(context.G.X))
+	}
+	if len(context.R) != len(context.G.Y) {
+		violate("Wrong number of commitments: got %d expected %d", len(context.R), len(context.G.Y))
+	}
+
+	//Points are compared by their encoding to find the repeated ones
+	seen := make(map[string]string)
+	checkPoints := func(name string, points []abstract.Point) {
+		for i, p := range points {
+			label := fmt.Sprintf("%s[%d]", name, i)
+			if p == nil {
+				violate("Empty point %s", label)
+				continue
+			}
+			if err := checkPoint(suite, p, false); err != nil {
+				violate("Invalid point %s: %s", label, err)
+				continue
+			}
+			data, err := p.MarshalBinary()
+			if err != nil {
+				violate("Cannot encode %s: %s", label, err)
+				continue
+			}
+			if first, ok := seen[string(data)]; ok {
+				violate("Repeated point %s and %s", first, label)
+				continue
+			}
+			seen[string(data)] = label
+		}
+	}
+	checkPoints("X", context.G.X)
+	checkPoints("Y", context.G.Y)
+	checkPoints("R", context.R)
+	checkPoints("H", context.H)
+
+	//The generators can only be checked against a complete R
+	completeR := len(context.R) > 0
+	for _, commit := range context.R {
+		completeR = completeR && commit != nil
+	}
+	if completeR {
+		for i, H := range context.H {
+			if H != nil && !context.checkClientGenerator(i, H, generators) {
+				violate("H[%d] is not the generator of client %d for R", i, i)
+			}
+		}
+	}
+
+	if len(violations) != 0 {
+		return &ContextError{Violations: violations}
+	}
+	return nil
+}
+
+/*appendServers adds the violations of checkServers to err, if any*/
+func appendServers(err *ContextError, context *Context, servers []abstract.Point) error {
+	serr := checkServers(context, servers)
+	if err == nil {
+		return serr
+	}
+	if serr != nil {
+		err.Violations = append(err.Violations, serr.(*ContextError).Violations...)
+	}
+	return err
+}
+
+/*checkServers checks that Y holds exactly the expected servers, in any order, when servers is not nil*/
+func checkServers(context *Context, servers []abstract.Point) error {
+	if servers == nil {
+		return nil
+	}
+	var violations []string
+	if len(servers) != len(context.G.Y) {
+		violations = append(violations, fmt.Sprintf("Wrong number of servers: got %d expected %d", len(context.G.Y), len(servers)))
+	}
+	for i, expected := range servers {
+		found := false
+		for _, Y := range context.G.Y {
+			if expected != nil && Y != nil && Y.Equal(expected) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			violations = append(violations, fmt.Sprintf("Missing expected server %d", i))
+		}
+	}
+	if len(violations) != 0 {
+		return &ContextError{Violations: violations}
+	}
+	return nil
+}
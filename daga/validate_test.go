@@ -0,0 +1,141 @@
+package daga
+
+import (
+	"math/rand"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+func TestValidateContext(t *testing.T) {
+	clients, servers, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+2)
+
+	//Normal execution
+	if err := ValidateContext(context, nil); err != nil {
+		t.Errorf("Valid context rejected: %s", err)
+	}
+	expected := make([]abstract.Point, len(servers))
+	for i := range servers {
+		expected[len(servers)-1-i] = context.G.Y[i]
+	}
+	if err := ValidateContext(context, expected); err != nil {
+		t.Errorf("Expected servers rejected: %s", err)
+	}
+
+	//The client generators can be left out
+	lazy := *context
+	lazy.H = nil
+	if err := ValidateContext(&lazy, expected); err != nil {
+		t.Errorf("Context without generators rejected: %s", err)
+	}
+	lazy.H = context.H[1:]
+	if err := ValidateContext(&lazy, nil); err == nil {
+		t.Error("Wrong check: Missing generator")
+	}
+
+	//Every violation is listed
+	invalid := *context
+	invalid.G.X = append([]abstract.Point{}, context.G.X...)
+	invalid.G.X[1] = context.G.X[0]
+	invalid.G.Y = append([]abstract.Point{}, context.G.Y...)
+	invalid.G.Y[0] = suite.Point().Null()
+	invalid.H = append([]abstract.Point{}, context.H...)
+	invalid.H[0], invalid.H[1] = context.H[1], context.H[0]
+	err := ValidateContext(&invalid, expected)
+	cerr, ok := err.(*ContextError)
+	if !ok {
+		t.Fatalf("Wrong error type: %v", err)
+	}
+	//Repeated X, neutral Y, two wrong generators and a missing server
+	if len(cerr.Violations) != 5 {
+		t.Errorf("Wrong violations:\n%s", err)
+	}
+
+	//Unexpected servers
+	if err = ValidateContext(context, expected[1:]); err == nil {
+		t.Error("Wrong check: Missing server")
+	}
+
+	//Wrong lengths and empty points
+	invalid = *context
+	invalid.H = context.H[1:]
+	invalid.R = append([]abstract.Point{}, context.R...)
+	invalid.R[0] = nil
+	if err = ValidateContext(&invalid, nil); err == nil {
+		t.Error("Wrong check: Lengths and empty point")
+	}
+	if err = ValidateContext(nil, nil); err == nil {
+		t.Error("Wrong check: Empty context")
+	}
+
+	//The protocol rejects the invalid contexts
+	invalid = *context
+	invalid.H = append([]abstract.Point{}, context.H...)
+	invalid.H[0] = suite.Point().Base()
+	if _, _, _, err = clients[0].CreateRequest(&invalid); err == nil {
+		t.Error("Wrong check: Request for an invalid context")
+	}
+	request, _ := clients[0].CreateNonInteractiveMessage(context)
+	msg := servers[0].InitializeServerMessage(request)
+	if err = servers[0].ServerProtocol(&invalid, msg); err == nil {
+		t.Error("Wrong check: Protocol with an invalid context")
+	}
+}
+
+func TestContextCache(t *testing.T) {
+	_, servers, context, _ := generateTestContext(rand.Intn(10)+2, rand.Intn(10)+2)
+	cache, err := NewContextCache(2)
+	if err != nil {
+		t.Fatalf("Cannot create the cache: %s", err)
+	}
+
+	//Normal execution
+	if err = cache.Validate(context, nil); err != nil {
+		t.Errorf("Valid context rejected: %s", err)
+	}
+	id, _ := context.ID()
+	if _, ok := cache.ids[id]; !ok {
+		t.Error("Valid context not remembered")
+	}
+	//The expected servers are checked even for a known context
+	if err = cache.Validate(context, []abstract.Point{context.G.Y[0]}); err == nil {
+		t.Error("Wrong check: Missing server on a known context")
+	}
+
+	//Invalid contexts are not remembered
+	invalid := *context
+	invalid.G.Y = append([]abstract.Point{}, context.G.Y...)
+	invalid.G.Y[0] = suite.Point().Null()
+	if err = cache.Validate(&invalid, nil); err == nil {
+		t.Error("Wrong check: Invalid context")
+	}
+	if cache.order.Len() != 1 {
+		t.Errorf("Wrong number of contexts: %d", cache.order.Len())
+	}
+
+	//The least recently used context is forgotten
+	next, err := RotateRound(context, servers)
+	if err != nil {
+		t.Fatalf("Cannot create the next round: %s", err)
+	}
+	last, _ := RotateRound(next, servers)
+	cache.Validate(next, nil)
+	cache.Validate(context, nil)
+	cache.Validate(last, nil)
+	nextID, _ := next.ID()
+	lastID, _ := last.ID()
+	if _, ok := cache.ids[nextID]; ok || cache.order.Len() != 2 {
+		t.Error("Least recently used context kept")
+	}
+	if _, ok := cache.ids[id]; !ok {
+		t.Error("Recently used context forgotten")
+	}
+	if _, ok := cache.ids[lastID]; !ok {
+		t.Error("Last context not remembered")
+	}
+
+	//Wrong size
+	if cache, err = NewContextCache(0); err == nil || cache != nil {
+		t.Error("Wrong check: Empty cache")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -12,16 +13,45 @@ import (
 )
 
 func main() {
+	mode := flag.String("mode", "time", "benchmark to run: time (JSON traffic and duration of the protocol) or size (size of each message in the JSON and binary encodings)")
+	context := flag.String("context", "value", "in time mode, how the messages carry the context: value (full copy) or ref (ID resolved by the servers)")
+	flag.Parse()
+
 	var ctos, stoc, stos *big.Int
 	var elapsed time.Duration
 	clients := []int{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768}
 	servers := []int{1, 2, 4, 8, 16, 32}
 	//clients := []int{1, 2}
 	//servers := []int{1, 2, 4}
+	if *mode == "size" {
+		fmt.Printf("Clients\tServers\tMessage\tJSON\tBinary\n")
+		for _, c := range clients {
+			for _, s := range servers {
+				results, err := sizes(c, s)
+				if err != nil {
+					fmt.Printf("%s\n", err)
+					continue
+				}
+				for _, r := range results {
+					fmt.Printf("%d\t%d\t%s\t%d\t%d\n", c, s, r.Message, r.JSON, r.Binary)
+				}
+			}
+		}
+		return
+	}
+	if *mode != "time" {
+		fmt.Printf("Unknown mode: %s\n", *mode)
+		return
+	}
+	if *context != "value" && *context != "ref" {
+		fmt.Printf("Unknown context variant: %s\n", *context)
+		return
+	}
+
 	fmt.Printf("Clients\tServers\tCtoS\tStoC\tStoS\tTotal\tTime\n")
 	for _, c := range clients {
 		for _, s := range servers {
-			ctos, stoc, stos, elapsed = scenario(c, s)
+			ctos, stoc, stos, elapsed = scenario(c, s, *context == "ref")
 			total := big.NewInt(0)
 			total.Add(ctos, stoc)
 			total.Add(total, stos)
@@ -31,18 +61,21 @@ func main() {
 }
 
 //Copy-Paste of scenario_test with small additions to measure time and message size
-func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
+//If byRef is set, the client and server messages reference the context by ID instead of carrying it
+func scenario(c, s int, byRef bool) (*big.Int, *big.Int, *big.Int, time.Duration) {
 	//Initialize benchmark variables
 	ctos := big.NewInt(0)
 	stoc := big.NewInt(0)
 	stos := big.NewInt(0)
 	zero := big.NewInt(0)
+	//Suite in which the protocol runs
+	suite := daga.Suite
 
 	//Generates clients
 	var X []abstract.Point
 	var clients []daga.Client
 	for i := 0; i < c; i++ {
-		client, err := daga.CreateClient(i, nil)
+		client, err := daga.CreateClient(suite, i, nil)
 		if err != nil {
 			fmt.Printf("Cannot create clients:\n%s\n", err)
 			return zero, zero, zero, 0
@@ -55,7 +88,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 	var Y []abstract.Point
 	var servers []daga.Server
 	for j := 0; j < s; j++ {
-		server, err := daga.CreateServer(j, nil)
+		server, err := daga.CreateServer(suite, j, nil)
 		if err != nil {
 			fmt.Printf("Cannot create servers:\n%s\n", err)
 			return zero, zero, zero, 0
@@ -73,7 +106,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 	//Generate client's generators
 	var H []abstract.Point
 	for i := 0; i < len(X); i++ {
-		temp, err := daga.GenerateClientGenerator(i, &R)
+		temp, err := daga.GenerateClientGenerator(suite, i, &R)
 		if err != nil {
 			fmt.Printf("Error in client's geenrators:\n%s\n", err)
 			return zero, zero, zero, 0
@@ -81,7 +114,11 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		H = append(H, temp)
 	}
 
-	serviceContext := daga.ContextEd25519{G: daga.Members{X: X, Y: Y}, R: R, H: H}
+	serviceContext, err := daga.NewContext(suite, daga.Members{X: X, Y: Y}, R, H)
+	if err != nil {
+		fmt.Printf("Cannot create the context\n%s\n", err)
+		return zero, zero, zero, 0
+	}
 
 	//Simulate the transfer of the context from the service to the client
 	//Encoding
@@ -97,7 +134,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 	}
 	//Network transfer
 	//Decoding
-	var netContext daga.NetContextEd25519
+	var netContext daga.NetContext
 	err = json.Unmarshal(netdata, &netContext)
 	if err != nil || &netContext == nil {
 		fmt.Printf("Cannot json unmarshal the context\n%s\n", err)
@@ -109,6 +146,13 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		return zero, zero, zero, 0
 	}
 
+	//The servers know the context, the messages can reference it by ID
+	store, err := daga.NewContextStore(context)
+	if err != nil {
+		fmt.Printf("Cannot create the context store\n%s\n", err)
+		return zero, zero, zero, 0
+	}
+
 	//Start time measurement as we consider that the context is already distributed
 	start := time.Now()
 
@@ -143,7 +187,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		fmt.Printf("Cannot json unmarshal the commitments t\n%s\n", err)
 		return zero, zero, zero, 0
 	}
-	tserver, err := daga.NetDecodePoints(nett)
+	tserver, err := daga.NetDecodePoints(suite, nett)
 	if err != nil || tserver == nil {
 		fmt.Printf("Error in t decoding\n%s\n", err)
 		return zero, zero, zero, 0
@@ -159,7 +203,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 	//Initialize both arrays
 	for num := 0; num < len(context.G.Y); num++ {
 		commits = append(commits, daga.Commitment{})
-		openings = append(openings, daga.Suite.Scalar().Zero())
+		openings = append(openings, suite.Scalar().Zero())
 	}
 
 	//The leader asks other servers to generates commitments by publishing its own signed commitment
@@ -193,7 +237,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		fmt.Printf("Error when json unmarshal the commitment of the leader %d\n%s\n", j, err)
 		return zero, zero, zero, 0
 	}
-	_, err = rcvCom.NetDecode()
+	_, err = rcvCom.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the commitment of the leader %d\n%s\n", j, err)
 		return zero, zero, zero, 0
@@ -234,7 +278,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 			fmt.Printf("Error when json unmarshal the commitment of server %d\n%s\n", num, e)
 			return zero, zero, zero, 0
 		}
-		_, e = rcvCom.NetDecode()
+		_, e = rcvCom.NetDecode(suite)
 		if e != nil {
 			fmt.Printf("Error when decoding the commitment of server %d\n%s\n", num, e)
 			return zero, zero, zero, 0
@@ -271,7 +315,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		fmt.Printf("Error when json unmarshal the opening of the leader %d\n", j)
 		return zero, zero, zero, 0
 	}
-	_, err = rcvOpen.NetDecode()
+	_, err = rcvOpen.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the opening of the leader %d\n", j)
 		return zero, zero, zero, 0
@@ -304,7 +348,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 			return zero, zero, zero, 0
 		}
 		//No need to check that this value is the same as the one before transfer, this is done in the test of the network functions in daga
-		_, e = rcvOpen.NetDecode()
+		_, e = rcvOpen.NetDecode(suite)
 		if e != nil {
 			fmt.Printf("Error when decoding the opening of server %d\n%s\n", num, e)
 			return zero, zero, zero, 0
@@ -312,7 +356,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 	}
 
 	//After receiving all the openings, server j veerifies them and initializes the challenge structure
-	challenge, err := daga.InitializeChallenge(context, commits, openings)
+	challenge, err := daga.InitializeChallenge(context, *t, commits, openings)
 	if err != nil {
 		fmt.Printf("Error when initializing the challenge\n%s\n", err)
 		return zero, zero, zero, 0
@@ -347,7 +391,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 			fmt.Printf("Error when json unmarshal the challenge at server %d\n%s\n", index, e)
 			return zero, zero, zero, 0
 		}
-		serverChallenge, e := rcvChall.NetDecode()
+		serverChallenge, e := rcvChall.NetDecode(suite)
 		if e != nil {
 			fmt.Printf("Error when decoding the challenge at server %d\n%s\n", index, e)
 			return zero, zero, zero, 0
@@ -378,7 +422,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		fmt.Printf("Error when json unmarshal the challenge back at the leader %d\n%s\n", j, err)
 		return zero, zero, zero, 0
 	}
-	finalChallenge, err := rcvfinalChall.NetDecode()
+	finalChallenge, err := rcvfinalChall.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the challenge at the leader %d\n%s\n", j, err)
 		return zero, zero, zero, 0
@@ -413,7 +457,7 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		fmt.Printf("Error when json unmarshal the challenge at client %d\n%s\n", i, err)
 		return zero, zero, zero, 0
 	}
-	_, err = rcvclientChall.NetDecode()
+	_, err = rcvclientChall.NetDecode(suite)
 	if err != nil {
 		fmt.Printf("Error when decoding the challenge at client %d\n%s\n", i, err)
 		return zero, zero, zero, 0
@@ -433,31 +477,14 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 	j = rand.Intn(len(Y))
 
 	//Simulate the transfer of the client message to the server
-	sendclientMsg, err := msg.NetEncode()
-	if err != nil {
-		fmt.Printf("Error when encoding the client message\n%s\n", err)
-		return zero, zero, zero, 0
-	}
-	netdata, err = json.Marshal(sendclientMsg)
+	size, err := transferClientMessage(msg, store, byRef)
 	if err != nil {
-		fmt.Printf("Error when json marshal the client message\n%s\n", err)
+		fmt.Printf("%s\n", err)
 		return zero, zero, zero, 0
 	}
 
 	//Network transfer
-	ctos.Add(ctos, big.NewInt(int64(len(netdata))))
-
-	var rcvclientMsg daga.NetClientMessage
-	err = json.Unmarshal(netdata, &rcvclientMsg)
-	if err != nil {
-		fmt.Printf("Error when json unmarshal the client message\n%s\n", err)
-		return zero, zero, zero, 0
-	}
-	_, err = rcvclientMsg.NetDecode()
-	if err != nil {
-		fmt.Printf("Error when decoding the client message\n%s\n", err)
-		return zero, zero, zero, 0
-	}
+	ctos.Add(ctos, big.NewInt(int64(size)))
 
 	//This server initialize the server message with the request from the client
 	msgServ := servers[j].InitializeServerMessage(msg)
@@ -471,35 +498,18 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		}
 		//The server pass the massage to the next one
 		//If this is the last server, it broadcasts it to all the servers and the client
-		sendservMsg, e := msgServ.NetEncode()
-		if e != nil {
-			fmt.Printf("Error when encoding the server message at server %d\n%s\n", index, e)
-			return zero, zero, zero, 0
-		}
-		netdata, e = json.Marshal(sendservMsg)
+		size, e := transferServerMessage(msgServ, store, byRef)
 		if e != nil {
-			fmt.Printf("Error when json marshal the server message at server %d\n%s\n", index, e)
+			fmt.Printf("%s at server %d\n", e, index)
 			return zero, zero, zero, 0
 		}
 
 		//Network transfer
 		if shift == s-1 {
-			stos.Add(stos, big.NewInt(int64((s-1)*len(netdata)))) //The messa is broadcast to all servers
-			stoc.Add(stoc, big.NewInt(int64(len(netdata))))       //The message is sent back to the client
+			stos.Add(stos, big.NewInt(int64((s-1)*size))) //The messa is broadcast to all servers
+			stoc.Add(stoc, big.NewInt(int64(size)))       //The message is sent back to the client
 		} else {
-			stos.Add(stos, big.NewInt(int64(len(netdata)))) //The message is only passed to the next server
-		}
-
-		var rcvservMsg daga.NetServerMessage
-		e = json.Unmarshal(netdata, &rcvservMsg)
-		if e != nil {
-			fmt.Printf("Error when json unmarshal the server message at server %d\n%s\n", index, e)
-			return zero, zero, zero, 0
-		}
-		_, e = rcvservMsg.NetDecode()
-		if e != nil {
-			fmt.Printf("Error when decoding the server message at server %d\n%s\n", index, e)
-			return zero, zero, zero, 0
+			stos.Add(stos, big.NewInt(int64(size))) //The message is only passed to the next server
 		}
 	}
 
@@ -512,10 +522,86 @@ func scenario(c, s int) (*big.Int, *big.Int, *big.Int, time.Duration) {
 		return zero, zero, zero, 0
 	}
 	//A Null value means that the authentication is rejected
-	if Tf.Equal(daga.Suite.Point().Null()) {
+	if Tf.Equal(suite.Point().Null()) {
 		fmt.Printf("Authentication rejected\n")
 		return zero, zero, zero, 0
 	}
 	elapsed := time.Since(start)
 	return ctos, stoc, stos, elapsed
 }
+
+//transferClientMessage encodes the client message in JSON, with the context or a reference to it, and decodes it back like the receiving server
+//It returns the size of the encoded message
+func transferClientMessage(msg *daga.ClientMessage, store *daga.ContextStore, byRef bool) (int, error) {
+	var netdata []byte
+	var err error
+	if byRef {
+		netmsg, e := msg.NetEncodeRef()
+		if e != nil {
+			return 0, fmt.Errorf("Error when encoding the client message\n%s", e)
+		}
+		if netdata, err = json.Marshal(netmsg); err != nil {
+			return 0, fmt.Errorf("Error when json marshal the client message\n%s", err)
+		}
+		var rcvmsg daga.NetClientMessageRef
+		if err = json.Unmarshal(netdata, &rcvmsg); err != nil {
+			return 0, fmt.Errorf("Error when json unmarshal the client message\n%s", err)
+		}
+		_, err = rcvmsg.NetDecode(store)
+	} else {
+		netmsg, e := msg.NetEncode()
+		if e != nil {
+			return 0, fmt.Errorf("Error when encoding the client message\n%s", e)
+		}
+		if netdata, err = json.Marshal(netmsg); err != nil {
+			return 0, fmt.Errorf("Error when json marshal the client message\n%s", err)
+		}
+		var rcvmsg daga.NetClientMessage
+		if err = json.Unmarshal(netdata, &rcvmsg); err != nil {
+			return 0, fmt.Errorf("Error when json unmarshal the client message\n%s", err)
+		}
+		_, err = rcvmsg.NetDecode()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Error when decoding the client message\n%s", err)
+	}
+	return len(netdata), nil
+}
+
+//transferServerMessage encodes the server message in JSON, with the context or a reference to it, and decodes it back like the receiving server
+//It returns the size of the encoded message
+func transferServerMessage(msg *daga.ServerMessage, store *daga.ContextStore, byRef bool) (int, error) {
+	var netdata []byte
+	var err error
+	if byRef {
+		netmsg, e := msg.NetEncodeRef()
+		if e != nil {
+			return 0, fmt.Errorf("Error when encoding the server message\n%s", e)
+		}
+		if netdata, err = json.Marshal(netmsg); err != nil {
+			return 0, fmt.Errorf("Error when json marshal the server message\n%s", err)
+		}
+		var rcvmsg daga.NetServerMessageRef
+		if err = json.Unmarshal(netdata, &rcvmsg); err != nil {
+			return 0, fmt.Errorf("Error when json unmarshal the server message\n%s", err)
+		}
+		_, err = rcvmsg.NetDecode(store)
+	} else {
+		netmsg, e := msg.NetEncode()
+		if e != nil {
+			return 0, fmt.Errorf("Error when encoding the server message\n%s", e)
+		}
+		if netdata, err = json.Marshal(netmsg); err != nil {
+			return 0, fmt.Errorf("Error when json marshal the server message\n%s", err)
+		}
+		var rcvmsg daga.NetServerMessage
+		if err = json.Unmarshal(netdata, &rcvmsg); err != nil {
+			return 0, fmt.Errorf("Error when json unmarshal the server message\n%s", err)
+		}
+		_, err = rcvmsg.NetDecode()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Error when decoding the server message\n%s", err)
+	}
+	return len(netdata), nil
+}
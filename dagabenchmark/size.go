@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*encodedSize stores the size of a message in both encodings*/
+type encodedSize struct {
+	Message string
+	JSON    int
+	Binary  int
+}
+
+/*binaryEncoder is implemented by the messages having a binary representation*/
+type binaryEncoder interface {
+	MarshalBinary() ([]byte, error)
+}
+
+//measure returns the size of a message with the JSON and binary encodings
+func measure(name string, msg binaryEncoder, net interface{}, err error) (encodedSize, error) {
+	if err != nil {
+		return encodedSize{}, fmt.Errorf("Error when encoding the %s\n%s", name, err)
+	}
+	netdata, err := json.Marshal(net)
+	if err != nil {
+		return encodedSize{}, fmt.Errorf("Cannot json marshal the %s\n%s", name, err)
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return encodedSize{}, fmt.Errorf("Error when binary encoding the %s\n%s", name, err)
+	}
+	return encodedSize{Message: name, JSON: len(netdata), Binary: len(data)}, nil
+}
+
+//sizes runs the protocol with c clients and s servers without the network and measures each kind of message
+func sizes(c, s int) ([]encodedSize, error) {
+	suite := daga.Suite
+
+	var X, Y, R, H []abstract.Point
+	var clients []daga.Client
+	for i := 0; i < c; i++ {
+		client, err := daga.CreateClient(suite, i, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot create clients:\n%s", err)
+		}
+		clients = append(clients, client)
+		X = append(X, client.GetPublicKey())
+	}
+	var servers []daga.Server
+	for j := 0; j < s; j++ {
+		server, err := daga.CreateServer(suite, j, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot create servers:\n%s", err)
+		}
+		R = append(R, server.GenerateNewRoundSecret())
+		servers = append(servers, server)
+		Y = append(Y, server.GetPublicKey())
+	}
+	for i := range X {
+		temp, err := daga.GenerateClientGenerator(suite, i, &R)
+		if err != nil {
+			return nil, fmt.Errorf("Error in client's generators:\n%s", err)
+		}
+		H = append(H, temp)
+	}
+	context, err := daga.NewContext(suite, daga.Members{X: X, Y: Y}, R, H)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the context\n%s", err)
+	}
+
+	var results []encodedSize
+	add := func(name string, msg binaryEncoder, net interface{}, err error) error {
+		size, err := measure(name, msg, net, err)
+		if err != nil {
+			return err
+		}
+		results = append(results, size)
+		return nil
+	}
+
+	netcontext, err := context.NetEncode()
+	if err = add("Context", context, netcontext, err); err != nil {
+		return nil, err
+	}
+
+	//Client's commitments
+	i := rand.Intn(c)
+	T0, S, secret, err := clients[i].CreateRequest(context)
+	if err != nil {
+		return nil, fmt.Errorf("Error when creating the request:\n%s", err)
+	}
+	t, v, w := clients[i].GenerateProofCommitments(context, T0, secret)
+
+	//Challenge generation
+	var commits []daga.Commitment
+	var openings []abstract.Scalar
+	for j := range servers {
+		com, open, e := servers[j].GenerateCommitment(context)
+		if e != nil {
+			return nil, fmt.Errorf("Error when generating the commitment at server %d\n%s", j, e)
+		}
+		commits = append(commits, *com)
+		openings = append(openings, open)
+	}
+	netcom, err := commits[0].NetEncode()
+	if err = add("Commitment", &commits[0], netcom, err); err != nil {
+		return nil, err
+	}
+	check, err := daga.InitializeChallenge(context, *t, commits, openings)
+	if err != nil {
+		return nil, fmt.Errorf("Error when initializing the challenge\n%s", err)
+	}
+	for j := range servers {
+		if err = servers[j].CheckUpdateChallenge(context, check); err != nil {
+			return nil, fmt.Errorf("Error when updating the challenge at server %d\n%s", j, err)
+		}
+	}
+	netcheck, err := check.NetEncode()
+	if err = add("ChallengeCheck", check, netcheck, err); err != nil {
+		return nil, err
+	}
+	challenge, err := daga.FinalizeChallenge(context, check)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot finalize the challenge\n%s", err)
+	}
+	netchall, err := challenge.NetEncode()
+	if err = add("Challenge", challenge, netchall, err); err != nil {
+		return nil, err
+	}
+
+	//Client's responses
+	cclient, r, err := clients[i].GenerateProofResponses(context, secret, challenge, v, w)
+	if err != nil {
+		return nil, fmt.Errorf("Error in the proof responses:\n%s", err)
+	}
+	msg := clients[i].AssembleMessage(context, &S, T0, challenge, t, cclient, r)
+	netmsg, err := msg.NetEncode()
+	if err = add("ClientMessage", msg, netmsg, err); err != nil {
+		return nil, err
+	}
+
+	//Message completed by all the servers
+	servmsg := servers[0].InitializeServerMessage(msg)
+	for j := range servers {
+		if err = servers[j].ServerProtocol(context, servmsg); err != nil {
+			return nil, fmt.Errorf("Error in the server protocol at server %d:\n%s", j, err)
+		}
+	}
+	netservmsg, err := servmsg.NetEncode()
+	if err = add("ServerMessage", servmsg, netservmsg, err); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,181 @@
+package dagahttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*Client is used by a DAGA client to reach a Service
+Suite is the suite of the context, used to decode the challenge
+PollInterval is the delay between two pollings of a job and Timeout bounds the wait for its result
+The message answering a challenge is submitted with the job of the challenge, so a Client serves a single authentication at a time*/
+type Client struct {
+	Suite        abstract.Suite
+	URL          string
+	HTTP         *http.Client
+	PollInterval time.Duration
+	Timeout      time.Duration
+
+	challenge string //Job of the last challenge, sent with the next message
+}
+
+//Client can be used by a daga.ClientSession to authenticate
+var _ daga.ServerEndpoint = (*Client)(nil)
+
+//NewClient creates a Client for the service at the base URL, running DAGA in the given suite
+func NewClient(suite abstract.Suite, url string) *Client {
+	return &Client{
+		Suite:        suite,
+		URL:          strings.TrimRight(url, "/"),
+		HTTP:         &http.Client{Timeout: 30 * time.Second},
+		PollInterval: 100 * time.Millisecond,
+		Timeout:      time.Minute,
+	}
+}
+
+//FetchContext returns the context served by the service
+//Nothing proves that the servers agreed to it, see FetchSignedContext
+func (client *Client) FetchContext() (*daga.Context, error) {
+	var netcontext daga.NetContext
+	if _, err := client.do(http.MethodGet, "/context", nil, &netcontext); err != nil {
+		return nil, err
+	}
+	return netcontext.NetDecode()
+}
+
+//FetchSignedContext returns the context served by the service after verifying that its servers are the trusted ones and that all of them endorsed it
+func (client *Client) FetchSignedContext(servers []abstract.Point) (*daga.SignedContext, error) {
+	var netsigned daga.NetSignedContext
+	if _, err := client.do(http.MethodGet, "/context/signed", nil, &netsigned); err != nil {
+		return nil, err
+	}
+	signed, err := netsigned.NetDecode()
+	if err != nil {
+		return nil, err
+	}
+	if err = signed.Verify(servers); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+//RequestChallenge sends the client's commitments t to the service and returns the challenge signed by all the servers
+func (client *Client) RequestChallenge(t []abstract.Point) (*daga.Challenge, error) {
+	nett, err := daga.NetEncodePoints(t)
+	if err != nil {
+		return nil, fmt.Errorf("Error when encoding the commitments: %s", err)
+	}
+	var netchall daga.NetChallenge
+	id, err := client.run("/challenges", "", nett, &netchall)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := netchall.NetDecode(client.Suite)
+	if err != nil {
+		return nil, err
+	}
+	client.challenge = id
+	return challenge, nil
+}
+
+//SubmitMessage sends the client's message to the service and returns the ServerMessage completed by all the servers
+//The message is sent with the job of the last challenge, which can only be answered once
+func (client *Client) SubmitMessage(msg *daga.ClientMessage) (*daga.ServerMessage, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("Empty message")
+	}
+	netmsg, err := msg.NetEncode()
+	if err != nil {
+		return nil, fmt.Errorf("Error when encoding the client message: %s", err)
+	}
+	query := ""
+	if client.challenge != "" {
+		query = "?challenge=" + url.QueryEscape(client.challenge)
+		client.challenge = ""
+	}
+	var netservmsg daga.NetServerMessage
+	if _, err = client.run("/messages", query, netmsg, &netservmsg); err != nil {
+		return nil, err
+	}
+	return netservmsg.NetDecode()
+}
+
+/*run starts a job with the data and polls it until its result is decoded into out, returning the ID of the job
+The query is only sent with the request starting the job*/
+func (client *Client) run(path, query string, data, out interface{}) (string, error) {
+	var started Job
+	if _, err := client.do(http.MethodPost, path+query, data, &started); err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(client.Timeout)
+	for {
+		var raw json.RawMessage
+		code, err := client.do(http.MethodGet, path+"/"+started.ID, nil, &raw)
+		if err != nil {
+			return "", err
+		}
+		if code == http.StatusOK {
+			if err = json.Unmarshal(raw, out); err != nil {
+				return "", fmt.Errorf("Cannot unmarshal the result: %s", err)
+			}
+			return started.ID, nil
+		}
+		if time.Now().After(deadline) {
+			return "", &daga.TransportError{Err: fmt.Errorf("Job %s not finished after %s", started.ID, client.Timeout)}
+		}
+		time.Sleep(client.PollInterval)
+	}
+}
+
+/*do sends a request and decodes the answer into out
+The status code is returned for the successful answers, the other ones being turned into errors
+The service being unreachable or unavailable is reported as a *daga.TransportError*/
+func (client *Client) do(method, path string, data, out interface{}) (int, error) {
+	var body bytes.Buffer
+	if data != nil {
+		if err := json.NewEncoder(&body).Encode(data); err != nil {
+			return 0, fmt.Errorf("Cannot marshal the request: %s", err)
+		}
+	}
+	req, err := http.NewRequest(method, client.URL+path, &body)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot create the request: %s", err)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.HTTP.Do(req)
+	if err != nil {
+		return 0, &daga.TransportError{Err: fmt.Errorf("Cannot reach %s: %s", client.URL, err)}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		if resp.StatusCode == http.StatusAccepted && method == http.MethodGet {
+			return resp.StatusCode, nil
+		}
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, fmt.Errorf("Cannot unmarshal the answer: %s", err)
+		}
+		return resp.StatusCode, nil
+	case http.StatusUnprocessableEntity:
+		var failed Job
+		json.NewDecoder(resp.Body).Decode(&failed)
+		return 0, fmt.Errorf("%s", failed.Error)
+	}
+	var e errorResponse
+	json.NewDecoder(resp.Body).Decode(&e)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return 0, &daga.TransportError{Err: fmt.Errorf("Error %d: %s", resp.StatusCode, e.Error)}
+	}
+	return 0, fmt.Errorf("Error %d: %s", resp.StatusCode, e.Error)
+}
@@ -0,0 +1,350 @@
+/*Package dagahttp exposes a DAGA server set to clients over HTTP with the JSON Net* types of the daga package
+A Service fronts a dagatcp.Node: the client sends its commitments and its message to the service, which runs the challenge generation and the server protocol with the other nodes.
+Both steps can take a while, so they run as jobs that the client polls:
+
+	GET  /context           the NetContext of the node
+	GET  /context/signed    the NetSignedContext, when the service has one
+	POST /challenges        starts a challenge generation for the []NetPoint commitments t
+	GET  /challenges/{id}   the NetChallenge once generated
+	POST /messages          starts the authentication of the NetClientMessage
+	GET  /messages/{id}     the completed NetServerMessage
+
+An interactive message answers the challenge of a job: it is posted to /messages?challenge={id}, which can be done only once per challenge.
+The POST requests answer 202 Accepted with the Job to poll, the GET requests of a job answer 202 Accepted while it runs.
+At most MaxJobs jobs run at the same time, the POST requests answer 503 Service Unavailable beyond*/
+package dagahttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"github.com/dedis/student_17_pop_fs/dagatcp"
+)
+
+//DefaultJobTTL is the time a finished job stays available to the client
+const DefaultJobTTL = 5 * time.Minute
+
+//DefaultMaxJobs is the number of jobs a service runs at the same time
+const DefaultMaxJobs = 256
+
+//MaxBodySize bounds the size of the requests of the clients
+const MaxBodySize = 16 << 20
+
+//Status of a job
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+/*Job is the answer of the service to a POST request and to the polling of an unfinished job*/
+type Job struct {
+	ID     string
+	Status string
+	Error  string `json:",omitempty"`
+}
+
+/*errorResponse is the body of the answers reporting an error*/
+type errorResponse struct {
+	Error string
+}
+
+/*job is a challenge generation or an authentication run by the service
+issued is the challenge generated by the job until a message answers it*/
+type job struct {
+	status   string
+	err      string
+	result   interface{}
+	issued   *daga.IssuedChallenge
+	started  time.Time
+	finished time.Time
+}
+
+/*Service serves the DAGA client requests of a dagatcp.Node over HTTP
+JobTTL is the time a job is kept for the client to fetch its result
+MaxJobs bounds the number of jobs running at the same time*/
+type Service struct {
+	node    *dagatcp.Node
+	signed  *daga.SignedContext
+	JobTTL  time.Duration
+	MaxJobs int
+
+	mutex   sync.Mutex
+	jobs    map[string]*job
+	running int //Number of jobs whose work is not finished
+	mux     *http.ServeMux
+}
+
+//Service is an http.Handler
+var _ http.Handler = (*Service)(nil)
+
+//NewService creates the HTTP front-end of a node
+//signed is the context of the node endorsed by the servers, it may be nil
+func NewService(node *dagatcp.Node, signed *daga.SignedContext) (*Service, error) {
+	if node == nil {
+		return nil, fmt.Errorf("Empty node")
+	}
+	if signed != nil {
+		id, _ := node.Context().ID()
+		if signedID, _ := signed.Context().ID(); signedID != id {
+			return nil, fmt.Errorf("Signed context not served by the node")
+		}
+	}
+	service := &Service{node: node, signed: signed, JobTTL: DefaultJobTTL, MaxJobs: DefaultMaxJobs, jobs: make(map[string]*job), mux: http.NewServeMux()}
+	service.mux.HandleFunc("/context", service.handleContext)
+	service.mux.HandleFunc("/context/signed", service.handleSignedContext)
+	service.mux.HandleFunc("/challenges", service.handleStartChallenge)
+	service.mux.HandleFunc("/challenges/", service.handleJob)
+	service.mux.HandleFunc("/messages", service.handleStartMessage)
+	service.mux.HandleFunc("/messages/", service.handleJob)
+	return service, nil
+}
+
+//ServeHTTP dispatches the request to its endpoint
+func (service *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service.mux.ServeHTTP(w, r)
+}
+
+/*writeJSON writes the value with the status code*/
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(value)
+}
+
+/*writeError reports the error with the status code*/
+func writeError(w http.ResponseWriter, code int, format string, a ...interface{}) {
+	writeJSON(w, code, errorResponse{Error: fmt.Sprintf(format, a...)})
+}
+
+/*checkMethod answers 405 Method Not Allowed and returns false when the request does not use the method*/
+func checkMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "Method %s not allowed", r.Method)
+		return false
+	}
+	return true
+}
+
+/*decodeBody unmarshals the JSON body of the request, answering 400 Bad Request on failure*/
+func decodeBody(w http.ResponseWriter, r *http.Request, value interface{}) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize)).Decode(value); err != nil {
+		writeError(w, http.StatusBadRequest, "Cannot unmarshal the request: %s", err)
+		return false
+	}
+	return true
+}
+
+/*handleContext serves the context of the node*/
+func (service *Service) handleContext(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	netcontext, err := service.node.Context().NetEncode()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Cannot encode the context: %s", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, netcontext)
+}
+
+/*handleSignedContext serves the context endorsed by the servers*/
+func (service *Service) handleSignedContext(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	if service.signed == nil {
+		writeError(w, http.StatusNotFound, "No signed context")
+		return
+	}
+	netsigned, err := service.signed.NetEncode()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Cannot encode the signed context: %s", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, netsigned)
+}
+
+/*handleStartChallenge checks the commitments t of the client and starts the generation of a challenge*/
+func (service *Service) handleStartChallenge(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodPost) {
+		return
+	}
+	var nett []daga.NetPoint
+	if !decodeBody(w, r, &nett) {
+		return
+	}
+	context := service.node.Context()
+	t, err := daga.NetDecodePoints(context.Suite(), nett)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Cannot decode the commitments: %s", err)
+		return
+	}
+	if len(t) != 3*len(context.G.X) {
+		writeError(w, http.StatusBadRequest, "Wrong number of commitments: got %d expected %d", len(t), 3*len(context.G.X))
+		return
+	}
+	service.start(w, "/challenges/", func(j *job) (interface{}, error) {
+		issued, err := service.node.GenerateChallenge(t)
+		if err != nil {
+			return nil, err
+		}
+		netchall, err := issued.Challenge().NetEncode()
+		if err != nil {
+			return nil, err
+		}
+		service.mutex.Lock()
+		j.issued = issued
+		service.mutex.Unlock()
+		return netchall, nil
+	})
+}
+
+/*takeChallenge returns the challenge generated by the job and forgets it, so that it is answered at most once*/
+func (service *Service) takeChallenge(id string) (*daga.IssuedChallenge, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	j, ok := service.jobs["/challenges/"+id]
+	if !ok || j.issued == nil {
+		return nil, fmt.Errorf("No challenge to answer for job %s", id)
+	}
+	issued := j.issued
+	j.issued = nil
+	return issued, nil
+}
+
+/*handleStartMessage checks the message of the client and starts its authentication by all the servers
+An interactive message must name the job of the challenge it answers in the challenge query parameter*/
+func (service *Service) handleStartMessage(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodPost) {
+		return
+	}
+	var netmsg daga.NetClientMessage
+	if !decodeBody(w, r, &netmsg) {
+		return
+	}
+	//Rejected before the decoding of the points
+	if id, _ := service.node.Context().ID(); netmsg.ContextID != id {
+		writeError(w, http.StatusBadRequest, "Unknown context: %s", netmsg.ContextID)
+		return
+	}
+	msg, err := netmsg.NetDecode()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Cannot decode the client message: %s", err)
+		return
+	}
+	var issued *daga.IssuedChallenge
+	if id := r.URL.Query().Get("challenge"); id != "" {
+		if issued, err = service.takeChallenge(id); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+	}
+	service.start(w, "/messages/", func(*job) (interface{}, error) {
+		servmsg, err := service.node.Authenticate(msg, issued)
+		if err != nil {
+			return nil, err
+		}
+		return servmsg.NetEncode()
+	})
+}
+
+/*start runs the work as a new job and answers with its location
+The jobs older than JobTTL are forgotten, even the ones still running*/
+func (service *Service) start(w http.ResponseWriter, prefix string, work func(j *job) (interface{}, error)) {
+	id, err := newJobID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	//The prefix keeps the challenges and the messages apart
+	key := prefix + id
+	now := time.Now()
+	j := &job{status: StatusPending, started: now}
+
+	service.mutex.Lock()
+	for k, old := range service.jobs {
+		if now.Sub(old.started) > service.JobTTL && (old.status == StatusPending || now.Sub(old.finished) > service.JobTTL) {
+			delete(service.jobs, k)
+		}
+	}
+	if service.running >= service.MaxJobs {
+		service.mutex.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "Too many jobs in progress")
+		return
+	}
+	service.running++
+	service.jobs[key] = j
+	service.mutex.Unlock()
+
+	go func() {
+		result, err := runWork(work, j)
+		service.mutex.Lock()
+		defer service.mutex.Unlock()
+		service.running--
+		j.finished = time.Now()
+		if err != nil {
+			j.status, j.err = StatusFailed, err.Error()
+			return
+		}
+		j.status, j.result = StatusDone, result
+	}()
+
+	w.Header().Set("Location", key)
+	writeJSON(w, http.StatusAccepted, Job{ID: id, Status: StatusPending})
+}
+
+/*runWork runs the work of a job, turning a panic into an error so that a malformed request cannot bring the service down*/
+func runWork(work func(j *job) (interface{}, error), j *job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("Internal error: %v", r)
+		}
+	}()
+	return work(j)
+}
+
+/*handleJob answers the polling of a job: its result once done, its status otherwise*/
+func (service *Service) handleJob(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	service.mutex.Lock()
+	j, ok := service.jobs[r.URL.Path]
+	var status, errmsg string
+	var result interface{}
+	if ok {
+		status, errmsg, result = j.status, j.err, j.result
+	}
+	service.mutex.Unlock()
+
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, "Unknown job: %s", id)
+	case status == StatusPending:
+		writeJSON(w, http.StatusAccepted, Job{ID: id, Status: status})
+	case status == StatusFailed:
+		writeJSON(w, http.StatusUnprocessableEntity, Job{ID: id, Status: status, Error: errmsg})
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+/*newJobID returns a random identifier for a job*/
+func newJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("Cannot generate job ID: %s", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
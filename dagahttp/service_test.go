@@ -0,0 +1,334 @@
+package dagahttp
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"github.com/dedis/student_17_pop_fs/dagatcp"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+/*startNodes creates a context with c clients and s servers and runs each server as a dagatcp node on a localhost port
+The context is returned endorsed by all the servers*/
+func startNodes(t *testing.T, c, s int) (clients []daga.Client, nodes []*dagatcp.Node, signed *daga.SignedContext) {
+	var X []abstract.Point
+	for i := 0; i < c; i++ {
+		client, err := daga.CreateClient(daga.Suite, i, nil)
+		if err != nil {
+			t.Fatalf("Cannot create clients: %s", err)
+		}
+		clients = append(clients, client)
+		X = append(X, client.GetPublicKey())
+	}
+
+	var Y, R []abstract.Point
+	var servers []daga.Server
+	for j := 0; j < s; j++ {
+		server, err := daga.CreateServer(daga.Suite, j, nil)
+		if err != nil {
+			t.Fatalf("Cannot create servers: %s", err)
+		}
+		Y = append(Y, server.GetPublicKey())
+		R = append(R, server.GenerateNewRoundSecret())
+		servers = append(servers, server)
+	}
+
+	var H []abstract.Point
+	for i := range X {
+		temp, err := daga.GenerateClientGenerator(daga.Suite, i, &R)
+		if err != nil {
+			t.Fatalf("Cannot generate the client generators: %s", err)
+		}
+		H = append(H, temp)
+	}
+	context := &daga.Context{G: daga.Members{X: X, Y: Y}, R: R, H: H}
+	signed, err := daga.EndorseContext(context, servers)
+	if err != nil {
+		t.Fatalf("Cannot endorse the context: %s", err)
+	}
+
+	var listeners []net.Listener
+	var peers []string
+	for j := 0; j < s; j++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Cannot listen: %s", err)
+		}
+		listeners = append(listeners, listener)
+		peers = append(peers, listener.Addr().String())
+	}
+	for j := 0; j < s; j++ {
+		node, err := dagatcp.NewNode(&servers[j], context, peers)
+		if err != nil {
+			t.Fatalf("Cannot create node %d: %s", j, err)
+		}
+		node.Timeout = 5 * time.Second
+		nodes = append(nodes, node)
+		go node.Serve(listeners[j])
+	}
+	return clients, nodes, signed
+}
+
+func stopNodes(nodes []*dagatcp.Node) {
+	for _, node := range nodes {
+		node.Close()
+	}
+}
+
+func TestService(t *testing.T) {
+	clients, nodes, signed := startNodes(t, 4, 3)
+	defer stopNodes(nodes)
+	service, err := NewService(nodes[rand.Intn(len(nodes))], signed)
+	if err != nil {
+		t.Fatalf("Cannot create the service: %s", err)
+	}
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := NewClient(daga.Suite, server.URL)
+	client.PollInterval = 10 * time.Millisecond
+
+	//Normal execution, the client only knowing the URL of the service and the keys of the servers
+	trusted := signed.Context().G.Y
+	fetched, err := client.FetchSignedContext(trusted)
+	if err != nil {
+		t.Fatalf("Cannot fetch the signed context: %s", err)
+	}
+	i := rand.Intn(len(clients))
+	session, err := daga.NewSignedClientSession(&clients[i], fetched, trusted)
+	if err != nil {
+		t.Fatalf("Cannot create the session: %s", err)
+	}
+	Tf, err := session.Authenticate(client)
+	if err != nil || Tf.Equal(daga.Suite.Point().Null()) {
+		t.Fatalf("Authentication failed: %s", err)
+	}
+	context, err := client.FetchContext()
+	if err != nil {
+		t.Fatalf("Cannot fetch the context: %s", err)
+	}
+	id, _ := context.ID()
+	if signedID, _ := signed.Context().ID(); id != signedID {
+		t.Error("Wrong context")
+	}
+
+	//Servers other than the trusted ones
+	untrusted := append([]abstract.Point{daga.Suite.Point().Mul(nil, daga.Suite.Scalar().One())}, trusted[1:]...)
+	if _, err = client.FetchSignedContext(untrusted); err == nil {
+		t.Error("Wrong check: Untrusted servers")
+	}
+
+	//The protocol fails in the job
+	T0, S, s, _ := clients[i].CreateRequest(context)
+	tclient, v, w := clients[i].GenerateProofCommitments(context, T0, s)
+	challenge, err := client.RequestChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+	c, r, _ := clients[i].GenerateProofResponses(context, s, challenge, v, w)
+	(*r)[0] = daga.Suite.Scalar().One()
+	msg := clients[i].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+	_, err = client.SubmitMessage(msg)
+	if err == nil {
+		t.Error("Wrong check: Invalid proof")
+	}
+	if _, ok := err.(*daga.TransportError); ok {
+		t.Error("Rejection reported as a transport error")
+	}
+
+	//Invalid requests
+	if _, err = client.RequestChallenge((*tclient)[:3]); err == nil {
+		t.Error("Wrong check: Number of commitments")
+	}
+	if _, err = client.SubmitMessage(nil); err == nil {
+		t.Error("Wrong check: Empty message")
+	}
+	for _, req := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/challenges/unknown", http.StatusNotFound},
+		{http.MethodGet, "/messages", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/context", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/challenges", http.StatusBadRequest},
+	} {
+		r, _ := http.NewRequest(req.method, server.URL+req.path, bytes.NewBufferString("{"))
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatalf("Cannot send the request: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != req.code {
+			t.Errorf("%s %s: got %d expected %d", req.method, req.path, resp.StatusCode, req.code)
+		}
+	}
+	//Unreachable service
+	unreachable := NewClient(daga.Suite, "http://127.0.0.1:1")
+	if _, err = unreachable.RequestChallenge(*tclient); err == nil {
+		t.Error("Wrong check: Unreachable service")
+	}
+	if _, ok := err.(*daga.TransportError); !ok {
+		t.Errorf("Wrong error type: %v", err)
+	}
+}
+
+func TestService_NoSignedContext(t *testing.T) {
+	_, nodes, signed := startNodes(t, 1, 1)
+	defer stopNodes(nodes)
+	service, _ := NewService(nodes[0], nil)
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	if _, err := NewClient(daga.Suite, server.URL).FetchSignedContext(signed.Context().G.Y); err == nil {
+		t.Error("Wrong check: No signed context")
+	}
+
+	//Wrong inputs
+	if _, err := NewService(nil, nil); err == nil {
+		t.Error("Wrong check: Empty node")
+	}
+	_, others, _ := startNodes(t, 1, 1)
+	defer stopNodes(others)
+	if _, err := NewService(others[0], signed); err == nil {
+		t.Error("Wrong check: Signed context of another node")
+	}
+}
+
+/*TestService_ForgedChallenge checks that a message answers the challenge of a job once, with the commitments it was issued for*/
+func TestService_ForgedChallenge(t *testing.T) {
+	clients, nodes, signed := startNodes(t, 3, 2)
+	defer stopNodes(nodes)
+	service, _ := NewService(nodes[0], signed)
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := NewClient(daga.Suite, server.URL)
+	client.PollInterval = 10 * time.Millisecond
+	context := signed.Context()
+
+	//Commitments chosen after the challenge
+	T0, S, s, _ := clients[0].CreateRequest(context)
+	tclient, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	other, _, _ := clients[0].GenerateProofCommitments(context, T0, s)
+	challenge, err := client.RequestChallenge(*other)
+	if err != nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+	id := client.challenge
+	c, r, _ := clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	msg := clients[0].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+	if _, err = client.SubmitMessage(msg); err == nil {
+		t.Error("Wrong check: Commitments chosen after the challenge")
+	}
+
+	//The challenge was consumed by the rejected message
+	client.challenge = id
+	if _, err = client.SubmitMessage(msg); err == nil {
+		t.Error("Wrong check: Replayed challenge")
+	}
+
+	//Interactive message without a challenge
+	if _, err = client.SubmitMessage(msg); err == nil {
+		t.Error("Wrong check: No challenge")
+	}
+
+	//A correct answer is accepted once
+	challenge, err = client.RequestChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+	id = client.challenge
+	c, r, _ = clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	msg = clients[0].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+	if _, err = client.SubmitMessage(msg); err != nil {
+		t.Fatalf("Cannot authenticate: %s", err)
+	}
+	client.challenge = id
+	if _, err = client.SubmitMessage(msg); err == nil {
+		t.Error("Wrong check: Replayed message")
+	}
+
+	//Non-interactive message
+	msg, err = clients[1].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	if _, err = client.SubmitMessage(msg); err != nil {
+		t.Errorf("Cannot authenticate a non-interactive message: %s", err)
+	}
+}
+
+func TestService_MaxJobs(t *testing.T) {
+	clients, nodes, signed := startNodes(t, 1, 2)
+	defer stopNodes(nodes)
+	service, _ := NewService(nodes[0], signed)
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := NewClient(daga.Suite, server.URL)
+	client.PollInterval = 10 * time.Millisecond
+	T0, _, s, _ := clients[0].CreateRequest(signed.Context())
+	tclient, _, _ := clients[0].GenerateProofCommitments(signed.Context(), T0, s)
+
+	//No job can start
+	service.MaxJobs = 0
+	if _, err := client.RequestChallenge(*tclient); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Wrong check: Too many jobs: %s", err)
+	}
+
+	//The finished jobs do not count
+	service.MaxJobs = 1
+	for k := 0; k < 3; k++ {
+		if _, err := client.RequestChallenge(*tclient); err != nil {
+			t.Fatalf("Cannot get the challenge: %s", err)
+		}
+	}
+
+	//Expired jobs are forgotten, including the challenges waiting for a message
+	service.JobTTL = 0
+	id := client.challenge
+	time.Sleep(time.Millisecond)
+	if _, err := client.RequestChallenge(*tclient); err != nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+	if _, err := service.takeChallenge(id); err == nil {
+		t.Error("Wrong check: Expired challenge")
+	}
+}
+
+/*TestService_MisbehavingClient checks that the proofs of a misbehaving client, which have no r2, go through the service*/
+func TestService_MisbehavingClient(t *testing.T) {
+	clients, nodes, signed := startNodes(t, 2, 3)
+	defer stopNodes(nodes)
+	service, _ := NewService(nodes[2], signed)
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := NewClient(daga.Suite, server.URL)
+	client.PollInterval = 10 * time.Millisecond
+	context := signed.Context()
+
+	T0, S, s, _ := clients[1].CreateRequest(context)
+	tclient, v, w := clients[1].GenerateProofCommitments(context, T0, s)
+	challenge, err := client.RequestChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+	c, r, _ := clients[1].GenerateProofResponses(context, s, challenge, v, w)
+	//Wrong commitment for server 0, the proof of the client only covers the last one
+	S[2] = daga.Suite.Point().Mul(nil, daga.Suite.Scalar().Pick(random.Stream))
+	msg := clients[1].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+
+	servmsg, err := client.SubmitMessage(msg)
+	if err != nil || servmsg == nil {
+		t.Fatalf("Cannot authenticate a misbehaving client: %s", err)
+	}
+	Tf, err := clients[1].GetFinalLinkageTag(context, servmsg)
+	if err != nil || !Tf.Equal(daga.Suite.Point().Null()) {
+		t.Errorf("Wrong final linkage tag for a misbehaving client: %s", err)
+	}
+}
@@ -0,0 +1,68 @@
+package dagatcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+/*Remote is used by a client to reach a server of the context over TCP
+Suite is the suite of the context, used to decode the challenge
+Timeout bounds a whole exchange with the server, including the work it does with its peers
+The message answering a challenge must be submitted to the server that issued it, with the session it returned, so a Remote serves a single authentication at a time*/
+type Remote struct {
+	Suite   abstract.Suite
+	Address string
+	Timeout time.Duration
+
+	session string //Session of the last challenge, sent with the next message
+}
+
+//Remote can be used by a daga.ClientSession to authenticate
+var _ daga.ServerEndpoint = (*Remote)(nil)
+
+//NewRemote creates a Remote for the server listening on addr, running DAGA in the given suite
+func NewRemote(suite abstract.Suite, addr string) *Remote {
+	return &Remote{Suite: suite, Address: addr, Timeout: time.Minute}
+}
+
+//RequestChallenge sends the client's commitments t to the server and returns the challenge signed by all the servers
+func (remote *Remote) RequestChallenge(t []abstract.Point) (*daga.Challenge, error) {
+	nett, err := daga.NetEncodePoints(t)
+	if err != nil {
+		return nil, fmt.Errorf("Error when encoding the commitments: %s", err)
+	}
+	var issued netIssuedChallenge
+	err = exchange(remote.Address, remote.Timeout, typeClientCommitments, "", nett, &issued)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := issued.Challenge.NetDecode(remote.Suite)
+	if err != nil {
+		return nil, err
+	}
+	remote.session = issued.Session
+	return challenge, nil
+}
+
+//SubmitMessage sends the client's message to the server and returns the ServerMessage completed by all the servers
+//The message is sent with the session of the last challenge, which can only be answered once
+func (remote *Remote) SubmitMessage(msg *daga.ClientMessage) (*daga.ServerMessage, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("Empty message")
+	}
+	netmsg, err := msg.NetEncode()
+	if err != nil {
+		return nil, fmt.Errorf("Error when encoding the client message: %s", err)
+	}
+	session := remote.session
+	remote.session = ""
+	var netservmsg daga.NetServerMessage
+	err = exchange(remote.Address, remote.Timeout, typeClientMessage, session, netmsg, &netservmsg)
+	if err != nil {
+		return nil, err
+	}
+	return netservmsg.NetDecode()
+}
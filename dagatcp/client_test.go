@@ -0,0 +1,66 @@
+package dagatcp
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+)
+
+func TestRemote(t *testing.T) {
+	clients, nodes, context := startNodes(t, 5, 3)
+	defer stopNodes(nodes)
+
+	i := rand.Intn(len(clients))
+	T0, S, s, err := clients[i].CreateRequest(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	tclient, v, w := clients[i].GenerateProofCommitments(context, T0, s)
+
+	//The client can talk to any server, but answers the challenge to the one that issued it
+	remote := NewRemote(daga.Suite, nodes[0].peers[rand.Intn(len(nodes))])
+	challenge, err := remote.RequestChallenge(*tclient)
+	if err != nil || challenge == nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+	c, r, err := clients[i].GenerateProofResponses(context, s, challenge, v, w)
+	if err != nil {
+		t.Fatalf("Invalid challenge: %s", err)
+	}
+	msg := clients[i].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+
+	servmsg, err := remote.SubmitMessage(msg)
+	if err != nil || servmsg == nil {
+		t.Fatalf("Cannot submit the message: %s", err)
+	}
+	Tf, err := clients[i].GetFinalLinkageTag(context, servmsg)
+	if err != nil || Tf.Equal(daga.Suite.Point().Null()) {
+		t.Errorf("Authentication failed: %s", err)
+	}
+
+	//Wrong number of commitments
+	challenge, err = remote.RequestChallenge((*tclient)[:3])
+	if err == nil || challenge != nil {
+		t.Error("Wrong check: Number of commitments")
+	}
+	if _, ok := err.(*daga.TransportError); ok {
+		t.Error("Rejection reported as a transport error")
+	}
+
+	//Empty message
+	servmsg, err = remote.SubmitMessage(nil)
+	if err == nil || servmsg != nil {
+		t.Error("Wrong check: Empty message")
+	}
+
+	//Unreachable server
+	stopNodes(nodes)
+	challenge, err = remote.RequestChallenge(*tclient)
+	if err == nil || challenge != nil {
+		t.Error("Wrong check: Unreachable server")
+	}
+	if _, ok := err.(*daga.TransportError); !ok {
+		t.Errorf("Wrong error type: %v", err)
+	}
+}
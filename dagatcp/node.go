@@ -0,0 +1,620 @@
+/*Package dagatcp provides a TCP transport for DAGA
+It allows each daga.Server to run in its own process and to exchange the Net* messages with its peers*/
+package dagatcp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+)
+
+//Types of the requests exchanged over TCP
+const (
+	typeCommitment        = "commitment"
+	typeOpening           = "opening"
+	typeChallengeCheck    = "challengecheck"
+	typeServerMessage     = "servermessage"
+	typeServerMessageRef  = "servermessageref"
+	typeClientCommitments = "clientcommitments"
+	typeClientMessage     = "clientmessage"
+)
+
+//DefaultTimeout is the time allowed to a peer to answer a request
+const DefaultTimeout = 10 * time.Second
+
+/*request is the envelope of every message sent to a node
+Session links the commitment and opening requests of a same challenge generation*/
+type request struct {
+	Type    string
+	Session string
+	Data    json.RawMessage
+}
+
+/*response is the envelope of every answer sent by a node
+Error is empty on success*/
+type response struct {
+	Error string
+	Data  json.RawMessage
+}
+
+/*pendingOpening is an opening waiting to be revealed to the leader of a session*/
+type pendingOpening struct {
+	opening  abstract.Scalar
+	deadline time.Time
+}
+
+/*pendingChallenge is a challenge issued to a client, waiting for its message*/
+type pendingChallenge struct {
+	issued   *daga.IssuedChallenge
+	deadline time.Time
+}
+
+/*netIssuedChallenge is the answer to the commitments of a client
+Session identifies the challenge, the client sends it back with its message*/
+type netIssuedChallenge struct {
+	Session   string
+	Challenge daga.NetChallenge
+}
+
+/*Node runs a daga.Server behind a TCP listener
+peers holds the address of every server of the context, indexed like context.G.Y
+Timeout bounds every exchange with a peer and RoundTimeout every phase of a round led by the node*/
+type Node struct {
+	server       *daga.Server
+	context      *daga.Context
+	contextID    daga.ContextID
+	contexts     *daga.ContextStore //Resolves the messages referencing the context by ID
+	peers        []string
+	Timeout      time.Duration
+	RoundTimeout time.Duration
+
+	mutex    sync.Mutex
+	listener net.Listener
+	pending  map[string]pendingOpening   //Openings waiting for the leader, keyed by session
+	issued   map[string]pendingChallenge //Challenges waiting for the client's message, keyed by session
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+//NewNode creates a node for a server, its context and the address of all the servers of the context
+func NewNode(server *daga.Server, context *daga.Context, peers []string) (*Node, error) {
+	if server == nil || context == nil {
+		return nil, fmt.Errorf("Invalid inputs")
+	}
+	if len(peers) != len(context.G.Y) {
+		return nil, fmt.Errorf("Wrong number of peers: got %d expected %d", len(peers), len(context.G.Y))
+	}
+	if server.GetIndex() >= len(peers) {
+		return nil, fmt.Errorf("Server index %d out of range", server.GetIndex())
+	}
+	contexts, err := daga.NewContextStore(context)
+	if err != nil {
+		return nil, err
+	}
+	id, err := context.ID()
+	if err != nil {
+		return nil, fmt.Errorf("Error in context: %s", err)
+	}
+	return &Node{
+		server:       server,
+		context:      context,
+		contextID:    id,
+		contexts:     contexts,
+		peers:        peers,
+		Timeout:      DefaultTimeout,
+		RoundTimeout: daga.DefaultRoundTimeout,
+		pending:      make(map[string]pendingOpening),
+		issued:       make(map[string]pendingChallenge),
+	}, nil
+}
+
+//Context returns the context served by the node
+func (node *Node) Context() *daga.Context {
+	return node.context
+}
+
+//ListenAndServe listens on the TCP address addr and serves incoming requests until Close is called
+func (node *Node) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("Cannot listen on %s: %s", addr, err)
+	}
+	return node.Serve(listener)
+}
+
+//Serve accepts connections on the listener and serves them until Close is called
+func (node *Node) Serve(listener net.Listener) error {
+	node.mutex.Lock()
+	if node.closed {
+		node.mutex.Unlock()
+		listener.Close()
+		return fmt.Errorf("Node closed")
+	}
+	node.listener = listener
+	node.mutex.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			node.mutex.Lock()
+			closed := node.closed
+			node.mutex.Unlock()
+			if closed {
+				return nil
+			}
+			return fmt.Errorf("Error in accept: %s", err)
+		}
+		node.wg.Add(1)
+		go func() {
+			defer node.wg.Done()
+			node.handleConn(conn)
+		}()
+	}
+}
+
+//Close stops the listener and waits for the requests being served
+func (node *Node) Close() error {
+	node.mutex.Lock()
+	node.closed = true
+	listener := node.listener
+	node.mutex.Unlock()
+
+	var err error
+	if listener != nil {
+		err = listener.Close()
+	}
+	node.wg.Wait()
+	return err
+}
+
+/*handleConn reads a single request from the connection and writes back the response*/
+func (node *Node) handleConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(node.Timeout))
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(response{Error: fmt.Sprintf("Cannot decode request: %s", err)})
+		return
+	}
+
+	//The leader side of the requests from a client can take longer than a single exchange
+	if req.Type == typeClientCommitments || req.Type == typeClientMessage {
+		conn.SetDeadline(time.Time{})
+	}
+
+	var resp response
+	data, err := node.safeHandle(&req)
+	if err == nil {
+		resp.Data, err = json.Marshal(data)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+/*safeHandle runs handle, turning a panic into an error so that a malformed request cannot bring the node down*/
+func (node *Node) safeHandle(req *request) (data interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, fmt.Errorf("Internal error when handling %s: %v", req.Type, r)
+		}
+	}()
+	return node.handle(req)
+}
+
+/*handle dispatches a request to the corresponding step of the protocol*/
+func (node *Node) handle(req *request) (interface{}, error) {
+	switch req.Type {
+	case typeCommitment:
+		return node.handleCommitment(req)
+	case typeOpening:
+		return node.handleOpening(req)
+	case typeChallengeCheck:
+		return node.handleChallengeCheck(req)
+	case typeServerMessage:
+		return node.handleServerMessage(req)
+	case typeServerMessageRef:
+		return node.handleServerMessageRef(req)
+	case typeClientCommitments:
+		return node.handleClientCommitments(req)
+	case typeClientMessage:
+		return node.handleClientMessage(req)
+	}
+	return nil, fmt.Errorf("Unknown request type: %s", req.Type)
+}
+
+/*handleCommitment generates the server's commitment upon request of the leader
+The opening is kept until the leader asks for it, for at most one round timeout*/
+func (node *Node) handleCommitment(req *request) (interface{}, error) {
+	if req.Session == "" {
+		return nil, fmt.Errorf("Empty session")
+	}
+	var leader daga.NetCommitment
+	if err := json.Unmarshal(req.Data, &leader); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal the leader's commitment: %s", err)
+	}
+	if _, err := leader.NetDecode(node.context.Suite()); err != nil {
+		return nil, fmt.Errorf("Cannot decode the leader's commitment: %s", err)
+	}
+
+	commit, opening, err := node.server.GenerateCommitment(node.context)
+	if err != nil {
+		return nil, err
+	}
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	now := time.Now()
+	for session, pending := range node.pending {
+		if now.After(pending.deadline) {
+			delete(node.pending, session)
+		}
+	}
+	if _, ok := node.pending[req.Session]; ok {
+		return nil, fmt.Errorf("Session %s already exists", req.Session)
+	}
+	node.pending[req.Session] = pendingOpening{opening: opening, deadline: now.Add(node.RoundTimeout)}
+
+	return commit.NetEncode()
+}
+
+/*handleOpening reveals the opening of the commitment generated for the session*/
+func (node *Node) handleOpening(req *request) (interface{}, error) {
+	var leader daga.NetScalar
+	if err := json.Unmarshal(req.Data, &leader); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal the leader's opening: %s", err)
+	}
+	if _, err := leader.NetDecode(node.context.Suite()); err != nil {
+		return nil, fmt.Errorf("Cannot decode the leader's opening: %s", err)
+	}
+
+	node.mutex.Lock()
+	pending, ok := node.pending[req.Session]
+	delete(node.pending, req.Session)
+	node.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown session %s", req.Session)
+	}
+	if time.Now().After(pending.deadline) {
+		return nil, fmt.Errorf("Session %s expired", req.Session)
+	}
+
+	return daga.NetEncodeScalar(pending.opening)
+}
+
+/*handleChallengeCheck checks and signs the challenge received from the leader*/
+func (node *Node) handleChallengeCheck(req *request) (interface{}, error) {
+	var netchall daga.NetChallengeCheck
+	if err := json.Unmarshal(req.Data, &netchall); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal the challenge: %s", err)
+	}
+	challenge, err := netchall.NetDecode(node.context.Suite())
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode the challenge: %s", err)
+	}
+
+	if err = node.server.CheckUpdateChallenge(node.context, challenge); err != nil {
+		return nil, err
+	}
+
+	return challenge.NetEncode()
+}
+
+/*handleServerMessage runs the server protocol on a message received from another server*/
+func (node *Node) handleServerMessage(req *request) (interface{}, error) {
+	var netmsg daga.NetServerMessage
+	if err := json.Unmarshal(req.Data, &netmsg); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal the server message: %s", err)
+	}
+	//Rejected before the decoding of the points
+	if netmsg.ContextID != node.contextID {
+		return nil, fmt.Errorf("Unknown context: %s", netmsg.ContextID)
+	}
+	msg, err := netmsg.NetDecode()
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode the server message: %s", err)
+	}
+
+	if err = node.server.ServerProtocol(node.context, msg); err != nil {
+		return nil, err
+	}
+
+	return msg.NetEncode()
+}
+
+/*handleServerMessageRef runs the server protocol on a message received from another server that references the context by ID*/
+func (node *Node) handleServerMessageRef(req *request) (interface{}, error) {
+	var netmsg daga.NetServerMessageRef
+	if err := json.Unmarshal(req.Data, &netmsg); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal the server message: %s", err)
+	}
+	msg, err := netmsg.NetDecode(node.contexts)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode the server message: %s", err)
+	}
+
+	if err = node.server.ServerProtocol(node.context, msg); err != nil {
+		return nil, err
+	}
+
+	return msg.NetEncodeRef()
+}
+
+/*handleClientCommitments generates a challenge for a client that sent its commitments t
+The challenge is kept under a new session until the client answers it, for at most one round timeout*/
+func (node *Node) handleClientCommitments(req *request) (interface{}, error) {
+	var nett []daga.NetPoint
+	if err := json.Unmarshal(req.Data, &nett); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal the commitments: %s", err)
+	}
+	t, err := daga.NetDecodePoints(node.context.Suite(), nett)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode the commitments: %s", err)
+	}
+	if len(t) != 3*len(node.context.G.X) {
+		return nil, fmt.Errorf("Wrong number of commitments: got %d expected %d", len(t), 3*len(node.context.G.X))
+	}
+
+	issued, err := node.GenerateChallenge(t)
+	if err != nil {
+		return nil, err
+	}
+	netchall, err := issued.Challenge().NetEncode()
+	if err != nil {
+		return nil, err
+	}
+	session, err := newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	now := time.Now()
+	for old, pending := range node.issued {
+		if now.After(pending.deadline) {
+			delete(node.issued, old)
+		}
+	}
+	node.issued[session] = pendingChallenge{issued: issued, deadline: now.Add(node.RoundTimeout)}
+
+	return netIssuedChallenge{Session: session, Challenge: *netchall}, nil
+}
+
+/*takeChallenge returns the challenge issued under the session and forgets it, so that it is answered at most once*/
+func (node *Node) takeChallenge(session string) (*daga.IssuedChallenge, error) {
+	node.mutex.Lock()
+	pending, ok := node.issued[session]
+	delete(node.issued, session)
+	node.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown session %s", session)
+	}
+	if time.Now().After(pending.deadline) {
+		return nil, fmt.Errorf("Session %s expired", session)
+	}
+	return pending.issued, nil
+}
+
+/*handleClientMessage runs the authentication of a client message with all the servers
+An interactive message must come with the session of the challenge it answers*/
+func (node *Node) handleClientMessage(req *request) (interface{}, error) {
+	var netmsg daga.NetClientMessage
+	if err := json.Unmarshal(req.Data, &netmsg); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal the client message: %s", err)
+	}
+	//Rejected before the decoding of the points
+	if netmsg.ContextID != node.contextID {
+		return nil, fmt.Errorf("Unknown context: %s", netmsg.ContextID)
+	}
+	msg, err := netmsg.NetDecode()
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode the client message: %s", err)
+	}
+	var issued *daga.IssuedChallenge
+	if req.Session != "" {
+		if issued, err = node.takeChallenge(req.Session); err != nil {
+			return nil, err
+		}
+	}
+
+	servmsg, err := node.Authenticate(msg, issued)
+	if err != nil {
+		return nil, err
+	}
+	return servmsg.NetEncode()
+}
+
+/*GenerateChallenge runs the distributed challenge generation for the commitments t of a client with this node as the leader
+It collects the commitments and openings of all the peers, then passes the challenge along the ring of servers for signature*/
+func (node *Node) GenerateChallenge(t []abstract.Point) (*daga.IssuedChallenge, error) {
+	j := node.server.GetIndex()
+	n := len(node.context.G.Y)
+
+	session, err := newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	//The leader publishes its own commitment and collects the others
+	round, comlead, err := daga.NewRound(node.server, node.context, t, node.RoundTimeout)
+	if err != nil {
+		return nil, err
+	}
+	netcomlead, err := comlead.NetEncode()
+	if err != nil {
+		return nil, fmt.Errorf("Error when encoding the leader commitment: %s", err)
+	}
+	for k := 0; k < n; k++ {
+		if k == j {
+			continue
+		}
+		var netcom daga.NetCommitment
+		if err = node.call(round, k, typeCommitment, session, netcomlead, &netcom); err != nil {
+			return nil, round.Abort(err)
+		}
+		com, e := netcom.NetDecode(node.context.Suite())
+		if e != nil {
+			return nil, round.Abort(fmt.Errorf("Error when decoding the commitment of server %d: %s", k, e))
+		}
+		if e = round.AddCommitment(com); e != nil {
+			return nil, round.Abort(e)
+		}
+	}
+
+	//Once all the commitments are verified, the openings are revealed
+	if round.Phase() == daga.PhaseOpening {
+		openlead, e := round.Opening()
+		if e != nil {
+			return nil, e
+		}
+		netopenlead, e := daga.NetEncodeScalar(openlead)
+		if e != nil {
+			return nil, round.Abort(fmt.Errorf("Error when encoding the leader opening: %s", e))
+		}
+		for k := 0; k < n; k++ {
+			if k == j {
+				continue
+			}
+			var netopen daga.NetScalar
+			if e = node.call(round, k, typeOpening, session, netopenlead, &netopen); e != nil {
+				return nil, round.Abort(e)
+			}
+			open, e := netopen.NetDecode(node.context.Suite())
+			if e != nil {
+				return nil, round.Abort(fmt.Errorf("Error when decoding the opening of server %d: %s", k, e))
+			}
+			if e = round.AddOpening(k, open); e != nil {
+				return nil, round.Abort(e)
+			}
+		}
+	}
+
+	//The challenge goes through every other server for signature before coming back to the leader
+	for round.Phase() == daga.PhaseChallenge {
+		challenge, k, e := round.ChallengeCheck()
+		if e != nil {
+			return nil, e
+		}
+		netchall, e := challenge.NetEncode()
+		if e != nil {
+			return nil, round.Abort(fmt.Errorf("Error when encoding the challenge for server %d: %s", k, e))
+		}
+		var rcvchall daga.NetChallengeCheck
+		if e = node.call(round, k, typeChallengeCheck, session, netchall, &rcvchall); e != nil {
+			return nil, round.Abort(e)
+		}
+		challenge, e = rcvchall.NetDecode(node.context.Suite())
+		if e != nil {
+			return nil, round.Abort(fmt.Errorf("Error when decoding the challenge of server %d: %s", k, e))
+		}
+		if e = round.UpdateChallenge(challenge); e != nil {
+			return nil, round.Abort(e)
+		}
+	}
+
+	return round.Issued()
+}
+
+/*Authenticate runs the server protocol on a client message with this node as the entry point
+An interactive message must answer the issued challenge, a non-interactive one comes without
+The message goes through every server of the context, the completed ServerMessage is returned
+The servers share the context, so the message references it instead of carrying it on every hop*/
+func (node *Node) Authenticate(request *daga.ClientMessage, issued *daga.IssuedChallenge) (*daga.ServerMessage, error) {
+	round, err := daga.NewTagRound(node.server, node.context, issued, node.RoundTimeout)
+	if err != nil {
+		return nil, err
+	}
+	msg, k, err := round.ProcessRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for k != -1 {
+		netmsg, e := msg.NetEncodeRef()
+		if e != nil {
+			return nil, round.Abort(fmt.Errorf("Error when encoding the server message for server %d: %s", k, e))
+		}
+		var rcvmsg daga.NetServerMessageRef
+		if e = node.call(round, k, typeServerMessageRef, "", netmsg, &rcvmsg); e != nil {
+			return nil, round.Abort(e)
+		}
+		msg, e = rcvmsg.NetDecode(node.contexts)
+		if e != nil {
+			return nil, round.Abort(fmt.Errorf("Error when decoding the server message of server %d: %s", k, e))
+		}
+		if k, e = round.UpdateServerMessage(msg); e != nil {
+			return nil, e
+		}
+	}
+
+	return round.ServerMessage()
+}
+
+/*call sends a request to the server of index k and decodes its answer into out
+The server must answer before the deadline of the current phase of the round*/
+func (node *Node) call(round *daga.Round, k int, typ, session string, data, out interface{}) error {
+	if err := round.CheckDeadline(); err != nil {
+		return err
+	}
+	timeout := node.Timeout
+	if left := round.Remaining(); left < timeout {
+		timeout = left
+	}
+	err := exchange(node.peers[k], timeout, typ, session, data, out)
+	if err != nil {
+		return fmt.Errorf("No valid answer from server %d: %s", k, err)
+	}
+	return nil
+}
+
+/*exchange opens a connection to addr, sends a single request and decodes the answer into out
+The failures of the connection are returned as a *daga.TransportError, the error sent by the server as is*/
+func exchange(addr string, timeout time.Duration, typ, session string, data, out interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Cannot marshal request: %s", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return &daga.TransportError{Err: fmt.Errorf("Cannot connect to %s: %s", addr, err)}
+	}
+	defer conn.Close()
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if err = json.NewEncoder(conn).Encode(request{Type: typ, Session: session, Data: raw}); err != nil {
+		return &daga.TransportError{Err: fmt.Errorf("Cannot send request: %s", err)}
+	}
+	var resp response
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return &daga.TransportError{Err: fmt.Errorf("Cannot read response: %s", err)}
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	if err = json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("Cannot unmarshal response: %s", err)
+	}
+	return nil
+}
+
+/*newSession returns a random identifier for a challenge generation*/
+func newSession() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("Cannot generate session: %s", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
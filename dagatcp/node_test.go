@@ -0,0 +1,467 @@
+package dagatcp
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_pop_fs/daga"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+/*startNodes creates a context with c clients and s servers and runs each server as a node on a localhost port*/
+func startNodes(t *testing.T, c, s int) (clients []daga.Client, nodes []*Node, context *daga.Context) {
+	var X []abstract.Point
+	for i := 0; i < c; i++ {
+		client, err := daga.CreateClient(daga.Suite, i, nil)
+		if err != nil {
+			t.Fatalf("Cannot create clients: %s", err)
+		}
+		clients = append(clients, client)
+		X = append(X, client.GetPublicKey())
+	}
+
+	var Y, R []abstract.Point
+	var servers []*daga.Server
+	for j := 0; j < s; j++ {
+		server, err := daga.CreateServer(daga.Suite, j, nil)
+		if err != nil {
+			t.Fatalf("Cannot create servers: %s", err)
+		}
+		servers = append(servers, &server)
+		Y = append(Y, server.GetPublicKey())
+		R = append(R, server.GenerateNewRoundSecret())
+	}
+
+	var H []abstract.Point
+	for i := range X {
+		temp, err := daga.GenerateClientGenerator(daga.Suite, i, &R)
+		if err != nil {
+			t.Fatalf("Cannot generate the client generators: %s", err)
+		}
+		H = append(H, temp)
+	}
+	context = &daga.Context{G: daga.Members{X: X, Y: Y}, R: R, H: H}
+
+	var listeners []net.Listener
+	var peers []string
+	for j := 0; j < s; j++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Cannot listen: %s", err)
+		}
+		listeners = append(listeners, listener)
+		peers = append(peers, listener.Addr().String())
+	}
+
+	for j := 0; j < s; j++ {
+		node, err := NewNode(servers[j], context, peers)
+		if err != nil {
+			t.Fatalf("Cannot create node %d: %s", j, err)
+		}
+		node.Timeout = 5 * time.Second
+		nodes = append(nodes, node)
+		go node.Serve(listeners[j])
+	}
+
+	return clients, nodes, context
+}
+
+/*clientCommitments returns the proof commitments t of a fresh request by the client*/
+func clientCommitments(client daga.Client, context *daga.Context) []abstract.Point {
+	T0, _, s, _ := client.CreateRequest(context)
+	tclient, _, _ := client.GenerateProofCommitments(context, T0, s)
+	return *tclient
+}
+
+/*simulateProof replaces the proof of the encoded request with a proof simulated for the challenge cs, without any private key
+The commitments t are chosen after cs, so the servers must not accept it for an interactive challenge they did not sign for t*/
+func simulateProof(netmsg *daga.NetClientMessage, context *daga.Context, cs abstract.Scalar) error {
+	suite := daga.Suite
+	T0, err := netmsg.T0.NetDecode(suite)
+	if err != nil {
+		return err
+	}
+	S, err := daga.NetDecodePoints(suite, netmsg.SArray)
+	if err != nil {
+		return err
+	}
+	Sm := S[len(S)-1]
+
+	n := len(context.G.X)
+	c := make([]abstract.Scalar, n)
+	r := make([]abstract.Scalar, 2*n)
+	tproof := make([]abstract.Point, 3*n)
+	sum := suite.Scalar().Zero()
+	for i := 0; i < n; i++ {
+		if i < n-1 {
+			c[i] = suite.Scalar().Pick(random.Stream)
+			sum = suite.Scalar().Add(sum, c[i])
+		} else {
+			c[i] = suite.Scalar().Sub(cs, sum)
+		}
+		r[2*i] = suite.Scalar().Pick(random.Stream)
+		r[2*i+1] = suite.Scalar().Pick(random.Stream)
+		H, err := context.ClientGenerator(i, nil)
+		if err != nil {
+			return err
+		}
+		tproof[3*i] = suite.Point().Add(suite.Point().Mul(context.G.X[i], c[i]), suite.Point().Mul(nil, r[2*i]))
+		tproof[3*i+1] = suite.Point().Add(suite.Point().Mul(Sm, c[i]), suite.Point().Mul(nil, r[2*i+1]))
+		tproof[3*i+2] = suite.Point().Add(suite.Point().Mul(T0, c[i]), suite.Point().Mul(H, r[2*i+1]))
+	}
+
+	netcs, err := daga.NetEncodeScalar(cs)
+	if err != nil {
+		return err
+	}
+	nett, err := daga.NetEncodePoints(tproof)
+	if err != nil {
+		return err
+	}
+	netc, err := daga.NetEncodeScalars(c)
+	if err != nil {
+		return err
+	}
+	netr, err := daga.NetEncodeScalars(r)
+	if err != nil {
+		return err
+	}
+	netmsg.Proof = daga.NetClientProof{Cs: *netcs, T: nett, C: netc, R: netr}
+	return nil
+}
+
+func stopNodes(nodes []*Node) {
+	for _, node := range nodes {
+		node.Close()
+	}
+}
+
+func TestNewNode(t *testing.T) {
+	server, _ := daga.CreateServer(daga.Suite, 0, nil)
+	context := &daga.Context{G: daga.Members{Y: []abstract.Point{server.GetPublicKey()}}}
+
+	//Normal execution
+	node, err := NewNode(&server, context, []string{"127.0.0.1:0"})
+	if err != nil || node == nil {
+		t.Error("Cannot create a node")
+	}
+
+	//Empty inputs
+	node, err = NewNode(nil, context, []string{"127.0.0.1:0"})
+	if err == nil || node != nil {
+		t.Error("Wrong check: Empty server")
+	}
+	node, err = NewNode(&server, nil, []string{"127.0.0.1:0"})
+	if err == nil || node != nil {
+		t.Error("Wrong check: Empty context")
+	}
+
+	//Wrong number of peers
+	node, err = NewNode(&server, context, []string{"127.0.0.1:0", "127.0.0.1:1"})
+	if err == nil || node != nil {
+		t.Error("Wrong check: Too many peers")
+	}
+
+	//Index out of range
+	other, _ := daga.CreateServer(daga.Suite, 1, nil)
+	node, err = NewNode(&other, context, []string{"127.0.0.1:0"})
+	if err == nil || node != nil {
+		t.Error("Wrong check: Index out of range")
+	}
+}
+
+func TestGenerateChallenge(t *testing.T) {
+	clients, nodes, context := startNodes(t, 3, 4)
+	defer stopNodes(nodes)
+	tclient := clientCommitments(clients[0], context)
+
+	for j, node := range nodes {
+		issued, err := node.GenerateChallenge(tclient)
+		if err != nil || issued == nil || issued.Challenge() == nil {
+			t.Errorf("Cannot generate the challenge with leader %d: %s", j, err)
+		}
+	}
+
+	//Wrong commitments
+	challenge, err := nodes[0].GenerateChallenge(tclient[1:])
+	if err == nil || challenge != nil {
+		t.Error("Wrong check: Wrong number of commitments")
+	}
+
+	//Unreachable peer
+	nodes[1].Close()
+	challenge, err = nodes[0].GenerateChallenge(tclient)
+	if err == nil || challenge != nil {
+		t.Error("Wrong check: Unreachable peer")
+	}
+}
+
+func TestGenerateChallenge_Timeout(t *testing.T) {
+	clients, nodes, context := startNodes(t, 1, 3)
+	defer stopNodes(nodes)
+
+	//Server 2 accepts connections but never answers
+	nodes[2].Close()
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Cannot listen: %s", err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	nodes[0].peers[2] = silent.Addr().String()
+	nodes[0].RoundTimeout = 200 * time.Millisecond
+
+	start := time.Now()
+	challenge, err := nodes[0].GenerateChallenge(clientCommitments(clients[0], context))
+	if err == nil || challenge != nil {
+		t.Fatal("Wrong check: Silent peer")
+	}
+	if !strings.Contains(err.Error(), "Round aborted in phase commitment") || !strings.Contains(err.Error(), "server 2") {
+		t.Errorf("Unclear error: %s", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Round deadline not enforced")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	clients, nodes, context := startNodes(t, 3, 4)
+	defer stopNodes(nodes)
+
+	T0, S, s, _ := clients[1].CreateRequest(context)
+	tclient, v, w := clients[1].GenerateProofCommitments(context, T0, s)
+	issued, err := nodes[2].GenerateChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot generate the challenge: %s", err)
+	}
+	challenge := issued.Challenge()
+	c, r, _ := clients[1].GenerateProofResponses(context, s, challenge, v, w)
+	msg := clients[1].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+
+	servmsg, err := nodes[3].Authenticate(msg, issued)
+	if err != nil || servmsg == nil {
+		t.Fatalf("Cannot authenticate: %s", err)
+	}
+	Tf, err := clients[1].GetFinalLinkageTag(context, servmsg)
+	if err != nil || Tf == nil || Tf.Equal(daga.Suite.Point().Null()) {
+		t.Errorf("Invalid final linkage tag: %s", err)
+	}
+
+	//Interactive request without the challenge it answers
+	servmsg, err = nodes[3].Authenticate(msg, nil)
+	if err == nil || servmsg != nil {
+		t.Error("Wrong check: No issued challenge")
+	}
+
+	//Request answering another challenge
+	other, err := nodes[0].GenerateChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot generate the challenge: %s", err)
+	}
+	servmsg, err = nodes[0].Authenticate(msg, other)
+	if err == nil || servmsg != nil {
+		t.Error("Wrong check: Another challenge")
+	}
+
+	//Non-interactive request
+	msg, err = clients[0].CreateNonInteractiveMessage(context)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	servmsg, err = nodes[1].Authenticate(msg, nil)
+	if err != nil || servmsg == nil {
+		t.Errorf("Cannot authenticate a non-interactive request: %s", err)
+	}
+
+	//Empty request
+	servmsg, err = nodes[0].Authenticate(nil, nil)
+	if err == nil || servmsg != nil {
+		t.Error("Wrong check: Empty request")
+	}
+}
+
+/*TestForgedChallenge checks that a client cannot answer a challenge with commitments chosen after seeing it, nor reuse it*/
+func TestForgedChallenge(t *testing.T) {
+	clients, nodes, context := startNodes(t, 3, 3)
+	defer stopNodes(nodes)
+	remote := &Remote{Suite: daga.Suite, Address: nodes[0].peers[0], Timeout: 5 * time.Second}
+
+	//Commitments chosen after the challenge
+	challenge, err := remote.RequestChallenge(clientCommitments(clients[0], context))
+	if err != nil {
+		t.Fatalf("Cannot request the challenge: %s", err)
+	}
+	session := remote.session
+	T0, S, s, _ := clients[0].CreateRequest(context)
+	tclient, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	c, r, _ := clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	msg := clients[0].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+	if msg != nil {
+		t.Error("Wrong check: Message assembled for commitments chosen after the challenge")
+	}
+
+	//Proof simulated for the signed challenge
+	msg, _ = clients[0].CreateNonInteractiveMessage(context)
+	netmsg, _ := msg.NetEncode()
+	netchall, _ := challenge.NetEncode()
+	cs, _ := netchall.Cs.NetDecode(daga.Suite)
+	if err = simulateProof(netmsg, context, cs); err != nil {
+		t.Fatalf("Cannot simulate the proof: %s", err)
+	}
+	netmsg.Proof.Sigs = netchall.Sigs
+	var out daga.NetServerMessage
+	err = exchange(remote.Address, remote.Timeout, typeClientMessage, session, netmsg, &out)
+	if err == nil {
+		t.Error("Wrong check: Commitments chosen after the challenge")
+	}
+
+	//The challenge was consumed by the rejected message
+	err = exchange(remote.Address, remote.Timeout, typeClientMessage, session, netmsg, &out)
+	if err == nil || !strings.Contains(err.Error(), "Unknown session") {
+		t.Errorf("Wrong check: Replayed challenge: %s", err)
+	}
+
+	//A correct answer is accepted once
+	challenge, err = remote.RequestChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot request the challenge: %s", err)
+	}
+	session = remote.session
+	c, r, _ = clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	msg = clients[0].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+	servmsg, err := remote.SubmitMessage(msg)
+	if err != nil || servmsg == nil {
+		t.Fatalf("Cannot authenticate: %s", err)
+	}
+	remote.session = session
+	servmsg, err = remote.SubmitMessage(msg)
+	if err == nil || servmsg != nil {
+		t.Error("Wrong check: Replayed message")
+	}
+
+	//Interactive message without a session
+	servmsg, err = remote.SubmitMessage(msg)
+	if err == nil || servmsg != nil {
+		t.Error("Wrong check: No session")
+	}
+}
+
+/*TestForgedServerMessage checks that the servers do not run the protocol for a message from a peer whose client proof was simulated for a challenge they never signed*/
+func TestForgedServerMessage(t *testing.T) {
+	clients, nodes, context := startNodes(t, 3, 3)
+	defer stopNodes(nodes)
+
+	msg, _ := clients[0].CreateNonInteractiveMessage(context)
+	netmsg, _ := msg.NetEncode()
+	ref, _ := msg.NetEncodeRef()
+	forged := daga.NetServerMessage{ContextID: netmsg.ContextID, Request: *netmsg}
+	forgedRef := daga.NetServerMessageRef{ContextID: ref.ContextID, Request: *ref}
+
+	//Simulated proof for a challenge chosen by the sender
+	cs := daga.Suite.Scalar().Pick(random.Stream)
+	if err := simulateProof(&forged.Request, context, cs); err != nil {
+		t.Fatalf("Cannot simulate the proof: %s", err)
+	}
+	forgedRef.Request.Proof = forged.Request.Proof
+	var out interface{}
+	if err := exchange(nodes[0].peers[1], time.Second, typeServerMessage, "", forged, &out); err == nil {
+		t.Error("Wrong check: Unsigned challenge")
+	}
+	if err := exchange(nodes[0].peers[1], time.Second, typeServerMessageRef, "", forgedRef, &out); err == nil {
+		t.Error("Wrong check: Unsigned challenge in a message referencing the context")
+	}
+
+	//Simulated proof for a challenge signed for other commitments
+	challenge, err := nodes[0].GenerateChallenge(clientCommitments(clients[0], context))
+	if err != nil {
+		t.Fatalf("Cannot generate the challenge: %s", err)
+	}
+	netchall, _ := challenge.Challenge().NetEncode()
+	cs, _ = netchall.Cs.NetDecode(daga.Suite)
+	if err = simulateProof(&forged.Request, context, cs); err != nil {
+		t.Fatalf("Cannot simulate the proof: %s", err)
+	}
+	forged.Request.Proof.Sigs = netchall.Sigs
+	forgedRef.Request.Proof = forged.Request.Proof
+	if err = exchange(nodes[0].peers[1], time.Second, typeServerMessage, "", forged, &out); err == nil {
+		t.Error("Wrong check: Challenge signed for other commitments")
+	}
+	if err = exchange(nodes[0].peers[1], time.Second, typeServerMessageRef, "", forgedRef, &out); err == nil {
+		t.Error("Wrong check: Challenge signed for other commitments in a message referencing the context")
+	}
+}
+
+func TestUnknownRequest(t *testing.T) {
+	_, nodes, _ := startNodes(t, 1, 1)
+	defer stopNodes(nodes)
+
+	var out interface{}
+	err := exchange(nodes[0].peers[0], time.Second, "unknown", "", nil, &out)
+	if err == nil {
+		t.Error("Wrong check: Unknown request type")
+	}
+
+	//Opening for a session that was never started
+	open, _ := daga.NetEncodeScalar(daga.Suite.Scalar().One())
+	err = exchange(nodes[0].peers[0], time.Second, typeOpening, "missing", open, &out)
+	if err == nil {
+		t.Error("Wrong check: Unknown session")
+	}
+}
+
+func TestUnknownContext(t *testing.T) {
+	_, nodes, _ := startNodes(t, 2, 2)
+	defer stopNodes(nodes)
+	clients, others, other := startNodes(t, 2, 2)
+	defer stopNodes(others)
+
+	//A request built for another context is rejected before being decoded
+	msg, err := clients[0].CreateNonInteractiveMessage(other)
+	if err != nil {
+		t.Fatalf("Cannot create the request: %s", err)
+	}
+	netmsg, _ := msg.NetEncode()
+	var out daga.NetServerMessage
+	err = exchange(nodes[0].peers[0], time.Second, typeClientMessage, "", netmsg, &out)
+	if err == nil || !strings.Contains(err.Error(), "Unknown context") {
+		t.Errorf("Wrong check: Unknown context: %s", err)
+	}
+}
+
+/*TestMisbehavingClient checks that the proofs of a misbehaving client, which have no r2, go through the servers*/
+func TestMisbehavingClient(t *testing.T) {
+	clients, nodes, context := startNodes(t, 2, 3)
+	defer stopNodes(nodes)
+	remote := NewRemote(daga.Suite, nodes[1].peers[1])
+
+	T0, S, s, _ := clients[0].CreateRequest(context)
+	tclient, v, w := clients[0].GenerateProofCommitments(context, T0, s)
+	challenge, err := remote.RequestChallenge(*tclient)
+	if err != nil {
+		t.Fatalf("Cannot get the challenge: %s", err)
+	}
+	c, r, _ := clients[0].GenerateProofResponses(context, s, challenge, v, w)
+	//Wrong commitment for server 0, the proof of the client only covers the last one
+	S[2] = daga.Suite.Point().Mul(nil, daga.Suite.Scalar().Pick(random.Stream))
+	msg := clients[0].AssembleMessage(context, &S, T0, challenge, tclient, c, r)
+
+	servmsg, err := remote.SubmitMessage(msg)
+	if err != nil || servmsg == nil {
+		t.Fatalf("Cannot authenticate a misbehaving client: %s", err)
+	}
+	Tf, err := clients[0].GetFinalLinkageTag(context, servmsg)
+	if err != nil || !Tf.Equal(daga.Suite.Point().Null()) {
+		t.Errorf("Wrong final linkage tag for a misbehaving client: %s", err)
+	}
+}